@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/model"
+)
+
+// modelKeyBytes decodes the NFWS_MODEL_KEY hex into AES-256 key
+// material; empty input means encrypted models are not in use.
+func modelKeyBytes(hexKey string) ([]byte, error) {
+	if hexKey == "" {
+		return nil, nil
+	}
+	key, err := hex.DecodeString(hexKey)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("NFWS_MODEL_KEY must be 64 hex characters (32 bytes)")
+	}
+	return key, nil
+}
+
+// encryptModelCmd implements "nfws-api encrypt-model <in.onnx>
+// [out.onnx.enc]", sealing a plaintext model file with NFWS_MODEL_KEY
+// so it can sit on a shared volume encrypted at rest. The server
+// decrypts it into memory at load time; the plaintext can then be
+// removed from the volume.
+func encryptModelCmd(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("usage: nfws-api encrypt-model <in.onnx> [out.onnx.enc]")
+	}
+	key, err := modelKeyBytes(os.Getenv("NFWS_MODEL_KEY"))
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return errors.New("encrypt-model requires NFWS_MODEL_KEY")
+	}
+	in := args[0]
+	out := in + ".enc"
+	if len(args) == 2 {
+		out = args[1]
+	}
+	if strings.HasSuffix(in, ".enc") {
+		return fmt.Errorf("%s is already encrypted", in)
+	}
+	plain, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	sealed, err := model.EncryptModel(key, plain)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, sealed, 0o600); err != nil {
+		return err
+	}
+	fmt.Printf("%s -> %s (%d bytes)\n", in, out, len(sealed))
+	return nil
+}
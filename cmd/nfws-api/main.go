@@ -0,0 +1,445 @@
+// Command nfws-api serves the NFWS moderation HTTP API.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/auth"
+	"github.com/illussioon/NFWS-Moderations-API/internal/bus"
+	"github.com/illussioon/NFWS-Moderations-API/internal/cache"
+	"github.com/illussioon/NFWS-Moderations-API/internal/chaos"
+	"github.com/illussioon/NFWS-Moderations-API/internal/config"
+	"github.com/illussioon/NFWS-Moderations-API/internal/digest"
+	"github.com/illussioon/NFWS-Moderations-API/internal/drift"
+	"github.com/illussioon/NFWS-Moderations-API/internal/export"
+	"github.com/illussioon/NFWS-Moderations-API/internal/flags"
+	"github.com/illussioon/NFWS-Moderations-API/internal/hashlist"
+	"github.com/illussioon/NFWS-Moderations-API/internal/inference"
+	"github.com/illussioon/NFWS-Moderations-API/internal/jobs"
+	"github.com/illussioon/NFWS-Moderations-API/internal/labels"
+	"github.com/illussioon/NFWS-Moderations-API/internal/model"
+	"github.com/illussioon/NFWS-Moderations-API/internal/plugin"
+	"github.com/illussioon/NFWS-Moderations-API/internal/policy"
+	"github.com/illussioon/NFWS-Moderations-API/internal/provider"
+	"github.com/illussioon/NFWS-Moderations-API/internal/rescan"
+	"github.com/illussioon/NFWS-Moderations-API/internal/s3"
+	"github.com/illussioon/NFWS-Moderations-API/internal/scan"
+	"github.com/illussioon/NFWS-Moderations-API/internal/seal"
+	"github.com/illussioon/NFWS-Moderations-API/internal/server"
+	"github.com/illussioon/NFWS-Moderations-API/internal/stats"
+	"github.com/illussioon/NFWS-Moderations-API/internal/store"
+)
+
+func main() {
+	log := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "encrypt-model":
+			err = encryptModelCmd(os.Args[2:])
+		case "validate-config":
+			err = validateConfigCmd()
+		case "migrate":
+			err = migrateCmd()
+		default:
+			err = fmt.Errorf("unknown subcommand %q", os.Args[1])
+		}
+		if err != nil {
+			log.Error("fatal", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := run(log); err != nil {
+		log.Error("fatal", "err", err)
+		os.Exit(1)
+	}
+}
+
+// waitForBackend retries fn once a second until it succeeds or wait
+// runs out, logging progress so a slow dependency is visible instead of
+// a silent hang or an instant crash-loop.
+func waitForBackend(wait time.Duration, name string, fn func(context.Context) error, log *slog.Logger) error {
+	start := time.Now()
+	deadline := start.Add(wait)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		err := fn(ctx)
+		cancel()
+		if err == nil {
+			if waited := time.Since(start); waited > time.Second {
+				log.Info("dependency ready", "backend", name, "waited", waited.Round(time.Second).String())
+			}
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s backend not ready after %s: %w", name, wait, err)
+		}
+		log.Info("waiting for dependency", "backend", name, "err", err.Error(), "elapsed", time.Since(start).Round(time.Second).String())
+		time.Sleep(time.Second)
+	}
+}
+
+func run(log *slog.Logger) error {
+	cfg, err := config.Load()
+	if problems := cfg.Validate(false); err != nil || len(problems) > 0 {
+		return errors.Join(append([]error{err}, problems...)...)
+	}
+
+	var engine inference.Engine
+	if cfg.MockInference {
+		log.Warn("MOCK_INFERENCE enabled: scores are deterministic fakes, not model output")
+		engine = inference.NewMockEngine()
+	} else {
+		gpu := inference.GPUConfig{Enabled: cfg.GPU, Device: cfg.GPUDevice}
+		if len(cfg.GPUModels) > 0 {
+			gpu.Models = make(map[string]bool, len(cfg.GPUModels))
+			for _, m := range cfg.GPUModels {
+				gpu.Models[m] = true
+			}
+		}
+		engine, err = inference.NewEngine(gpu)
+		if err != nil {
+			return err
+		}
+		if cfg.GPU {
+			log.Info("gpu execution requested", "device", cfg.GPUDevice, "models", cfg.GPUModels)
+		}
+	}
+	preload := make(map[string]string, len(cfg.ModelPreload))
+	for name, hint := range cfg.ModelPreload {
+		preload[name] = hint.Mode
+	}
+	modelKey, err := modelKeyBytes(cfg.ModelKey)
+	if err != nil {
+		return err
+	}
+	if modelKey != nil {
+		log.Info("encrypted model support enabled")
+	}
+	registry, err := model.NewRegistry(engine, cfg.ModelsDir, preload, modelKey)
+	if err != nil {
+		return err
+	}
+	defer registry.Close()
+	if cfg.PreferQuantized {
+		if err := registry.PreferQuantized(); err != nil {
+			return err
+		}
+		log.Info("quantized model variants preferred where available")
+	}
+
+	st, err := store.Open(cfg.StoreDSN)
+	if err != nil {
+		return err
+	}
+	if store.Shared(cfg.StoreDSN) {
+		if cfg.StartupWait > 0 {
+			if err := waitForBackend(cfg.StartupWait, "store", st.Ping, log); err != nil {
+				st.Close()
+				return err
+			}
+		}
+		// Shared backends ride through outages: operations fall back to
+		// process-local memory while a prober reconnects.
+		st = store.NewResilient(st, log)
+	}
+	defer st.Close()
+
+	staticFlags := make(map[string]flags.Flag)
+	if cfg.FlagsFile != "" {
+		if staticFlags, err = flags.LoadFile(cfg.FlagsFile); err != nil {
+			return err
+		}
+	}
+	inlineFlags, err := flags.ParseSpec(cfg.FlagsSpec)
+	if err != nil {
+		return err
+	}
+	for name, f := range inlineFlags {
+		staticFlags[name] = f
+	}
+	flagSet := flags.New(staticFlags)
+	if err := flagSet.LoadStored(context.Background(), st); err != nil {
+		return err
+	}
+	if len(staticFlags) > 0 {
+		log.Info("feature flags configured", "flags", len(staticFlags))
+	}
+
+	collector := stats.NewCollector()
+	if cfg.ClusterMode {
+		collector = stats.NewShared(st)
+		log.Info("cluster mode enabled: stats aggregate via shared store")
+	}
+
+	scanner := scan.New(registry, cfg.DownloadTimeout, cfg.MaxImageBytes, cfg.NSFWThreshold)
+	chaosInj := chaos.NewInjector()
+	scanner.SetChaos(chaosInj)
+	scanner.SetAdultThreshold(cfg.AdultThreshold)
+	scanner.SetThumbnailParams(cfg.ThumbnailSize, cfg.ThumbnailBlur)
+	scanner.SetIgnoreMargin(cfg.IgnoreMargin)
+	scanner.SetCascadeMargin(cfg.CascadeMargin)
+	scanner.SetLottieRenderer(cfg.LottieRenderer)
+	scanner.SetImageConverter(cfg.ImageConverter)
+	scanner.SetVideoDecoder(cfg.VideoFFmpeg, cfg.VideoFPS)
+	scanner.SetReproducible(cfg.Reproducible)
+	if cfg.Reproducible {
+		log.Info("reproducibility mode enabled: verdicts carry tensor digests and runtime provenance")
+	}
+	if cfg.LabelsFile != "" {
+		table, err := labels.Load(cfg.LabelsFile)
+		if err != nil {
+			return err
+		}
+		scanner.SetLabels(table)
+		log.Info("label mapping enabled", "file", cfg.LabelsFile)
+	}
+	if cfg.PolicyFile != "" {
+		rules, err := policy.Load(cfg.PolicyFile)
+		if err != nil {
+			return err
+		}
+		scanner.SetPolicy(rules)
+		log.Info("policy rules enabled", "file", cfg.PolicyFile, "rules", rules.Len())
+	}
+
+	// The event bus decouples integrations from the handlers: the
+	// exporter and the generic webhook are just subscribers, and new
+	// ones plug in here without touching the scan path.
+	events := bus.New(log)
+	defer events.Close()
+	if cfg.ExportSink != "" {
+		sink, err := export.NewSink(cfg.ExportSink, cfg.ExportURL, cfg.ExportTable, cfg.ExportUsername, cfg.ExportPassword)
+		if err != nil {
+			return err
+		}
+		exporter := export.New(sink, cfg.ExportBatchSize, cfg.ExportFlushInterval, log)
+		defer exporter.Close()
+		events.Subscribe("exporter", func(ev bus.Event) {
+			if e, ok := ev.Data.(export.Event); ok {
+				exporter.Emit(e)
+			}
+		}, bus.ScanCompleted)
+		log.Info("scan event export enabled", "sink", sink.Name())
+	}
+	if cfg.EventsWebhookURL != "" {
+		events.Subscribe("webhook", bus.Webhook(cfg.EventsWebhookURL, log), cfg.EventsWebhookTypes...)
+		log.Info("event webhook enabled", "types", cfg.EventsWebhookTypes)
+	}
+	if cfg.WebhooksFile != "" {
+		dests, err := bus.LoadWebhooks(cfg.WebhooksFile)
+		if err != nil {
+			return err
+		}
+		for i := range dests {
+			d := &dests[i]
+			events.Subscribe("webhook:"+d.Name, d.Handler(log), d.Types...)
+		}
+		log.Info("webhook destinations configured", "count", len(dests))
+	}
+	// Names rather than All here: All would pull in preload=lazy models
+	// that should only load on first use.
+	for _, name := range registry.Names() {
+		events.Publish(bus.ModelLoaded, map[string]any{"model": name, "version": registry.ActiveVersion(name)})
+	}
+
+	var s3c *s3.Client
+	if cfg.S3Endpoint != "" {
+		s3c = &s3.Client{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		}
+		log.Info("s3 support enabled", "endpoint", cfg.S3Endpoint, "writeback", cfg.S3WritebackMode)
+	}
+
+	var prov provider.Provider
+	if cfg.Provider != "" {
+		prov, err = provider.New(cfg.Provider, cfg.ProviderURL, cfg.ProviderKey, cfg.ProviderTimeout)
+		if err != nil {
+			return err
+		}
+		log.Info("borderline escalation enabled", "provider", prov.Name(), "margin", cfg.ProviderMargin)
+	}
+
+	var plug *plugin.Plugin
+	if cfg.PluginFile != "" {
+		plug, err = plugin.Load(context.Background(), cfg.PluginFile, cfg.PluginHook, cfg.PluginTimeout)
+		if err != nil {
+			return err
+		}
+		defer plug.Close(context.Background())
+		log.Info("wasm plugin enabled", "file", cfg.PluginFile, "hook", plug.Hook())
+	}
+
+	var drifter *drift.Detector
+	if cfg.DriftWindow > 0 {
+		drifter = drift.New(cfg.DriftWindow, cfg.DriftThreshold, cfg.DriftWebhookURL, log)
+		log.Info("drift detection enabled", "window", cfg.DriftWindow, "threshold", cfg.DriftThreshold)
+	}
+
+	var keys *auth.Keys
+	if cfg.KeysFile != "" {
+		keys, err = auth.Load(cfg.KeysFile)
+		if err != nil {
+			return err
+		}
+		log.Info("per-key roles enabled", "file", cfg.KeysFile)
+	}
+
+	var preloader *model.PreloadScheduler
+	windows := make(map[string]model.Window)
+	for name, hint := range cfg.ModelPreload {
+		if hint.Mode == model.PreloadOnSchedule {
+			windows[name] = model.Window{From: hint.FromHour, To: hint.ToHour}
+		}
+	}
+	if len(windows) > 0 {
+		preloader = model.NewPreloadScheduler(registry, windows, func(name, version string) {
+			events.Publish(bus.ModelLoaded, map[string]any{"model": name, "version": version})
+		}, log)
+		log.Info("scheduled model preloading enabled", "models", len(windows))
+	}
+
+	sealer, err := seal.New(cfg.PayloadKeys, cfg.PayloadActiveKey)
+	if err != nil {
+		return err
+	}
+	if sealer != nil {
+		log.Info("payload sealing enabled", "keys", len(cfg.PayloadKeys), "active", sealer.Active())
+	}
+
+	var digester *digest.Digest
+	if cfg.DigestSchedule != "" {
+		digester = digest.New(cfg.DigestSchedule, cfg.SMTPAddr, cfg.SMTPFrom, cfg.DigestTo,
+			cfg.SMTPUsername, cfg.SMTPPassword, collector, st, log)
+		events.Subscribe("digest", digester.Record, bus.ScanCompleted)
+		log.Info("moderation digest enabled", "schedule", cfg.DigestSchedule, "recipients", len(cfg.DigestTo))
+	}
+
+	var hashlists *hashlist.Matcher
+	if len(cfg.HashListSources) > 0 {
+		distances := make(map[string]hashlist.Distance, len(cfg.HashListDistances))
+		for name, d := range cfg.HashListDistances {
+			distances[name] = hashlist.Distance{Metric: d.Metric, Max: d.MaxDistance}
+		}
+		hashlists, err = hashlist.Load(cfg.HashListSources, distances)
+		if err != nil {
+			return err
+		}
+		// The alert webhook is not optional: config validation already
+		// required the URL, and it is subscribed unconditionally here.
+		events.Subscribe("hashlist-alert", bus.Webhook(cfg.HashListAlertURL, log), bus.HashMatched)
+		log.Info("industry hash lists enabled", "lists", hashlists.Entries())
+	}
+
+	var scanCache cache.Cache
+	if cfg.CacheDSN != "" {
+		scanCache, err = cache.Open(cfg.CacheDSN)
+		if err != nil && cfg.StartupWait > 0 {
+			// Memcached dials eagerly, so a cache that comes up after us
+			// gets the same grace as the store.
+			err = waitForBackend(cfg.StartupWait, "cache", func(context.Context) error {
+				var openErr error
+				scanCache, openErr = cache.Open(cfg.CacheDSN)
+				return openErr
+			}, log)
+		}
+		if err != nil {
+			return err
+		}
+		defer scanCache.Close()
+		log.Info("scan result caching enabled", "ttl", cfg.CacheTTL)
+	}
+
+	var rescanner *rescan.Runner
+	if cfg.RescanSchedule != "" {
+		rescanner, err = rescan.New(cfg.RescanSchedule, cfg.RescanManifest, scanner, st, s3c, log)
+		if err != nil {
+			return err
+		}
+		log.Info("scheduled rescans enabled", "schedule", cfg.RescanSchedule, "manifest", cfg.RescanManifest)
+	}
+
+	var jobQueue *jobs.Queue
+	if cfg.AsyncWorkers > 0 {
+		jobQueue = jobs.NewQueue(cfg.AsyncWorkers, cfg.JobTTL, log)
+		defer jobQueue.Close()
+		log.Info("asynchronous scans enabled", "workers", cfg.AsyncWorkers, "job_ttl", cfg.JobTTL)
+	}
+
+	srv := server.New(cfg, server.Deps{
+		Stats:    collector,
+		Scanner:  scanner,
+		Chaos:    chaosInj,
+		Events:   events,
+		Store:    st,
+		S3:       s3c,
+		Keys:     keys,
+		Provider: prov,
+		Drift:    drifter,
+		Plugin:   plug,
+		Sealer:   sealer,
+		Flags:    flagSet,
+		Digest:   digester,
+		HashList: hashlists,
+		Cache:    scanCache,
+		Jobs:     jobQueue,
+		Log:      log,
+	})
+
+	httpSrv := &http.Server{
+		Addr:              cfg.ListenAddr,
+		Handler:           srv.Router(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ln, err := listen(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if preloader != nil {
+		preloader.Start(ctx)
+	}
+	if rescanner != nil {
+		rescanner.Start(ctx)
+	}
+	if digester != nil {
+		digester.Start(ctx)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Info("listening", "addr", ln.Addr().String(), "engine", engine.Name(), "models", registry.Names())
+		errCh <- httpSrv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	case <-ctx.Done():
+		log.Info("shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if err := httpSrv.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
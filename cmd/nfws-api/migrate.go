@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/store"
+)
+
+// migrateCmd implements "nfws-api migrate": it brings the NFWS_STORE_DSN
+// schema up to date and exits, for init containers and pipelines that
+// migrate ahead of rolling the service. The server also applies pending
+// migrations at startup, so running this separately is optional.
+func migrateCmd() error {
+	version, err := store.Migrate(os.Getenv("NFWS_STORE_DSN"))
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		fmt.Println("store backend has no schema to migrate")
+		return nil
+	}
+	fmt.Printf("schema at version %d\n", version)
+	return nil
+}
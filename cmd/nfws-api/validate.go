@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/config"
+)
+
+// validateConfigCmd implements "nfws-api validate-config": it loads the
+// configuration from the environment and runs the full check set,
+// including probing the listen address, printing every problem found.
+// Deploy pipelines run it before rolling a config change so a broken
+// environment fails the pipeline instead of the service.
+func validateConfigCmd() error {
+	cfg, err := config.Load()
+	count := 0
+	if err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Println(line)
+			count++
+		}
+	}
+	for _, p := range cfg.Validate(true) {
+		fmt.Println(p)
+		count++
+	}
+	if count > 0 {
+		return fmt.Errorf("configuration invalid: %d problem(s)", count)
+	}
+	fmt.Println("configuration OK")
+	return nil
+}
@@ -0,0 +1,373 @@
+// Package api defines the wire types shared between handlers and clients.
+package api
+
+// Response schema versions selectable per request.
+const (
+	// SchemaV1 is the original flat shape: one overall nsfw/score pair.
+	SchemaV1 = "v1"
+	// SchemaV2 adds a categorized taxonomy (nudity, suggestive, ...)
+	// with a score and verdict per category.
+	SchemaV2 = "v2"
+)
+
+// ScanRequest is the body of POST /scan. Exactly one of URL or ImageBase64
+// must be set.
+type ScanRequest struct {
+	// URL of the image to download and scan.
+	URL string `json:"url,omitempty"`
+	// ImageBase64 is the raw image, base64-encoded.
+	ImageBase64 string `json:"image_base64,omitempty"`
+	// Models restricts the scan to the named models; empty means all
+	// loaded models.
+	Models []string `json:"models,omitempty"`
+	// Threshold overrides the server's NSFW threshold for this request.
+	Threshold *float64 `json:"threshold,omitempty"`
+	// Debug adds the timings breakdown to the response.
+	Debug bool `json:"debug,omitempty"`
+	// Schema selects the response shape: SchemaV1 (default) or SchemaV2.
+	Schema string `json:"schema,omitempty"`
+	// Lang requests localized display names on detections, when the
+	// server's label-mapping table has translations for that language.
+	Lang string `json:"lang,omitempty"`
+	// Aggregation selects how per-frame scores fold into the overall
+	// score for animated inputs: "max" (default), "mean", "topk", or
+	// "streak".
+	Aggregation string `json:"aggregation,omitempty"`
+	// AggregationK is the k for the topk (top-k mean) and streak
+	// (consecutive frames) strategies; defaults to 3.
+	AggregationK int `json:"aggregation_k,omitempty"`
+	// Thumbnail requests a small heavily-blurred preview when the scan
+	// is flagged, returned inline or as an uploaded object depending on
+	// server configuration.
+	Thumbnail bool `json:"thumbnail,omitempty"`
+	// Receipt requests a signed JWT receipt of the verdict. Requires the
+	// server to have a receipt secret configured.
+	Receipt bool `json:"receipt,omitempty"`
+	// CallbackURL, when set, has the ScanResponse POSTed to it once the
+	// scan completes — with an HMAC signature header when the server
+	// has a callback secret — so async callers don't have to poll.
+	CallbackURL string `json:"callback_url,omitempty"`
+	// Hashes attaches perceptual hashes to the response: PDQ for the
+	// image, plus TMK for animated inputs — for callers feeding
+	// hash-sharing programs from the moderation pipeline.
+	Hashes bool `json:"hashes,omitempty"`
+	// AgeGate evaluates the scan against the general-audience and the
+	// adult-verified policies at once and returns both verdicts.
+	AgeGate bool `json:"age_gate,omitempty"`
+	// AdultThreshold overrides the server's adult-surface threshold for
+	// this scan; only meaningful with AgeGate.
+	AdultThreshold *float64 `json:"adult_threshold,omitempty"`
+	// Compat adds a compatibility block to the response; "safesearch"
+	// mirrors Google Cloud Vision SafeSearch likelihood buckets.
+	Compat string `json:"compat,omitempty"`
+	// Surface declares which audience surface the content is destined
+	// for (e.g. "minors_app"), for policy rules to match on.
+	Surface string `json:"surface,omitempty"`
+	// StopOnFirstHit ends a multi-frame scan at the first frame whose
+	// score crosses the threshold, skipping the remaining frames — for
+	// callers that only need a yes/no on the whole input.
+	StopOnFirstHit bool `json:"stop_on_first_hit,omitempty"`
+	// Cascade gates the expensive detector models on the classifier
+	// score: detectors run only when a classifier lands in the uncertain
+	// band around the threshold. The response's stages field reports
+	// which stages actually ran.
+	Cascade bool `json:"cascade,omitempty"`
+	// ExternalID and BatchID are opaque upstream correlation IDs. Tagged
+	// scans leave an audit record and carry the IDs on their events, so
+	// an ingestion pipeline can reconcile its records with moderation
+	// outcomes.
+	ExternalID string `json:"external_id,omitempty"`
+	BatchID    string `json:"batch_id,omitempty"`
+}
+
+// ScanResponse is the result of scanning one image.
+type ScanResponse struct {
+	// NSFW is the overall verdict across all models run.
+	NSFW bool `json:"nsfw"`
+	// Score is the highest NSFW-relevant score across models.
+	Score float64 `json:"score"`
+	// Threshold is the value the verdict was computed against.
+	Threshold float64 `json:"threshold"`
+	// Format is the detected image format (jpeg, png, gif, ...).
+	Format string `json:"format"`
+	// Width and Height are the decoded image dimensions.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// Models holds the per-model results, keyed by model name.
+	Models map[string]ModelResult `json:"models"`
+	// Severity is the most explicit severity tier found at or above the
+	// threshold: "explicit", "suggestive", or "contextual". Empty when
+	// nothing with a severity crossed the threshold.
+	Severity string `json:"severity,omitempty"`
+	// Categories breaks the verdict down by taxonomy category; present
+	// only when the request asked for schema v2.
+	Categories map[string]CategoryResult `json:"categories,omitempty"`
+	// Timings is the stage-by-stage latency breakdown; present only when
+	// the request set debug.
+	Timings *Timings `json:"timings,omitempty"`
+	// Frames describes the per-frame score distribution; present only
+	// for animated inputs.
+	Frames *FrameStats `json:"frames,omitempty"`
+	// ThumbnailBase64 is the blurred JPEG preview; present when the
+	// request asked for a thumbnail, the scan was flagged, and no
+	// thumbnail bucket is configured.
+	ThumbnailBase64 string `json:"thumbnail_base64,omitempty"`
+	// ThumbnailURL is the s3:// location of the uploaded preview when a
+	// thumbnail bucket is configured.
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	// QuarantineURL is the s3:// location of the preserved original when
+	// a quarantine bucket is configured and the scan was flagged.
+	QuarantineURL string `json:"quarantine_url,omitempty"`
+	// HashMatch identifies the industry hash-list entry the image
+	// matched; present only on hash-list hits, which skip inference.
+	HashMatch *HashMatch `json:"hash_match,omitempty"`
+	// Hashes carries the requested perceptual hashes.
+	Hashes *PerceptualHashes `json:"hashes,omitempty"`
+	// Receipt is the signed verdict token; present when the request
+	// asked for one and the server signs receipts.
+	Receipt string `json:"receipt,omitempty"`
+	// Surfaces holds the per-surface verdicts ("general" and "adult")
+	// when the request asked for age-gate evaluation.
+	Surfaces map[string]SurfaceVerdict `json:"surfaces,omitempty"`
+	// SafeSearch mirrors Google Cloud Vision SafeSearch buckets when
+	// the request asked for safesearch compatibility.
+	SafeSearch *SafeSearchResult `json:"safe_search,omitempty"`
+	// Rekognition mirrors an AWS DetectModerationLabels response when
+	// the request asked for rekognition compatibility.
+	Rekognition *RekognitionResult `json:"rekognition,omitempty"`
+	// External is the second opinion from the configured escalation
+	// provider; present when the local score was borderline and the
+	// provider was consulted.
+	External *ExternalResult `json:"external,omitempty"`
+	// SampledOut marks a submission that was auto-allowed without
+	// running the models, per the caller's scan_sampling rate.
+	SampledOut bool `json:"sampled_out,omitempty"`
+	// Policies names the policy rules that fired on this scan; a fired
+	// rule flags the verdict regardless of the threshold.
+	Policies []string `json:"policies,omitempty"`
+	// Explanations are plain sentences summarizing what drove a flagged
+	// verdict, suitable for user-facing appeal messages; absent on clean
+	// scans.
+	Explanations []string `json:"explanations,omitempty"`
+	// Stages lists the cascade stages that ran, in order; present only
+	// for cascade scans. "detector" is absent when every frame's
+	// classifier score fell outside the uncertain band.
+	Stages []string `json:"stages,omitempty"`
+	// Segments are the timestamped runs of flagged frames in a video
+	// input, so callers can blur or cut just the offending stretch.
+	Segments []Segment `json:"segments,omitempty"`
+}
+
+// Segment is one contiguous run of sampled video frames that scored at
+// or above the threshold.
+type Segment struct {
+	StartS float64 `json:"start_s"`
+	EndS   float64 `json:"end_s"`
+	// Peak is the highest frame score inside the run.
+	Peak float64 `json:"peak"`
+}
+
+// PerceptualHashes holds the hex-encoded perceptual hashes of a scanned
+// input.
+type PerceptualHashes struct {
+	// PDQ is the 256-bit PDQ hash of the image (the first frame for
+	// animated inputs).
+	PDQ string `json:"pdq,omitempty"`
+	// TMK is the 256-bit time-averaged TMK+PDQF hash; present only for
+	// multi-frame inputs.
+	TMK string `json:"tmk,omitempty"`
+}
+
+// HashMatch records which industry hash list an image matched.
+type HashMatch struct {
+	// List is the configured name of the matched list.
+	List string `json:"list"`
+	// Format is the matched hash's format: md5, sha1, sha256, or pdq.
+	Format string `json:"format"`
+	// Hash is the matched digest, hex-encoded.
+	Hash string `json:"hash"`
+	// Distance is the Hamming bit distance between the image's
+	// perceptual hash and the matched entry; 0 for exact matches.
+	Distance int `json:"distance"`
+}
+
+// ExternalResult records an external provider's second opinion on a
+// borderline scan.
+type ExternalResult struct {
+	Provider string  `json:"provider"`
+	Score    float64 `json:"score"`
+	// Merged reports whether the external score raised the overall one.
+	Merged bool `json:"merged"`
+}
+
+// RekognitionResult matches the shape of an AWS Rekognition
+// DetectModerationLabels response.
+type RekognitionResult struct {
+	ModerationLabels       []ModerationLabel `json:"ModerationLabels"`
+	ModerationModelVersion string            `json:"ModerationModelVersion"`
+}
+
+// ModerationLabel is one Rekognition-style moderation label; Confidence
+// is on Rekognition's 0-100 scale.
+type ModerationLabel struct {
+	Name       string  `json:"Name"`
+	ParentName string  `json:"ParentName"`
+	Confidence float64 `json:"Confidence"`
+}
+
+// SafeSearchResult matches the field names and likelihood values of the
+// Google Cloud Vision SafeSearch annotation. Fields the loaded models
+// can't assess report UNKNOWN.
+type SafeSearchResult struct {
+	Adult    string `json:"adult"`
+	Spoof    string `json:"spoof"`
+	Medical  string `json:"medical"`
+	Violence string `json:"violence"`
+	Racy     string `json:"racy"`
+}
+
+// SurfaceVerdict is the verdict for one audience surface of an
+// age-gated platform.
+type SurfaceVerdict struct {
+	NSFW      bool    `json:"nsfw"`
+	Threshold float64 `json:"threshold"`
+	// Severity is the most explicit tier crossing this surface's
+	// threshold; empty when nothing did.
+	Severity string `json:"severity,omitempty"`
+}
+
+// FrameStats reports how an animated input's frames scored. Models holds
+// the results of the highest-scoring frame; Scores is the full
+// distribution so callers can apply their own policy on top of the
+// aggregate.
+type FrameStats struct {
+	// Count is the number of frames scanned (after sampling).
+	Count int `json:"count"`
+	// Skipped is how many frames were near-duplicates of an already
+	// scanned frame and reused its score instead of running inference.
+	Skipped int `json:"skipped"`
+	// Aggregation is the strategy that produced the overall score.
+	Aggregation string `json:"aggregation"`
+	// Scores is the per-frame overall score, in frame order.
+	Scores []float64 `json:"scores"`
+	// TopFrame is the index (into Scores) of the highest-scoring frame
+	// — the one whose results represent the animation.
+	TopFrame int `json:"top_frame"`
+	// Stopped reports that the scan ended early at the first frame over
+	// the threshold, as requested via stop_on_first_hit; Count and
+	// Scores then cover only the frames actually scanned.
+	Stopped bool `json:"stopped,omitempty"`
+}
+
+// Timings breaks a scan's wall time down by pipeline stage, in
+// milliseconds. Inference and pre/post-processing are summed across all
+// models run.
+type Timings struct {
+	DownloadMS    float64 `json:"download_ms"`
+	DecodeMS      float64 `json:"decode_ms"`
+	PreprocessMS  float64 `json:"preprocess_ms"`
+	InferenceMS   float64 `json:"inference_ms"`
+	PostprocessMS float64 `json:"postprocess_ms"`
+}
+
+// ModelResult is one model's output for an image.
+type ModelResult struct {
+	Kind string `json:"kind"`
+	// Scores maps class label to probability; set for classifiers.
+	Scores map[string]float64 `json:"scores,omitempty"`
+	// Detections lists bounding boxes; set for detectors.
+	Detections []Detection `json:"detections,omitempty"`
+	// NSFWScore is the model's contribution to the overall score.
+	NSFWScore float64 `json:"nsfw_score"`
+	// DurationMS is the inference wall time in milliseconds.
+	DurationMS float64 `json:"duration_ms"`
+	// TensorSHA256 is the digest of the preprocessed input tensor, set
+	// only in reproducibility mode so a replay can prove the model saw
+	// bit-identical input.
+	TensorSHA256 string `json:"tensor_sha256,omitempty"`
+}
+
+// CategoryResult is one taxonomy category's aggregate in a schema v2
+// response.
+type CategoryResult struct {
+	// Score is the highest score any model assigned to a class in this
+	// category.
+	Score float64 `json:"score"`
+	// Flagged is true when Score crosses the threshold. The neutral
+	// category is never flagged.
+	Flagged bool `json:"flagged"`
+}
+
+// Detection is one detector bounding box.
+type Detection struct {
+	Label string `json:"label"`
+	// Display is the localized display name for Label; present only
+	// when the request set lang and the label map has a translation.
+	Display string  `json:"display,omitempty"`
+	Score   float64 `json:"score"`
+	// Box is [x, y, width, height] in pixels of the original image.
+	Box [4]int `json:"box"`
+}
+
+// VerifyRequest is the body of POST /verify.
+type VerifyRequest struct {
+	// SHA256 is the hex digest of the original image bytes.
+	SHA256 string `json:"sha256"`
+}
+
+// FeedbackRequest is the body of POST /feedback: a human review's
+// ground-truth label for a previously scanned image.
+type FeedbackRequest struct {
+	// SHA256 is the hex digest of the original image bytes.
+	SHA256 string `json:"sha256"`
+	// NSFW is the reviewer's verdict.
+	NSFW *bool `json:"nsfw"`
+}
+
+// AppealRequest is the body of POST /appeals: an end user's dispute of
+// a previously scanned image's verdict.
+type AppealRequest struct {
+	// SHA256 is the hex digest of the original image bytes.
+	SHA256 string `json:"sha256"`
+	// Reason is the appellant's stated grounds, required.
+	Reason string `json:"reason"`
+	// Contact optionally identifies where to report the outcome.
+	Contact string `json:"contact,omitempty"`
+}
+
+// VerifyResponse says whether a stored verdict can still be relied on.
+type VerifyResponse struct {
+	// Known is true when a verdict exists for the hash at all.
+	Known bool `json:"known"`
+	// Valid is true when the verdict was produced by the current model
+	// set and is within the TTL; otherwise the content needs a rescan.
+	Valid bool `json:"valid"`
+	// Reason explains an invalid verdict: "unknown", "model_changed",
+	// or "expired".
+	Reason string `json:"reason,omitempty"`
+	// NSFW, Score, and Severity echo the stored verdict when known.
+	NSFW     *bool   `json:"nsfw,omitempty"`
+	Score    float64 `json:"score,omitempty"`
+	Severity string  `json:"severity,omitempty"`
+	// ModelVersion is the model-set fingerprint that produced the
+	// verdict; ScannedAt is when.
+	ModelVersion string `json:"model_version,omitempty"`
+	ScannedAt    string `json:"scanned_at,omitempty"`
+}
+
+// Error is the uniform error envelope for non-2xx responses.
+type Error struct {
+	Error string `json:"error"`
+	// Fields lists what is wrong with which request field, on
+	// validation failures that can be pinned to specific fields.
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError describes one validation failure. Rule is a stable
+// machine-readable identifier ("required", "exclusive", "scheme",
+// "max_size"); Message is the human-readable explanation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,156 @@
+// Package auth loads the API key table and the roles attached to each
+// key. A keys file replaces the single all-or-nothing NFWS_API_KEY with
+// per-key roles: scanner keys may only submit scans, operator keys may
+// only read stats and model info, and admin keys may do everything.
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Role is the capability class attached to an API key.
+type Role string
+
+const (
+	// RoleScanner may call the scanning endpoints (/scan, /verify) only.
+	RoleScanner Role = "scanner"
+	// RoleOperator may read stats and model info but not submit scans.
+	RoleOperator Role = "operator"
+	// RoleAdmin may call everything, including /admin.
+	RoleAdmin Role = "admin"
+)
+
+// Redaction levels for a key's scan responses.
+const (
+	RedactDetections = "detections"
+	RedactVerdict    = "verdict"
+)
+
+// Allows reports whether a key with role r may use an endpoint that
+// requires the given role. Admin keys pass every check; the other roles
+// are deliberately disjoint.
+func (r Role) Allows(required Role) bool {
+	return r == RoleAdmin || r == required
+}
+
+// Key is one entry of the keys file.
+type Key struct {
+	// Key is the secret presented in the X-API-Key header.
+	Key string `json:"key"`
+	// Name identifies the key in logs and audit records. Defaults to a
+	// short digest of the secret so logs never carry the key itself.
+	Name string `json:"name"`
+	Role Role   `json:"role"`
+	// LogSampling is the fraction of this key's requests that get an
+	// access-log line: unset logs everything, 0 opts the key out, 0.01
+	// keeps one line in a hundred. Failed requests are always logged
+	// regardless of the rate.
+	LogSampling *float64 `json:"log_sampling,omitempty"`
+	// MaxImageBytes overrides the server-wide image size cap for this
+	// key, in either direction; 0 keeps the server default.
+	MaxImageBytes int64 `json:"max_image_bytes,omitempty"`
+	// ScanSampling is the fraction of this key's submissions that
+	// actually run through the models; the rest are auto-allowed with a
+	// sampled_out flag. Unset scans everything. For low-risk surfaces
+	// where a statistical NSFW rate is enough.
+	ScanSampling *float64 `json:"scan_sampling,omitempty"`
+	// MinSlots reserves this many concurrent scan slots for the key on a
+	// shared deployment: other tenants can never occupy capacity that
+	// would leave fewer than MinSlots free for it. 0 reserves nothing.
+	MinSlots int `json:"min_slots,omitempty"`
+	// MaxSlots caps how many scan slots the key may hold at once, so a
+	// bulk backfill cannot starve interactive traffic from other
+	// tenants. 0 means no cap beyond the server-wide ceiling.
+	MaxSlots int `json:"max_slots,omitempty"`
+	// Redact strips sensitive detail from this key's scan responses:
+	// "detections" removes detection boxes and per-class scores (labels
+	// a client app must not see), "verdict" reduces the response to the
+	// verdict itself. Empty returns everything.
+	Redact string `json:"redact,omitempty"`
+}
+
+// Keys is the loaded key table.
+type Keys struct {
+	keys []Key
+}
+
+// Load reads a JSON keys file: an array of {"key", "name", "role"}
+// objects.
+func Load(path string) (*Keys, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: %w", err)
+	}
+	var entries []Key
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("auth: parsing %s: %w", path, err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("auth: %s contains no keys", path)
+	}
+	seen := make(map[string]bool, len(entries))
+	for i := range entries {
+		e := &entries[i]
+		if e.Key == "" {
+			return nil, fmt.Errorf("auth: %s: entry %d has an empty key", path, i)
+		}
+		if seen[e.Key] {
+			return nil, fmt.Errorf("auth: %s: entry %d duplicates an earlier key", path, i)
+		}
+		seen[e.Key] = true
+		switch e.Role {
+		case RoleScanner, RoleOperator, RoleAdmin:
+		default:
+			return nil, fmt.Errorf("auth: %s: entry %d: role must be scanner, operator, or admin, got %q", path, i, e.Role)
+		}
+		if e.LogSampling != nil && (*e.LogSampling < 0 || *e.LogSampling > 1) {
+			return nil, fmt.Errorf("auth: %s: entry %d: log_sampling must be in [0,1], got %v", path, i, *e.LogSampling)
+		}
+		if e.ScanSampling != nil && (*e.ScanSampling <= 0 || *e.ScanSampling > 1) {
+			return nil, fmt.Errorf("auth: %s: entry %d: scan_sampling must be in (0,1], got %v", path, i, *e.ScanSampling)
+		}
+		switch e.Redact {
+		case "", RedactDetections, RedactVerdict:
+		default:
+			return nil, fmt.Errorf("auth: %s: entry %d: redact must be empty, detections, or verdict, got %q", path, i, e.Redact)
+		}
+		if e.MaxImageBytes < 0 {
+			return nil, fmt.Errorf("auth: %s: entry %d: max_image_bytes must not be negative, got %d", path, i, e.MaxImageBytes)
+		}
+		if e.MinSlots < 0 || e.MaxSlots < 0 {
+			return nil, fmt.Errorf("auth: %s: entry %d: min_slots and max_slots must not be negative", path, i)
+		}
+		if e.MinSlots > 0 && e.MaxSlots > 0 && e.MaxSlots < e.MinSlots {
+			return nil, fmt.Errorf("auth: %s: entry %d: max_slots %d is below min_slots %d", path, i, e.MaxSlots, e.MinSlots)
+		}
+		if e.Name == "" {
+			sum := sha256.Sum256([]byte(e.Key))
+			e.Name = hex.EncodeToString(sum[:4])
+		}
+	}
+	return &Keys{keys: entries}, nil
+}
+
+// All returns the loaded entries, for callers that derive per-tenant
+// configuration from the table.
+func (k *Keys) All() []Key {
+	return k.keys
+}
+
+// Lookup finds the key entry matching the presented secret. Every entry
+// is compared in constant time so lookup duration doesn't leak which
+// prefix matched.
+func (k *Keys) Lookup(secret string) (*Key, bool) {
+	var found *Key
+	for i := range k.keys {
+		if subtle.ConstantTimeCompare([]byte(k.keys[i].Key), []byte(secret)) == 1 {
+			found = &k.keys[i]
+		}
+	}
+	return found, found != nil
+}
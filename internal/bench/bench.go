@@ -0,0 +1,174 @@
+// Package bench runs in-process benchmarks against a single model so
+// operators can size instances before go-live.
+package bench
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/model"
+	"github.com/illussioon/NFWS-Moderations-API/internal/scan"
+)
+
+// Params controls a benchmark run.
+type Params struct {
+	// Iterations is the total number of inferences to run.
+	Iterations int `json:"iterations"`
+	// Concurrency is the number of parallel workers.
+	Concurrency int `json:"concurrency"`
+	// Images are optional raw images to cycle through; when empty a
+	// synthetic test image is generated.
+	Images [][]byte `json:"-"`
+}
+
+// Result is the outcome of a benchmark run.
+type Result struct {
+	Model         string  `json:"model"`
+	Iterations    int     `json:"iterations"`
+	Concurrency   int     `json:"concurrency"`
+	Errors        int     `json:"errors"`
+	DurationMS    float64 `json:"duration_ms"`
+	ThroughputRPS float64 `json:"throughput_rps"`
+	LatencyMS     struct {
+		Min float64 `json:"min"`
+		P50 float64 `json:"p50"`
+		P90 float64 `json:"p90"`
+		P99 float64 `json:"p99"`
+		Max float64 `json:"max"`
+	} `json:"latency_ms"`
+	// MemDeltaBytes is the change in heap-in-use across the run. It can
+	// be negative when a GC cycle lands mid-run.
+	MemDeltaBytes int64 `json:"mem_delta_bytes"`
+}
+
+const (
+	defaultIterations = 50
+	maxIterations     = 10000
+	maxConcurrency    = 64
+)
+
+// Validate normalizes and bounds-checks the parameters.
+func (p *Params) Validate() error {
+	if p.Iterations == 0 {
+		p.Iterations = defaultIterations
+	}
+	if p.Concurrency == 0 {
+		p.Concurrency = 1
+	}
+	if p.Iterations < 1 || p.Iterations > maxIterations {
+		return fmt.Errorf("bench: iterations must be in [1,%d]", maxIterations)
+	}
+	if p.Concurrency < 1 || p.Concurrency > maxConcurrency {
+		return fmt.Errorf("bench: concurrency must be in [1,%d]", maxConcurrency)
+	}
+	return nil
+}
+
+// Run benchmarks one model and reports latency percentiles, throughput,
+// and memory delta.
+func Run(ctx context.Context, s *scan.Scanner, m *model.Loaded, p Params) (*Result, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	images := p.Images
+	if len(images) == 0 {
+		img, err := SyntheticImage(m.Spec.InputSize)
+		if err != nil {
+			return nil, err
+		}
+		images = [][]byte{img}
+	}
+
+	// One warm-up run so session initialization does not skew the first
+	// percentile bucket.
+	if _, err := s.RunModel(ctx, m, images[0]); err != nil {
+		return nil, fmt.Errorf("bench: warm-up run: %w", err)
+	}
+
+	var memBefore, memAfter runtime.MemStats
+	runtime.ReadMemStats(&memBefore)
+
+	latencies := make([]float64, p.Iterations)
+	errs := make([]error, p.Iterations)
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, p.Concurrency)
+	start := time.Now()
+	for i := 0; i < p.Iterations; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			t := time.Now()
+			_, err := s.RunModel(ctx, m, images[i%len(images)])
+			latencies[i] = float64(time.Since(t).Microseconds()) / 1000
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+	total := time.Since(start)
+	runtime.ReadMemStats(&memAfter)
+
+	res := &Result{
+		Model:         m.Spec.Name,
+		Iterations:    p.Iterations,
+		Concurrency:   p.Concurrency,
+		DurationMS:    float64(total.Microseconds()) / 1000,
+		MemDeltaBytes: int64(memAfter.HeapInuse) - int64(memBefore.HeapInuse),
+	}
+	ok := latencies[:0]
+	for i, l := range latencies {
+		if errs[i] != nil {
+			res.Errors++
+			continue
+		}
+		ok = append(ok, l)
+	}
+	if len(ok) > 0 {
+		sort.Float64s(ok)
+		res.LatencyMS.Min = ok[0]
+		res.LatencyMS.P50 = percentile(ok, 0.50)
+		res.LatencyMS.P90 = percentile(ok, 0.90)
+		res.LatencyMS.P99 = percentile(ok, 0.99)
+		res.LatencyMS.Max = ok[len(ok)-1]
+		res.ThroughputRPS = float64(len(ok)) / total.Seconds()
+	}
+	return res, nil
+}
+
+func percentile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(q * float64(len(sorted)-1))
+	return sorted[idx]
+}
+
+// SyntheticImage renders a deterministic gradient-and-blocks test pattern
+// of the given square size, PNG-encoded.
+func SyntheticImage(size int) ([]byte, error) {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.Set(x, y, color.NRGBA{
+				R: uint8(x * 255 / size),
+				G: uint8(y * 255 / size),
+				B: uint8((x ^ y) & 0xff),
+				A: 255,
+			})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("bench: encoding synthetic image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
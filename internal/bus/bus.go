@@ -0,0 +1,186 @@
+// Package bus is the in-process event bus the service publishes its
+// lifecycle events on. Integrations — webhooks, exporters, metrics —
+// subscribe to the event types they care about instead of being called
+// from the handlers, so adding one never touches the scan path.
+//
+// Delivery is asynchronous and best-effort: each subscriber drains its
+// own buffered queue on its own goroutine, and a subscriber that can't
+// keep up loses events rather than slowing publishers down.
+package bus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// The event types published by the service.
+const (
+	// ScanCompleted fires after every scan, flagged or not. Data is the
+	// scan's analytics record (export.Event).
+	ScanCompleted = "scan.completed"
+	// ScanFlagged fires for the subset of scans whose verdict is NSFW,
+	// with the same payload as ScanCompleted.
+	ScanFlagged = "scan.flagged"
+	// ModelLoaded fires when a model version becomes active: at startup
+	// and on activate/rollback.
+	ModelLoaded = "model.loaded"
+	// QuotaExceeded fires when a request is rejected for capacity or
+	// quota reasons before any scan work happens.
+	QuotaExceeded = "quota.exceeded"
+	// HashMatched fires when a scanned image matches an industry hash
+	// list, before any inference runs. Delivery to the configured alert
+	// webhook is mandatory when hash lists are enabled.
+	HashMatched = "hashlist.match"
+	// AppealOpened fires when an end user files an appeal against a
+	// stored verdict and the item enters the review queue.
+	AppealOpened = "appeal.opened"
+	// AppealResolved fires when a reviewer closes an appeal, with the
+	// outcome (upheld or overturned) in the payload.
+	AppealResolved = "appeal.resolved"
+	// AppealEscalated fires when an appeal's required reviewer
+	// decisions are in but disagree, so a senior reviewer must break
+	// the tie.
+	AppealEscalated = "appeal.escalated"
+)
+
+// Event is one published occurrence. Data is event-type specific; see
+// the type constants for what each carries.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data,omitempty"`
+}
+
+// Handler consumes events for one subscriber. It runs on the
+// subscriber's own goroutine, so it may block without affecting
+// publishers or other subscribers.
+type Handler func(Event)
+
+// subscriberBuffer is how many undelivered events one subscriber may
+// queue before new ones are dropped.
+const subscriberBuffer = 256
+
+// Bus fans events out to subscribers. Publish is safe for concurrent
+// use and safe on a nil bus, so publishers don't need to check whether
+// events are wired up.
+type Bus struct {
+	log *slog.Logger
+
+	mu   sync.Mutex
+	subs []*subscriber
+	done sync.WaitGroup
+}
+
+type subscriber struct {
+	name  string
+	types map[string]bool
+	ch    chan Event
+}
+
+// New returns an empty bus.
+func New(log *slog.Logger) *Bus {
+	return &Bus{log: log}
+}
+
+// Subscribe registers a handler for the given event types; no types
+// means every event. name identifies the subscriber in drop logs.
+func (b *Bus) Subscribe(name string, h Handler, types ...string) {
+	sub := &subscriber{name: name, ch: make(chan Event, subscriberBuffer)}
+	if len(types) > 0 {
+		sub.types = make(map[string]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+	b.done.Add(1)
+	go func() {
+		defer b.done.Done()
+		for ev := range sub.ch {
+			h(ev)
+		}
+	}()
+}
+
+// Publish stamps and delivers an event to every matching subscriber.
+// It never blocks: a full subscriber queue drops the event with a
+// warning.
+func (b *Bus) Publish(eventType string, data any) {
+	if b == nil {
+		return
+	}
+	ev := Event{Type: eventType, Time: time.Now().UTC(), Data: data}
+	b.mu.Lock()
+	subs := b.subs
+	b.mu.Unlock()
+	for _, sub := range subs {
+		if sub.types != nil && !sub.types[eventType] {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			b.log.Warn("event subscriber queue full, dropping event", "subscriber", sub.name, "type", eventType)
+		}
+	}
+}
+
+// Close stops delivery and waits for subscribers to drain their queues.
+func (b *Bus) Close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	subs := b.subs
+	b.subs = nil
+	b.mu.Unlock()
+	for _, sub := range subs {
+		close(sub.ch)
+	}
+	b.done.Wait()
+}
+
+// Webhook returns a handler POSTing each event as JSON to url — the
+// zero-code integration point for systems that just want to be told
+// when something happened.
+func Webhook(url string, log *slog.Logger) Handler {
+	return func(ev Event) {
+		body, err := json.Marshal(ev)
+		if err != nil {
+			return
+		}
+		postWebhook(url, "application/json", body, ev.Type, log)
+	}
+}
+
+// postWebhook delivers one webhook body, logging failures.
+func postWebhook(url, contentType string, body []byte, evType string, log *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		log.Error("event webhook failed", "type", evType, "err", err)
+		return
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Error("event webhook failed", "type", evType, "err", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		log.Error("event webhook failed", "type", evType,
+			"err", fmt.Errorf("webhook returned %d: %s", resp.StatusCode, msg))
+	}
+}
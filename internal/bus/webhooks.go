@@ -0,0 +1,145 @@
+package bus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/template"
+)
+
+// WebhookFilter narrows which events a destination receives, evaluated
+// against the event's data fields. Zero values leave a dimension
+// unfiltered.
+type WebhookFilter struct {
+	// NSFW, when set, requires the event's verdict to match.
+	NSFW *bool `json:"nsfw,omitempty"`
+	// Tenant requires the event to come from this tenant's key.
+	Tenant string `json:"tenant,omitempty"`
+	// MinScore requires the event's score to be at least this high — the
+	// severity floor for receivers that only care about explicit content.
+	MinScore float64 `json:"min_score,omitempty"`
+}
+
+// WebhookDestination is one entry of the webhooks file: where to
+// deliver, which event types and data to include, and optionally how to
+// shape the body.
+type WebhookDestination struct {
+	// Name identifies the destination in logs.
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Types restricts delivery to these event types; empty means all.
+	Types  []string       `json:"types,omitempty"`
+	Filter *WebhookFilter `json:"filter,omitempty"`
+	// Template is a Go text/template rendered over the event ({{.Type}},
+	// {{.Time}}, and the data fields under {{.Data}}); empty posts the
+	// event as JSON like the plain webhook.
+	Template string `json:"template,omitempty"`
+	// ContentType overrides the posted Content-Type; the default is
+	// application/json.
+	ContentType string `json:"content_type,omitempty"`
+
+	tmpl *template.Template
+}
+
+// LoadWebhooks reads a JSON webhooks file: an array of destination
+// objects. Templates are parsed here so a typo fails startup, not the
+// first delivery.
+func LoadWebhooks(path string) ([]WebhookDestination, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("bus: %w", err)
+	}
+	var dests []WebhookDestination
+	if err := json.Unmarshal(data, &dests); err != nil {
+		return nil, fmt.Errorf("bus: parsing %s: %w", path, err)
+	}
+	if len(dests) == 0 {
+		return nil, fmt.Errorf("bus: %s contains no destinations", path)
+	}
+	for i := range dests {
+		d := &dests[i]
+		if d.URL == "" {
+			return nil, fmt.Errorf("bus: %s: entry %d has no url", path, i)
+		}
+		if d.Name == "" {
+			d.Name = fmt.Sprintf("destination-%d", i)
+		}
+		if d.Filter != nil && (d.Filter.MinScore < 0 || d.Filter.MinScore > 1) {
+			return nil, fmt.Errorf("bus: %s: entry %d: min_score must be in [0,1], got %v", path, i, d.Filter.MinScore)
+		}
+		if d.Template != "" {
+			if d.tmpl, err = template.New(d.Name).Parse(d.Template); err != nil {
+				return nil, fmt.Errorf("bus: %s: entry %d: parsing template: %w", path, i, err)
+			}
+		}
+	}
+	return dests, nil
+}
+
+// Handler returns the subscriber handler delivering matching events to
+// the destination.
+func (d *WebhookDestination) Handler(log *slog.Logger) Handler {
+	return func(ev Event) {
+		data, ok := d.match(ev)
+		if !ok {
+			return
+		}
+		var body []byte
+		contentType := d.ContentType
+		if d.tmpl != nil {
+			var buf bytes.Buffer
+			view := struct {
+				Type string
+				Time string
+				Data map[string]any
+			}{ev.Type, ev.Time.Format("2006-01-02T15:04:05Z07:00"), data}
+			if err := d.tmpl.Execute(&buf, view); err != nil {
+				log.Error("event webhook template failed", "destination", d.Name, "type", ev.Type, "err", err)
+				return
+			}
+			body = buf.Bytes()
+		} else {
+			var err error
+			if body, err = json.Marshal(ev); err != nil {
+				return
+			}
+		}
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		postWebhook(d.URL, contentType, body, ev.Type, log)
+	}
+}
+
+// match applies the destination's filter, returning the event data as a
+// generic map (shared with template rendering) and whether to deliver.
+func (d *WebhookDestination) match(ev Event) (map[string]any, bool) {
+	var data map[string]any
+	if ev.Data != nil {
+		if raw, err := json.Marshal(ev.Data); err == nil {
+			json.Unmarshal(raw, &data)
+		}
+	}
+	f := d.Filter
+	if f == nil {
+		return data, true
+	}
+	if f.NSFW != nil {
+		nsfw, _ := data["nsfw"].(bool)
+		if nsfw != *f.NSFW {
+			return nil, false
+		}
+	}
+	if f.Tenant != "" && data["tenant"] != f.Tenant {
+		return nil, false
+	}
+	if f.MinScore > 0 {
+		score, _ := data["score"].(float64)
+		if score < f.MinScore {
+			return nil, false
+		}
+	}
+	return data, true
+}
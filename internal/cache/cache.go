@@ -0,0 +1,45 @@
+// Package cache is the optional read-through cache in front of repeat
+// work: scan results and URL downloads. Unlike the store, everything in
+// it is reconstructible, so entries expire, evict, and vanish on
+// restart without anyone noticing — which is also why the backend
+// surface is just Get and Set with a TTL. The backend is selected by a
+// DSN so operators can point it at whatever they already run.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrMiss is returned by Get for absent or expired keys.
+var ErrMiss = errors.New("cache: miss")
+
+// Cache is the backend interface. Both operations are best-effort:
+// callers treat every Get error as a miss and a failed Set costs
+// nothing but the next recomputation.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Close() error
+}
+
+// Open selects a backend from the DSN:
+//
+//	memory://          in-process memory (lost on restart)
+//	redis://...        shared Redis
+//	memcached://HOST   memcached over the text protocol
+func Open(dsn string) (Cache, error) {
+	switch {
+	case dsn == "memory://":
+		return newMemory(), nil
+	case strings.HasPrefix(dsn, "redis://"):
+		return openRedis(dsn)
+	case strings.HasPrefix(dsn, "memcached://"):
+		return openMemcached(strings.TrimPrefix(dsn, "memcached://"))
+	default:
+		return nil, fmt.Errorf("cache: unsupported DSN %q (expected memory://, redis://..., or memcached://HOST:PORT)", dsn)
+	}
+}
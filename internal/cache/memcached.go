@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memcachedCache speaks the memcached text protocol over a single
+// connection, redialed on the next call after any I/O error. One
+// connection is plenty: the protocol pipelines poorly anyway and cache
+// misses are cheap.
+type memcachedCache struct {
+	addr string
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// memcachedTimeout bounds one dial or round trip.
+const memcachedTimeout = 2 * time.Second
+
+func openMemcached(addr string) (Cache, error) {
+	if addr == "" {
+		return nil, fmt.Errorf("cache: memcached:// DSN needs HOST:PORT")
+	}
+	// Dial eagerly so a bad address fails startup, not the first scan.
+	c := &memcachedCache{addr: addr}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.ensure(); err != nil {
+		return nil, fmt.Errorf("cache: connecting to memcached: %w", err)
+	}
+	return c, nil
+}
+
+// ensure dials if no connection is live. Callers hold mu.
+func (c *memcachedCache) ensure() error {
+	if c.conn != nil {
+		return nil
+	}
+	conn, err := net.DialTimeout("tcp", c.addr, memcachedTimeout)
+	if err != nil {
+		return err
+	}
+	c.conn, c.r = conn, bufio.NewReader(conn)
+	return nil
+}
+
+// drop closes the connection after an error so the next call redials.
+// Callers hold mu.
+func (c *memcachedCache) drop() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn, c.r = nil, nil
+	}
+}
+
+func (c *memcachedCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.ensure(); err != nil {
+		return nil, err
+	}
+	c.conn.SetDeadline(time.Now().Add(memcachedTimeout))
+	if _, err := fmt.Fprintf(c.conn, "get %s\r\n", key); err != nil {
+		c.drop()
+		return nil, err
+	}
+	header, err := c.r.ReadString('\n')
+	if err != nil {
+		c.drop()
+		return nil, err
+	}
+	header = strings.TrimRight(header, "\r\n")
+	if header == "END" {
+		return nil, ErrMiss
+	}
+	// VALUE <key> <flags> <bytes>
+	fields := strings.Fields(header)
+	if len(fields) < 4 || fields[0] != "VALUE" {
+		c.drop()
+		return nil, fmt.Errorf("cache: unexpected memcached response %q", header)
+	}
+	size, err := strconv.Atoi(fields[3])
+	if err != nil {
+		c.drop()
+		return nil, fmt.Errorf("cache: unexpected memcached response %q", header)
+	}
+	value := make([]byte, size+2) // trailing \r\n
+	if _, err := io.ReadFull(c.r, value); err != nil {
+		c.drop()
+		return nil, err
+	}
+	if end, err := c.r.ReadString('\n'); err != nil || strings.TrimRight(end, "\r\n") != "END" {
+		c.drop()
+		return nil, fmt.Errorf("cache: unterminated memcached response")
+	}
+	return value[:size], nil
+}
+
+func (c *memcachedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.ensure(); err != nil {
+		return err
+	}
+	c.conn.SetDeadline(time.Now().Add(memcachedTimeout))
+	if _, err := fmt.Fprintf(c.conn, "set %s 0 %d %d\r\n", key, int(ttl.Seconds()), len(value)); err != nil {
+		c.drop()
+		return err
+	}
+	if _, err := c.conn.Write(append(value, '\r', '\n')); err != nil {
+		c.drop()
+		return err
+	}
+	reply, err := c.r.ReadString('\n')
+	if err != nil {
+		c.drop()
+		return err
+	}
+	if strings.TrimRight(reply, "\r\n") != "STORED" {
+		return fmt.Errorf("cache: memcached refused set: %s", strings.TrimRight(reply, "\r\n"))
+	}
+	return nil
+}
+
+func (c *memcachedCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.drop()
+	return nil
+}
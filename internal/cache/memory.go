@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryCache is the in-process backend: a map with per-entry expiry.
+// Expired entries are dropped lazily on read and swept in bulk on
+// write, so an idle cache still can't grow without bound.
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	// sweepAt is when the next full expiry sweep is due.
+	sweepAt time.Time
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// sweepInterval is how often Set walks the whole map to drop expired
+// entries.
+const sweepInterval = time.Minute
+
+func newMemory() *memoryCache {
+	return &memoryCache{
+		entries: make(map[string]memoryEntry),
+		sweepAt: time.Now().Add(sweepInterval),
+	}
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	if time.Now().After(e.expires) {
+		delete(c.entries, key)
+		return nil, ErrMiss
+	}
+	return e.value, nil
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.After(c.sweepAt) {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.sweepAt = now.Add(sweepInterval)
+	}
+	c.entries[key] = memoryEntry{value: value, expires: now.Add(ttl)}
+	return nil
+}
+
+func (c *memoryCache) Close() error { return nil }
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisCache maps the interface onto plain SETEX/GET — Redis's own TTL
+// handling does all the work.
+type redisCache struct {
+	client *redis.Client
+}
+
+// cachePrefix namespaces cache keys apart from the store's, which may
+// share the same Redis.
+const cachePrefix = "nfws:cache:"
+
+func openRedis(dsn string) (Cache, error) {
+	opts, err := redis.ParseURL(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &redisCache{client: redis.NewClient(opts)}, nil
+}
+
+func (c *redisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.client.Get(ctx, cachePrefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+func (c *redisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, cachePrefix+key, value, ttl).Err()
+}
+
+func (c *redisCache) Close() error { return c.client.Close() }
@@ -0,0 +1,111 @@
+// Package chaos implements opt-in fault injection so callers can verify
+// their retry and fallback logic against this dependency. Everything is
+// off by default and only reachable through the admin API.
+package chaos
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Config describes what to inject and how often. All rates are
+// probabilities in [0,1] applied independently per request.
+type Config struct {
+	// Enabled is the master switch; when false nothing is injected
+	// regardless of the rates.
+	Enabled bool `json:"enabled"`
+	// LatencyRate is the fraction of requests delayed by LatencyMS.
+	LatencyRate float64 `json:"latency_rate"`
+	// LatencyMS is the artificial delay added to affected requests.
+	LatencyMS int `json:"latency_ms"`
+	// ErrorRate is the fraction of requests failed with a 503 before any
+	// work happens.
+	ErrorRate float64 `json:"error_rate"`
+	// ModelFailRate is the fraction of model runs failed as if the
+	// inference runtime had errored.
+	ModelFailRate float64 `json:"model_fail_rate"`
+}
+
+// Validate bounds-checks the rates and delay.
+func (c Config) Validate() error {
+	for name, rate := range map[string]float64{
+		"latency_rate":    c.LatencyRate,
+		"error_rate":      c.ErrorRate,
+		"model_fail_rate": c.ModelFailRate,
+	} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("chaos: %s must be in [0,1], got %v", name, rate)
+		}
+	}
+	if c.LatencyMS < 0 || c.LatencyMS > 60000 {
+		return fmt.Errorf("chaos: latency_ms must be in [0,60000], got %d", c.LatencyMS)
+	}
+	return nil
+}
+
+// Injector holds the live chaos configuration. The zero value is a
+// disabled injector and all methods are safe on a nil receiver, so
+// callers never need to check whether chaos is wired in.
+type Injector struct {
+	mu  sync.RWMutex
+	cfg Config
+}
+
+// NewInjector returns a disabled injector.
+func NewInjector() *Injector { return &Injector{} }
+
+// Get returns the current configuration.
+func (i *Injector) Get() Config {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.cfg
+}
+
+// Set replaces the configuration after validating it.
+func (i *Injector) Set(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	i.mu.Lock()
+	i.cfg = cfg
+	i.mu.Unlock()
+	return nil
+}
+
+// ModelFailure returns an injected error for a model run, or nil.
+func (i *Injector) ModelFailure() error {
+	if i == nil {
+		return nil
+	}
+	cfg := i.Get()
+	if !cfg.Enabled || cfg.ModelFailRate <= 0 || rand.Float64() >= cfg.ModelFailRate {
+		return nil
+	}
+	return fmt.Errorf("chaos: injected model failure")
+}
+
+// Middleware applies latency and 5xx injection to HTTP requests.
+func (i *Injector) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := i.Get()
+		if cfg.Enabled {
+			if cfg.LatencyMS > 0 && rand.Float64() < cfg.LatencyRate {
+				select {
+				case <-time.After(time.Duration(cfg.LatencyMS) * time.Millisecond):
+				case <-r.Context().Done():
+					return
+				}
+			}
+			if rand.Float64() < cfg.ErrorRate {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				fmt.Fprintln(w, `{"error":"chaos: injected failure"}`)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,764 @@
+// Package config loads service configuration from the environment.
+//
+// Every option has an NFWS_-prefixed environment variable and a sensible
+// default so the service can start with nothing but the model files present.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the runtime configuration for the moderation service.
+type Config struct {
+	// ListenAddr is the TCP address the HTTP server binds to.
+	ListenAddr string
+	// ListenSocket, when set, serves over a Unix domain socket at this
+	// path instead of TCP — for reverse proxies on the same host and
+	// locked-down environments that must not expose a port.
+	ListenSocket string
+	// ReusePort binds the TCP listener with SO_REUSEPORT so a second
+	// instance can bind the same port during a binary upgrade.
+	ReusePort bool
+	// ModelsDir is the directory containing the ONNX model files.
+	ModelsDir string
+	// ModelPreload maps model names to preload hints parsed from
+	// NFWS_MODEL_PRELOAD, a comma-separated list like
+	// "nudenet=on-schedule:9-18,nsfw_mobilenet=lazy". Models without a
+	// hint load at startup and stay resident.
+	ModelPreload map[string]PreloadHint
+	// APIKey, when non-empty, is required in the X-API-Key header for
+	// scanning endpoints.
+	APIKey string
+	// AdminKey, when non-empty, is required in the X-Admin-Key header for
+	// /admin endpoints. Admin endpoints are disabled entirely when empty.
+	AdminKey string
+	// KeysFile is a JSON key table attaching a role (scanner, operator,
+	// or admin) to each API key. It replaces APIKey and AdminKey: the two
+	// single-key variables may not be set alongside it.
+	KeysFile string
+	// NSFWThreshold is the default score above which content is flagged.
+	NSFWThreshold float64
+	// AdultThreshold is the flagging threshold for the adult-verified
+	// surface when a scan asks for age-gate evaluation. Typically higher
+	// than NSFWThreshold: suggestive content passes, explicit still
+	// flags.
+	AdultThreshold float64
+	// MaxImageBytes caps the size of a downloaded or uploaded image.
+	MaxImageBytes int64
+	// DownloadTimeout bounds fetching a remote image URL.
+	DownloadTimeout time.Duration
+	// RequestTimeout bounds a whole scan request.
+	RequestTimeout time.Duration
+	// MockInference replaces the ONNX runtime with a deterministic mock
+	// that derives scores from the image hash. For integration testing
+	// only; enabled with MOCK_INFERENCE=true.
+	MockInference bool
+	// GPU places inference on the CUDA execution provider (builds with
+	// the ort tag); if CUDA fails to initialize, scans fall back to CPU.
+	GPU bool
+	// GPUModels limits GPU placement to the named models; empty places
+	// every model on the GPU when GPU is set.
+	GPUModels []string
+	// GPUDevice is the CUDA device ordinal.
+	GPUDevice int
+	// PreferQuantized serves the quantized variant ("<file>-int8.onnx"
+	// or "<file>-fp16.onnx") of each model whose variant file exists,
+	// trading some accuracy for throughput.
+	PreferQuantized bool
+	// ExportSink selects the scan-event analytics sink: "" (disabled),
+	// "clickhouse", "elasticsearch", or "http".
+	ExportSink string
+	// ExportURL is the sink endpoint (ClickHouse HTTP interface, the
+	// Elasticsearch cluster root, or a generic collector URL).
+	ExportURL string
+	// ExportTable is the ClickHouse table or Elasticsearch index.
+	ExportTable string
+	// ExportUsername and ExportPassword enable basic auth on sinks that
+	// support it (Elasticsearch).
+	ExportUsername string
+	ExportPassword string
+	// ExportBatchSize and ExportFlushInterval bound batching.
+	ExportBatchSize     int
+	ExportFlushInterval time.Duration
+	// StoreDSN selects the persistence backend for jobs, audit logs,
+	// API keys, and stats: memory:// (default), sqlite://PATH,
+	// redis://..., or postgres://....
+	StoreDSN string
+	// ClusterMode makes all cross-request state (stats, and any future
+	// rate limits, caches, and jobs) live in the shared store so
+	// replicas behind a load balancer stay consistent. Requires a
+	// redis:// or postgres:// StoreDSN.
+	ClusterMode bool
+	// CacheDSN enables the read-through cache for scan results and URL
+	// downloads: memory://, redis://..., or memcached://HOST:PORT.
+	// Empty disables caching.
+	CacheDSN string
+	// CacheTTL is how long cached entries stay valid.
+	CacheTTL time.Duration
+	// StartupWait is how long startup blocks for shared backends (the
+	// store, the cache) to answer before giving up, so the container
+	// doesn't crash-loop when they come up a few seconds late. Zero
+	// fails immediately.
+	StartupWait time.Duration
+	// S3Endpoint, S3Region, S3AccessKey, and S3SecretKey configure the
+	// object-store client used for s3:// scan URLs and verdict
+	// write-back. Empty endpoint disables S3 support.
+	S3Endpoint  string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+	// S3WritebackMode records verdicts on scanned s3:// objects:
+	// "" (off), "tags", or "sidecar".
+	S3WritebackMode string
+	// RescanSchedule is a five-field cron expression for scheduled
+	// rescans of RescanManifest; empty disables the scheduler. In a
+	// multi-replica deployment leader election via the shared store
+	// picks one replica per run.
+	RescanSchedule string
+	// RescanManifest is an http(s)://, s3://, or local path to a
+	// newline-delimited list of image URLs to rescan.
+	RescanManifest string
+	// LabelsFile is a JSON label-mapping table (model -> raw class ->
+	// policy label and localized display names) applied to responses;
+	// empty leaves raw class names.
+	LabelsFile string
+	// ThumbnailSize and ThumbnailBlur shape the blurred previews
+	// generated for flagged scans that request one.
+	ThumbnailSize int
+	ThumbnailBlur int
+	// ThumbnailBucket, when set, uploads previews to this S3 bucket and
+	// returns their URL instead of inline base64.
+	ThumbnailBucket string
+	// IgnoreMargin excludes this fraction of each image edge from
+	// scoring, so border watermarks can't trigger false positives. 0
+	// scans the full image.
+	IgnoreMargin float64
+	// LottieRenderer is an external command that reads Lottie JSON (or
+	// gzipped .tgs) on stdin and writes a GIF or APNG on stdout, making
+	// animated stickers scannable. Empty rejects Lottie input.
+	LottieRenderer string
+	// ImageConverter is an external command that reads a HEIC or AVIF
+	// container on stdin and writes a jpeg or png raster on stdout.
+	// Empty rejects both formats.
+	ImageConverter string
+	// VideoFFmpeg is the external ffmpeg command that decodes video
+	// containers (mp4, webm, avi) into sampled frames. Empty rejects
+	// video input.
+	VideoFFmpeg string
+	// VideoFPS is how many frames per second of video are sampled for
+	// scanning.
+	VideoFPS float64
+	// VerdictTTL is how long a stored verdict stays valid for
+	// POST /verify before callers are told to rescan.
+	VerdictTTL time.Duration
+	// Reproducible records preprocessing parameters, model checksums,
+	// the runtime version, and input-tensor digests with every stored
+	// verdict, so a disputed decision can later be replayed and proven
+	// bit-identical via POST /admin/reproduce.
+	Reproducible bool
+	// ReviewSLA is how long an appeal may sit open in the review queue
+	// before it counts as overdue in listings and queue stats.
+	ReviewSLA time.Duration
+	// ReviewConsensus maps severity tiers to how many independent
+	// reviewer decisions an appeal at that tier needs before its
+	// outcome is final. Tiers not listed resolve on a single decision.
+	ReviewConsensus map[string]int
+	// ReviewBlind hides model scores and verdicts from reviewers
+	// listing the queue until an appeal is resolved, so decisions are
+	// not anchored on what the models said.
+	ReviewBlind bool
+	// ReceiptSecret, when set, enables signed JWT scan receipts (HS256
+	// over this shared secret).
+	ReceiptSecret string
+	// CallbackSecret, when set, signs scan-completion callback bodies
+	// with HMAC-SHA256 in the X-NFWS-Signature header so receivers can
+	// authenticate them.
+	CallbackSecret string
+	// Provider selects an external moderation service for borderline
+	// escalation: "" (disabled), "http", "vision", or "hive".
+	Provider string
+	// ProviderURL and ProviderKey configure the adapter endpoint and
+	// credential.
+	ProviderURL string
+	ProviderKey string
+	// ProviderMargin is the escalation window: scans whose local score
+	// lands within this distance of the threshold get a second opinion.
+	ProviderMargin float64
+	// ProviderTimeout bounds one external scoring call.
+	ProviderTimeout time.Duration
+	// DriftWindow is the number of scans per model in one drift
+	// comparison window; 0 disables drift detection.
+	DriftWindow int
+	// DriftThreshold is the PSI at which a model's score distribution
+	// counts as drifted.
+	DriftThreshold float64
+	// DriftWebhookURL, when set, receives a JSON alert each time a
+	// model's distribution drifts from its baseline.
+	DriftWebhookURL string
+	// DatasetBucket is the S3 bucket retraining dataset exports land
+	// in; empty disables dataset collection and export.
+	DatasetBucket string
+	// QuarantineBucket, when set, preserves every flagged original in
+	// this bucket (private ACL, sealed when payload sealing is on) and
+	// returns its location on the verdict, for legal-hold workflows.
+	QuarantineBucket string
+	// QuarantineExpiryDays tags quarantined objects with an
+	// expire-after-days value the bucket's lifecycle rules can act on;
+	// 0 leaves objects untagged and unexpiring.
+	QuarantineExpiryDays int
+	// HashListSources maps hash-list names to their sources (a local
+	// path or an https:// URL), parsed from NFWS_HASHLIST_SOURCES as
+	// "name=location,name=location". Lists are newline-delimited MD5,
+	// SHA-1, or PDQ digests; every scanned image is checked against them
+	// before inference. Empty disables hash-list matching.
+	HashListSources map[string]string
+	// HashListDistances tunes perceptual matching per list, parsed from
+	// NFWS_HASHLIST_DISTANCES as "name=hamming:31,name=exact". PDQ
+	// entries of a list with a hamming metric match within that bit
+	// distance; exact (the default) requires identical hashes. The
+	// reserved name "blocklist" configures the victim-report blocklist.
+	HashListDistances map[string]HashDistance
+	// HashListAlertURL receives a JSON alert for every hash-list match.
+	// It is required when any source is configured: these matches carry
+	// reporting obligations, so running without an alert channel is a
+	// misconfiguration.
+	HashListAlertURL string
+	// DatasetInlineImages embeds base64 image bytes in dataset records
+	// for upload-sourced items. Off by default: it multiplies store and
+	// export size and retains content the service otherwise never keeps.
+	DatasetInlineImages bool
+	// EventsWebhookURL, when set, subscribes a webhook to the internal
+	// event bus, POSTing each event as JSON.
+	EventsWebhookURL string
+	// EventsWebhookTypes restricts the webhook to a comma-separated list
+	// of event types (e.g. "scan.flagged,model.loaded"); empty means all.
+	EventsWebhookTypes []string
+	// WebhooksFile is a JSON array of webhook destinations with
+	// per-destination event filters and payload templates, for
+	// deployments outgrowing the single NFWS_EVENTS_WEBHOOK_URL.
+	WebhooksFile string
+	// DigestSchedule enables the emailed moderation digest: "daily" or
+	// "weekly"; empty disables it.
+	DigestSchedule string
+	// SMTPAddr is the host:port of the relay digest emails go through.
+	SMTPAddr string
+	// SMTPFrom is the digest's From address.
+	SMTPFrom string
+	// DigestTo is the digest recipient list, from NFWS_DIGEST_TO as a
+	// comma-separated list.
+	DigestTo []string
+	// SMTPUsername and SMTPPassword enable PLAIN auth on the relay when
+	// set.
+	SMTPUsername string
+	SMTPPassword string
+	// PluginFile is a WASI command module with deployment-specific scan
+	// logic; empty disables the plugin hook.
+	PluginFile string
+	// PluginHook is where the plugin attaches: "verdict" (default) or
+	// "preprocess".
+	PluginHook string
+	// PluginTimeout bounds one sandboxed plugin execution.
+	PluginTimeout time.Duration
+	// PolicyFile is a JSON array of named CEL policy rules evaluated on
+	// every scan; empty disables policy evaluation.
+	PolicyFile string
+	// MaxInflightScans caps concurrently served scanning requests;
+	// the overflow is shed with 503. 0 means no cap.
+	MaxInflightScans int
+	// MaxInflightControl is the same ceiling for the operator and admin
+	// endpoints.
+	MaxInflightControl int
+	// AsyncWorkers is how many workers drain the POST /scan/async job
+	// queue; 0 disables asynchronous scans.
+	AsyncWorkers int
+	// JobTTL is how long a finished asynchronous job keeps its result
+	// for polling.
+	JobTTL time.Duration
+	// AdaptiveTargetP95 switches the scan ceiling to the AIMD
+	// controller, steering concurrency toward this p95 latency; 0 keeps
+	// the static limit.
+	AdaptiveTargetP95 time.Duration
+	// AdaptiveMaxInflight caps what the controller may ramp up to.
+	AdaptiveMaxInflight int
+	// QueueWait is how long a request at the in-flight ceiling may wait
+	// for a slot before it is shed; 0 sheds immediately. A waiting
+	// request whose client disconnects is dropped without running.
+	QueueWait time.Duration
+	// CascadeMargin is the half-width of the uncertain band around the
+	// threshold inside which cascade scans invoke the detector stage.
+	CascadeMargin float64
+	// PayloadKeys maps key IDs to hex AES-256 key material for
+	// encrypting persisted payloads (thumbnails, inline dataset images),
+	// parsed from NFWS_PAYLOAD_KEYS as "id=hex,id=hex". Empty stores
+	// payloads in plaintext.
+	PayloadKeys map[string]string
+	// PayloadActiveKey names the PayloadKeys entry new payloads are
+	// sealed with; older entries remain for decryption only, which is
+	// how keys rotate.
+	PayloadActiveKey string
+	// ModelKey is hex AES-256 key material for decrypting ".onnx.enc"
+	// model files into memory at load time; empty restricts the models
+	// directory to plaintext files.
+	ModelKey string
+	// FlagsSpec is the inline feature-flag list from NFWS_FLAGS, e.g.
+	// "cascade=25%,batching=off"; FlagsFile is a JSON flag table. Inline
+	// entries win over the file; unflagged features are fully on.
+	FlagsSpec string
+	FlagsFile string
+}
+
+// PreloadHint is one model's parsed NFWS_MODEL_PRELOAD entry. Mode is
+// always, lazy, or on-schedule; the hours bound the on-schedule window
+// [FromHour,ToHour) in local time and may wrap midnight.
+type PreloadHint struct {
+	Mode     string
+	FromHour int
+	ToHour   int
+}
+
+// getConsensus parses the comma-separated "tier=N" consensus list,
+// where N is how many reviewer decisions appeals at that severity tier
+// need before their outcome is final.
+func getConsensus(key string) (map[string]int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil, nil
+	}
+	out := make(map[string]int)
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		tier, count, ok := strings.Cut(entry, "=")
+		if !ok || tier == "" {
+			return nil, fmt.Errorf("config: %s entry %q: expected tier=N", key, entry)
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 2 {
+			return nil, fmt.Errorf("config: %s entry %q: N must be an integer >= 2", key, entry)
+		}
+		if _, dup := out[tier]; dup {
+			return nil, fmt.Errorf("config: %s: duplicate entry for tier %q", key, tier)
+		}
+		out[tier] = n
+	}
+	return out, nil
+}
+
+// getPreload parses the comma-separated "name=mode" preload list, where
+// mode is always, lazy, or on-schedule:<from>-<to>.
+func getPreload(key string) (map[string]PreloadHint, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil, nil
+	}
+	out := make(map[string]PreloadHint)
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, mode, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("config: %s entry %q: expected name=mode", key, entry)
+		}
+		if _, dup := out[name]; dup {
+			return nil, fmt.Errorf("config: %s: duplicate entry for model %q", key, name)
+		}
+		hint := PreloadHint{Mode: mode}
+		if rest, ok := strings.CutPrefix(mode, "on-schedule:"); ok {
+			hint.Mode = "on-schedule"
+			from, to, ok := strings.Cut(rest, "-")
+			if !ok {
+				return nil, fmt.Errorf("config: %s entry %q: expected on-schedule:<from>-<to>", key, entry)
+			}
+			var err error
+			if hint.FromHour, err = strconv.Atoi(from); err == nil {
+				hint.ToHour, err = strconv.Atoi(to)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("config: %s entry %q: invalid hour", key, entry)
+			}
+			if hint.FromHour < 0 || hint.FromHour > 23 || hint.ToHour < 0 || hint.ToHour > 23 {
+				return nil, fmt.Errorf("config: %s entry %q: hours must be in [0,23]", key, entry)
+			}
+			if hint.FromHour == hint.ToHour {
+				return nil, fmt.Errorf("config: %s entry %q: window must not be empty", key, entry)
+			}
+		}
+		switch hint.Mode {
+		case "always", "lazy":
+		case "on-schedule":
+			if hint.FromHour == hint.ToHour {
+				return nil, fmt.Errorf("config: %s entry %q: on-schedule needs a window, e.g. on-schedule:9-18", key, entry)
+			}
+		default:
+			return nil, fmt.Errorf("config: %s entry %q: mode must be always, lazy, or on-schedule", key, entry)
+		}
+		out[name] = hint
+	}
+	return out, nil
+}
+
+// HashDistance is one list's parsed NFWS_HASHLIST_DISTANCES entry.
+// Metric is exact or hamming; MaxDistance is the highest Hamming bit
+// distance that still counts as a match.
+type HashDistance struct {
+	Metric      string
+	MaxDistance int
+}
+
+// getDistances parses the comma-separated "name=metric" distance list,
+// where metric is exact or hamming:<bits>.
+func getDistances(key string) (map[string]HashDistance, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil, nil
+	}
+	out := make(map[string]HashDistance)
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, metric, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("config: %s entry %q: expected name=metric", key, entry)
+		}
+		if _, dup := out[name]; dup {
+			return nil, fmt.Errorf("config: %s: duplicate entry for list %q", key, name)
+		}
+		d := HashDistance{Metric: metric}
+		if rest, ok := strings.CutPrefix(metric, "hamming:"); ok {
+			d.Metric = "hamming"
+			var err error
+			if d.MaxDistance, err = strconv.Atoi(rest); err != nil {
+				return nil, fmt.Errorf("config: %s entry %q: invalid distance", key, entry)
+			}
+			if d.MaxDistance < 0 || d.MaxDistance > 256 {
+				return nil, fmt.Errorf("config: %s entry %q: distance must be in [0,256]", key, entry)
+			}
+		}
+		switch d.Metric {
+		case "exact", "hamming":
+		default:
+			return nil, fmt.Errorf("config: %s entry %q: metric must be exact or hamming:<bits>", key, entry)
+		}
+		out[name] = d
+	}
+	return out, nil
+}
+
+// getKeyMap parses a comma-separated "id=value" list into a map.
+func getKeyMap(key string) (map[string]string, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil, nil
+	}
+	out := make(map[string]string)
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, value, ok := strings.Cut(entry, "=")
+		if !ok || id == "" || value == "" {
+			return nil, fmt.Errorf("config: %s entry %q: expected id=value", key, entry)
+		}
+		if _, dup := out[id]; dup {
+			return nil, fmt.Errorf("config: %s: duplicate entry for key %q", key, id)
+		}
+		out[id] = value
+	}
+	return out, nil
+}
+
+// Load reads configuration from the environment, applying defaults.
+func Load() (*Config, error) {
+	env := &envReader{}
+	cfg := &Config{
+		ListenAddr:      getString("NFWS_LISTEN_ADDR", ":8080"),
+		ListenSocket:    os.Getenv("NFWS_LISTEN_SOCKET"),
+		ReusePort:       env.getBool("NFWS_REUSE_PORT", false),
+		ModelsDir:       getString("NFWS_MODELS_DIR", "models"),
+		APIKey:          os.Getenv("NFWS_API_KEY"),
+		AdminKey:        os.Getenv("NFWS_ADMIN_KEY"),
+		KeysFile:        os.Getenv("NFWS_KEYS_FILE"),
+		MaxImageBytes:   int64(env.getInt("NFWS_MAX_IMAGE_BYTES", 10<<20)),
+		DownloadTimeout: env.getDuration("NFWS_DOWNLOAD_TIMEOUT", 15*time.Second),
+		RequestTimeout:  env.getDuration("NFWS_REQUEST_TIMEOUT", 60*time.Second),
+		MockInference:   env.getBool("MOCK_INFERENCE", false),
+		GPU:             env.getBool("NFWS_GPU", false),
+		GPUDevice:       env.getInt("NFWS_GPU_DEVICE", 0),
+		PreferQuantized: env.getBool("NFWS_PREFER_QUANTIZED", false),
+
+		ExportSink:          os.Getenv("NFWS_EXPORT_SINK"),
+		ExportURL:           os.Getenv("NFWS_EXPORT_URL"),
+		ExportTable:         os.Getenv("NFWS_EXPORT_TABLE"),
+		ExportUsername:      os.Getenv("NFWS_EXPORT_USERNAME"),
+		ExportPassword:      os.Getenv("NFWS_EXPORT_PASSWORD"),
+		ExportBatchSize:     env.getInt("NFWS_EXPORT_BATCH_SIZE", 100),
+		ExportFlushInterval: env.getDuration("NFWS_EXPORT_FLUSH_INTERVAL", 5*time.Second),
+
+		StoreDSN:    os.Getenv("NFWS_STORE_DSN"),
+		ClusterMode: env.getBool("NFWS_CLUSTER", false),
+
+		CacheDSN: os.Getenv("NFWS_CACHE_DSN"),
+		CacheTTL: env.getDuration("NFWS_CACHE_TTL", 5*time.Minute),
+
+		StartupWait: env.getDuration("NFWS_STARTUP_WAIT", 0),
+
+		S3Endpoint:      os.Getenv("NFWS_S3_ENDPOINT"),
+		S3Region:        getString("NFWS_S3_REGION", "us-east-1"),
+		S3AccessKey:     os.Getenv("NFWS_S3_ACCESS_KEY"),
+		S3SecretKey:     os.Getenv("NFWS_S3_SECRET_KEY"),
+		S3WritebackMode: os.Getenv("NFWS_S3_WRITEBACK"),
+
+		RescanSchedule: os.Getenv("NFWS_RESCAN_SCHEDULE"),
+		RescanManifest: os.Getenv("NFWS_RESCAN_MANIFEST"),
+
+		LabelsFile: os.Getenv("NFWS_LABELS_FILE"),
+
+		ThumbnailSize:   env.getInt("NFWS_THUMBNAIL_SIZE", 128),
+		ThumbnailBlur:   env.getInt("NFWS_THUMBNAIL_BLUR", 12),
+		ThumbnailBucket: os.Getenv("NFWS_THUMBNAIL_BUCKET"),
+
+		QuarantineBucket:     os.Getenv("NFWS_QUARANTINE_BUCKET"),
+		QuarantineExpiryDays: env.getInt("NFWS_QUARANTINE_EXPIRY_DAYS", 0),
+
+		LottieRenderer: os.Getenv("NFWS_LOTTIE_RENDERER"),
+		ImageConverter: os.Getenv("NFWS_IMAGE_CONVERTER"),
+		VideoFFmpeg:    os.Getenv("NFWS_VIDEO_FFMPEG"),
+
+		VerdictTTL:   env.getDuration("NFWS_VERDICT_TTL", 30*24*time.Hour),
+		Reproducible: env.getBool("NFWS_REPRODUCIBLE", false),
+		ReviewSLA:    env.getDuration("NFWS_REVIEW_SLA", 24*time.Hour),
+		ReviewBlind:  env.getBool("NFWS_REVIEW_BLIND", false),
+
+		ReceiptSecret:  os.Getenv("NFWS_RECEIPT_SECRET"),
+		CallbackSecret: os.Getenv("NFWS_CALLBACK_SECRET"),
+
+		Provider:        os.Getenv("NFWS_PROVIDER"),
+		ProviderURL:     os.Getenv("NFWS_PROVIDER_URL"),
+		ProviderKey:     os.Getenv("NFWS_PROVIDER_KEY"),
+		ProviderTimeout: env.getDuration("NFWS_PROVIDER_TIMEOUT", 10*time.Second),
+
+		DriftWindow:     env.getInt("NFWS_DRIFT_WINDOW", 0),
+		DriftWebhookURL: os.Getenv("NFWS_DRIFT_WEBHOOK_URL"),
+
+		HashListAlertURL: os.Getenv("NFWS_HASHLIST_ALERT_URL"),
+
+		DatasetBucket:       os.Getenv("NFWS_DATASET_BUCKET"),
+		DatasetInlineImages: env.getBool("NFWS_DATASET_INLINE_IMAGES", false),
+
+		EventsWebhookURL: os.Getenv("NFWS_EVENTS_WEBHOOK_URL"),
+		WebhooksFile:     os.Getenv("NFWS_WEBHOOKS_FILE"),
+
+		PluginFile:    os.Getenv("NFWS_PLUGIN_FILE"),
+		PluginHook:    getString("NFWS_PLUGIN_HOOK", "verdict"),
+		PluginTimeout: env.getDuration("NFWS_PLUGIN_TIMEOUT", time.Second),
+
+		PolicyFile: os.Getenv("NFWS_POLICY_FILE"),
+
+		MaxInflightScans:   env.getInt("NFWS_MAX_INFLIGHT_SCANS", 0),
+		MaxInflightControl: env.getInt("NFWS_MAX_INFLIGHT_CONTROL", 0),
+
+		AsyncWorkers: env.getInt("NFWS_ASYNC_WORKERS", 4),
+		JobTTL:       env.getDuration("NFWS_JOB_TTL", 15*time.Minute),
+
+		AdaptiveTargetP95:   env.getDuration("NFWS_ADAPTIVE_P95_TARGET", 0),
+		AdaptiveMaxInflight: env.getInt("NFWS_ADAPTIVE_MAX_INFLIGHT", 64),
+		QueueWait:           env.getDuration("NFWS_QUEUE_WAIT", 0),
+
+		PayloadActiveKey: os.Getenv("NFWS_PAYLOAD_ACTIVE_KEY"),
+		ModelKey:         os.Getenv("NFWS_MODEL_KEY"),
+
+		FlagsSpec: os.Getenv("NFWS_FLAGS"),
+		FlagsFile: os.Getenv("NFWS_FLAGS_FILE"),
+
+		DigestSchedule: os.Getenv("NFWS_DIGEST_SCHEDULE"),
+		SMTPAddr:       os.Getenv("NFWS_SMTP_ADDR"),
+		SMTPFrom:       os.Getenv("NFWS_SMTP_FROM"),
+		SMTPUsername:   os.Getenv("NFWS_SMTP_USERNAME"),
+		SMTPPassword:   os.Getenv("NFWS_SMTP_PASSWORD"),
+	}
+	if v := os.Getenv("NFWS_EVENTS_WEBHOOK_TYPES"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				cfg.EventsWebhookTypes = append(cfg.EventsWebhookTypes, t)
+			}
+		}
+	}
+	if v := os.Getenv("NFWS_GPU_MODELS"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				cfg.GPUModels = append(cfg.GPUModels, t)
+			}
+		}
+	}
+	if v := os.Getenv("NFWS_DIGEST_TO"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				cfg.DigestTo = append(cfg.DigestTo, t)
+			}
+		}
+	}
+	// Problems accumulate instead of short-circuiting so one run reports
+	// everything wrong with an environment, not just the first find. The
+	// typed getters above already collected their parse errors in env.
+	fail := func(format string, args ...any) {
+		env.problems = append(env.problems, fmt.Errorf(format, args...))
+	}
+	var err error
+	if cfg.ModelPreload, err = getPreload("NFWS_MODEL_PRELOAD"); err != nil {
+		env.problems = append(env.problems, err)
+	}
+	if cfg.PayloadKeys, err = getKeyMap("NFWS_PAYLOAD_KEYS"); err != nil {
+		env.problems = append(env.problems, err)
+	}
+	if cfg.HashListSources, err = getKeyMap("NFWS_HASHLIST_SOURCES"); err != nil {
+		env.problems = append(env.problems, err)
+	}
+	if cfg.HashListDistances, err = getDistances("NFWS_HASHLIST_DISTANCES"); err != nil {
+		env.problems = append(env.problems, err)
+	}
+	if cfg.ReviewConsensus, err = getConsensus("NFWS_REVIEW_CONSENSUS"); err != nil {
+		env.problems = append(env.problems, err)
+	}
+	if len(cfg.HashListSources) > 0 && cfg.HashListAlertURL == "" {
+		fail("config: NFWS_HASHLIST_SOURCES requires NFWS_HASHLIST_ALERT_URL")
+	}
+	if len(cfg.GPUModels) > 0 && !cfg.GPU {
+		fail("config: NFWS_GPU_MODELS requires NFWS_GPU")
+	}
+	if cfg.NSFWThreshold = env.getFloat("NFWS_NSFW_THRESHOLD", 0.7); cfg.NSFWThreshold < 0 || cfg.NSFWThreshold > 1 {
+		fail("config: NFWS_NSFW_THRESHOLD must be in [0,1], got %v", cfg.NSFWThreshold)
+	}
+	if cfg.AdultThreshold = env.getFloat("NFWS_ADULT_THRESHOLD", 0.9); cfg.AdultThreshold < 0 || cfg.AdultThreshold > 1 {
+		fail("config: NFWS_ADULT_THRESHOLD must be in [0,1], got %v", cfg.AdultThreshold)
+	}
+	if cfg.ProviderMargin = env.getFloat("NFWS_PROVIDER_MARGIN", 0.1); cfg.ProviderMargin < 0 || cfg.ProviderMargin > 1 {
+		fail("config: NFWS_PROVIDER_MARGIN must be in [0,1], got %v", cfg.ProviderMargin)
+	}
+	if cfg.DriftThreshold = env.getFloat("NFWS_DRIFT_THRESHOLD", 0.2); cfg.DriftThreshold <= 0 {
+		fail("config: NFWS_DRIFT_THRESHOLD must be positive, got %v", cfg.DriftThreshold)
+	}
+	if cfg.DriftWindow < 0 {
+		fail("config: NFWS_DRIFT_WINDOW must be non-negative, got %d", cfg.DriftWindow)
+	}
+	if cfg.CascadeMargin = env.getFloat("NFWS_CASCADE_MARGIN", 0.15); cfg.CascadeMargin < 0 || cfg.CascadeMargin >= 1 {
+		fail("config: NFWS_CASCADE_MARGIN must be in [0,1), got %v", cfg.CascadeMargin)
+	}
+	if cfg.IgnoreMargin = env.getFloat("NFWS_IGNORE_MARGIN", 0); cfg.IgnoreMargin < 0 || cfg.IgnoreMargin >= 0.5 {
+		fail("config: NFWS_IGNORE_MARGIN must be in [0,0.5), got %v", cfg.IgnoreMargin)
+	}
+	if cfg.VideoFPS = env.getFloat("NFWS_VIDEO_FPS", 1); cfg.VideoFPS <= 0 {
+		fail("config: NFWS_VIDEO_FPS must be positive, got %v", cfg.VideoFPS)
+	}
+	switch cfg.S3WritebackMode {
+	case "", "tags", "sidecar":
+	default:
+		fail("config: NFWS_S3_WRITEBACK must be empty, tags, or sidecar, got %q", cfg.S3WritebackMode)
+	}
+	if cfg.AdaptiveTargetP95 > 0 && cfg.AdaptiveMaxInflight < 1 {
+		fail("config: NFWS_ADAPTIVE_MAX_INFLIGHT must be at least 1, got %d", cfg.AdaptiveMaxInflight)
+	}
+	switch cfg.DigestSchedule {
+	case "", "daily", "weekly":
+	default:
+		fail("config: NFWS_DIGEST_SCHEDULE must be empty, daily, or weekly, got %q", cfg.DigestSchedule)
+	}
+	if cfg.DigestSchedule != "" && (cfg.SMTPAddr == "" || cfg.SMTPFrom == "" || len(cfg.DigestTo) == 0) {
+		fail("config: NFWS_DIGEST_SCHEDULE requires NFWS_SMTP_ADDR, NFWS_SMTP_FROM, and NFWS_DIGEST_TO")
+	}
+	if cfg.KeysFile != "" && (cfg.APIKey != "" || cfg.AdminKey != "") {
+		fail("config: NFWS_KEYS_FILE replaces NFWS_API_KEY and NFWS_ADMIN_KEY; unset them")
+	}
+	if (cfg.RescanSchedule == "") != (cfg.RescanManifest == "") {
+		fail("config: NFWS_RESCAN_SCHEDULE and NFWS_RESCAN_MANIFEST must be set together")
+	}
+	if len(env.problems) > 0 {
+		// The populated Config comes back alongside the joined error so
+		// Validate can still run its environmental checks and the whole
+		// problem set surfaces in one pass.
+		return cfg, errors.Join(env.problems...)
+	}
+	return cfg, nil
+}
+
+func getString(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envReader reads typed environment values. A malformed value is never
+// silently replaced by the default: the parse error is collected so Load
+// reports it alongside every other configuration problem, and the
+// default is used only to keep the rest of loading going.
+type envReader struct {
+	problems []error
+}
+
+func (e *envReader) record(key string, err error) {
+	e.problems = append(e.problems, fmt.Errorf("config: %s: %w", key, err))
+}
+
+func (e *envReader) getInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		e.record(key, err)
+		return def
+	}
+	return n
+}
+
+func (e *envReader) getFloat(key string, def float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		e.record(key, err)
+		return def
+	}
+	return f
+}
+
+func (e *envReader) getBool(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.record(key, err)
+		return def
+	}
+	return b
+}
+
+func (e *envReader) getDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		e.record(key, err)
+		return def
+	}
+	return d
+}
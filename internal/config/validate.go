@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Validate runs the environmental checks Load cannot: referenced files
+// and directories must exist and be readable, options that need each
+// other must agree, and — when checkListen is set — the listen address
+// must be bindable. It returns every problem found, not just the first,
+// so an operator fixes a broken environment in one pass. The server
+// runs it at startup (without the listen probe, since binding follows
+// immediately) and the validate-config subcommand with it.
+func (c *Config) Validate(checkListen bool) []error {
+	var problems []error
+	fail := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+	if fi, err := os.Stat(c.ModelsDir); err != nil {
+		fail("config: NFWS_MODELS_DIR: %v", err)
+	} else if !fi.IsDir() {
+		fail("config: NFWS_MODELS_DIR %q is not a directory", c.ModelsDir)
+	} else if _, err := os.ReadDir(c.ModelsDir); err != nil {
+		fail("config: NFWS_MODELS_DIR: %v", err)
+	}
+	for _, f := range []struct{ env, path string }{
+		{"NFWS_KEYS_FILE", c.KeysFile},
+		{"NFWS_LABELS_FILE", c.LabelsFile},
+		{"NFWS_POLICY_FILE", c.PolicyFile},
+		{"NFWS_PLUGIN_FILE", c.PluginFile},
+		{"NFWS_FLAGS_FILE", c.FlagsFile},
+		{"NFWS_WEBHOOKS_FILE", c.WebhooksFile},
+	} {
+		if f.path == "" {
+			continue
+		}
+		if err := checkReadable(f.path); err != nil {
+			fail("config: %s: %v", f.env, err)
+		}
+	}
+	// The rescan manifest may be remote; only local paths are checkable.
+	if c.RescanManifest != "" && !strings.Contains(c.RescanManifest, "://") {
+		if err := checkReadable(c.RescanManifest); err != nil {
+			fail("config: NFWS_RESCAN_MANIFEST: %v", err)
+		}
+	}
+	if c.LottieRenderer != "" {
+		if args := strings.Fields(c.LottieRenderer); len(args) == 0 {
+			fail("config: NFWS_LOTTIE_RENDERER must name a command")
+		} else if _, err := exec.LookPath(args[0]); err != nil {
+			fail("config: NFWS_LOTTIE_RENDERER: %v", err)
+		}
+	}
+	if c.ClusterMode && !strings.HasPrefix(c.StoreDSN, "redis://") && !strings.HasPrefix(c.StoreDSN, "postgres://") {
+		fail("config: NFWS_CLUSTER requires a shared store (redis:// or postgres:// NFWS_STORE_DSN)")
+	}
+	if c.ExportSink != "" && c.ExportURL == "" {
+		fail("config: NFWS_EXPORT_SINK %q requires NFWS_EXPORT_URL", c.ExportSink)
+	}
+	if c.Provider != "" && c.Provider != "vision" && c.ProviderURL == "" {
+		fail("config: NFWS_PROVIDER %q requires NFWS_PROVIDER_URL", c.Provider)
+	}
+	if c.ListenSocket != "" {
+		if _, err := os.Stat(filepath.Dir(c.ListenSocket)); err != nil {
+			fail("config: NFWS_LISTEN_SOCKET: %v", err)
+		}
+	} else if checkListen {
+		ln, err := net.Listen("tcp", c.ListenAddr)
+		if err != nil {
+			fail("config: NFWS_LISTEN_ADDR: %v", err)
+		} else {
+			ln.Close()
+		}
+	}
+	return problems
+}
+
+// checkReadable verifies a configured path names a readable regular file.
+func checkReadable(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("%s is a directory, expected a file", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
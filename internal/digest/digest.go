@@ -0,0 +1,246 @@
+// Package digest emails a periodic summary of moderation activity —
+// scan volumes, flag rates, and top triggering categories per tenant,
+// plus error anomalies — for stakeholders who don't watch dashboards.
+// Aggregates come from the event bus, so the digest sees exactly what
+// the analytics export sees; leader election through the shared store
+// keeps a cluster from mailing one copy per replica.
+package digest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/smtp"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/bus"
+	"github.com/illussioon/NFWS-Moderations-API/internal/export"
+	"github.com/illussioon/NFWS-Moderations-API/internal/stats"
+	"github.com/illussioon/NFWS-Moderations-API/internal/store"
+)
+
+// sendHour is the UTC hour scheduled digests go out; weekly digests go
+// out on Monday.
+const sendHour = 6
+
+// leaseTTL bounds how long a crashed leader blocks the next send.
+const leaseTTL = 5 * time.Minute
+
+// Digest accumulates one reporting window and mails it on schedule.
+type Digest struct {
+	schedule string // "daily" or "weekly"
+	addr     string
+	from     string
+	to       []string
+	username string
+	password string
+	stats    *stats.Collector
+	store    store.Store
+	holder   string
+	log      *slog.Logger
+
+	mu          sync.Mutex
+	windowStart time.Time
+	tenants     map[string]*tenantWindow
+	// errorsAtSend and prevWindowErrors support the anomaly check: the
+	// collector's error counter at the last send, and the error count of
+	// the window before that.
+	errorsAtSend     int64
+	prevWindowErrors int64
+}
+
+// tenantWindow is one tenant's share of the current window.
+type tenantWindow struct {
+	scans      int64
+	flagged    int64
+	categories map[string]int64
+}
+
+// New builds a Digest; subscribe Record on the bus and call Start to
+// activate it.
+func New(schedule, addr, from string, to []string, username, password string, st *stats.Collector, str store.Store, log *slog.Logger) *Digest {
+	host, _ := os.Hostname()
+	return &Digest{
+		schedule:    schedule,
+		addr:        addr,
+		from:        from,
+		to:          to,
+		username:    username,
+		password:    password,
+		stats:       st,
+		store:       str,
+		holder:      fmt.Sprintf("%s-%d", host, os.Getpid()),
+		log:         log,
+		windowStart: time.Now().UTC(),
+		tenants:     make(map[string]*tenantWindow),
+	}
+}
+
+// Record folds one scan event into the current window. It is the bus
+// subscriber for scan.completed.
+func (d *Digest) Record(ev bus.Event) {
+	e, ok := ev.Data.(export.Event)
+	if !ok {
+		return
+	}
+	tenant := e.Tenant
+	if tenant == "" {
+		tenant = "(default)"
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	t := d.tenants[tenant]
+	if t == nil {
+		t = &tenantWindow{categories: make(map[string]int64)}
+		d.tenants[tenant] = t
+	}
+	t.scans++
+	if e.NSFW {
+		t.flagged++
+		for _, det := range e.Detections {
+			t.categories[det.Label]++
+		}
+	}
+}
+
+// Start runs the schedule loop until ctx is cancelled.
+func (d *Digest) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		var lastSent time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				minute := now.UTC().Truncate(time.Minute)
+				if !d.due(minute) || minute.Equal(lastSent) {
+					continue
+				}
+				lastSent = minute
+				ok, err := d.store.Lease(ctx, "digest", d.holder, leaseTTL)
+				if err != nil {
+					d.log.Error("digest leader election failed", "err", err)
+					continue
+				}
+				if !ok {
+					continue // another replica mails this round
+				}
+				if err := d.SendNow(ctx); err != nil {
+					d.log.Error("sending digest failed", "err", err)
+				}
+			}
+		}
+	}()
+}
+
+// due reports whether a digest should go out at the given minute.
+func (d *Digest) due(minute time.Time) bool {
+	if minute.Hour() != sendHour || minute.Minute() != 0 {
+		return false
+	}
+	return d.schedule == "daily" || minute.Weekday() == time.Monday
+}
+
+// SendNow composes and mails the current window, then resets it. The
+// admin trigger uses it directly, outside the schedule.
+func (d *Digest) SendNow(ctx context.Context) error {
+	body, recipients := d.compose(ctx)
+	var auth smtp.Auth
+	if d.username != "" {
+		host := d.addr
+		if i := strings.IndexByte(host, ':'); i >= 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", d.username, d.password, host)
+	}
+	if err := smtp.SendMail(d.addr, auth, d.from, recipients, body); err != nil {
+		return fmt.Errorf("digest: %w", err)
+	}
+	d.log.Info("moderation digest sent", "schedule", d.schedule, "recipients", len(recipients))
+	return nil
+}
+
+// compose renders the email and resets the window.
+func (d *Digest) compose(ctx context.Context) ([]byte, []string) {
+	snap := d.stats.Snapshot(ctx)
+
+	d.mu.Lock()
+	windowStart := d.windowStart
+	tenants := d.tenants
+	windowErrors := snap.Errors - d.errorsAtSend
+	prevErrors := d.prevWindowErrors
+	d.tenants = make(map[string]*tenantWindow)
+	d.windowStart = time.Now().UTC()
+	d.errorsAtSend = snap.Errors
+	d.prevWindowErrors = windowErrors
+	d.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", d.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(d.to, ", "))
+	fmt.Fprintf(&b, "Subject: Moderation digest (%s) %s\r\n", d.schedule, time.Now().UTC().Format("2006-01-02"))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+
+	fmt.Fprintf(&b, "Moderation activity since %s (UTC):\r\n\r\n", windowStart.Format("2006-01-02 15:04"))
+	if len(tenants) == 0 {
+		b.WriteString("No scans in this window.\r\n")
+	}
+	names := make([]string, 0, len(tenants))
+	for name := range tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := tenants[name]
+		rate := 0.0
+		if t.scans > 0 {
+			rate = 100 * float64(t.flagged) / float64(t.scans)
+		}
+		fmt.Fprintf(&b, "%s: %d scans, %d flagged (%.1f%%)", name, t.scans, t.flagged, rate)
+		if top := topCategories(t.categories, 3); len(top) > 0 {
+			fmt.Fprintf(&b, ", top categories: %s", strings.Join(top, ", "))
+		}
+		b.WriteString("\r\n")
+	}
+	fmt.Fprintf(&b, "\r\nScan errors this window: %d (previous window: %d)", windowErrors, prevErrors)
+	// Flag a jump in errors rather than making the reader compare: the
+	// digest exists for people who don't watch the dashboards.
+	if windowErrors > 10 && windowErrors > 2*prevErrors {
+		b.WriteString(" — ELEVATED")
+	}
+	b.WriteString("\r\n")
+	return []byte(b.String()), d.to
+}
+
+// topCategories returns up to n "label (count)" strings, most frequent
+// first.
+func topCategories(categories map[string]int64, n int) []string {
+	type entry struct {
+		label string
+		count int64
+	}
+	entries := make([]entry, 0, len(categories))
+	for label, count := range categories {
+		entries = append(entries, entry{label, count})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		return entries[i].label < entries[j].label
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = fmt.Sprintf("%s (%d)", e.label, e.count)
+	}
+	return out
+}
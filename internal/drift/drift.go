@@ -0,0 +1,223 @@
+// Package drift watches per-model score distributions for shifts
+// against a baseline window — an early signal of upstream content
+// changes or preprocessing regressions that per-scan verdicts won't
+// show.
+//
+// The first full window of scores a model produces becomes its
+// baseline. Each subsequent full window is compared against it with the
+// Population Stability Index (PSI) over ten equal-width buckets on
+// [0,1]; a PSI at or above the configured threshold flags the model as
+// drifting and fires a one-shot webhook alert until it recovers.
+package drift
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// buckets is the histogram resolution for the PSI comparison. Ten is
+// the conventional choice and keeps every bucket populated at the
+// window sizes we run.
+const buckets = 10
+
+// Detector accumulates per-model scores and evaluates drift one full
+// window at a time. All methods are safe for concurrent use and safe on
+// a nil detector, so callers don't need to check whether drift
+// detection is configured.
+type Detector struct {
+	window     int
+	threshold  float64
+	webhookURL string
+	log        *slog.Logger
+
+	mu     sync.Mutex
+	models map[string]*modelWindow
+}
+
+// modelWindow is one model's baseline histogram and in-progress window.
+type modelWindow struct {
+	baseline     [buckets]int
+	baselineFull bool
+	current      [buckets]int
+	count        int
+
+	psi       float64
+	drifting  bool
+	checkedAt time.Time
+	windows   int
+}
+
+// New returns a detector comparing windows of the given size. threshold
+// is the PSI at which a model counts as drifting; webhookURL, when
+// non-empty, receives a JSON alert on each transition into drift.
+func New(window int, threshold float64, webhookURL string, log *slog.Logger) *Detector {
+	return &Detector{
+		window:     window,
+		threshold:  threshold,
+		webhookURL: webhookURL,
+		log:        log,
+		models:     make(map[string]*modelWindow),
+	}
+}
+
+// Record adds one score for a model and, when it completes a window,
+// evaluates that window against the baseline.
+func (d *Detector) Record(model string, score float64) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	mw := d.models[model]
+	if mw == nil {
+		mw = &modelWindow{}
+		d.models[model] = mw
+	}
+	b := int(score * buckets)
+	if b >= buckets {
+		b = buckets - 1
+	}
+	if b < 0 {
+		b = 0
+	}
+	mw.current[b]++
+	mw.count++
+	if mw.count < d.window {
+		d.mu.Unlock()
+		return
+	}
+	// Window complete: the first one becomes the baseline, later ones
+	// are scored against it.
+	var alert *Alert
+	if !mw.baselineFull {
+		mw.baseline, mw.baselineFull = mw.current, true
+	} else {
+		mw.psi = psi(mw.baseline, mw.current)
+		mw.checkedAt = time.Now().UTC()
+		mw.windows++
+		was := mw.drifting
+		mw.drifting = mw.psi >= d.threshold
+		if mw.drifting && !was {
+			alert = &Alert{
+				Model:     model,
+				PSI:       mw.psi,
+				Threshold: d.threshold,
+				Window:    d.window,
+				Time:      mw.checkedAt,
+			}
+		} else if !mw.drifting && was {
+			d.log.Info("score distribution recovered", "model", model, "psi", mw.psi)
+		}
+	}
+	mw.current, mw.count = [buckets]int{}, 0
+	d.mu.Unlock()
+	if alert != nil {
+		d.fire(*alert)
+	}
+}
+
+// Alert is the webhook payload for one model entering drift.
+type Alert struct {
+	Type      string    `json:"type"`
+	Model     string    `json:"model"`
+	PSI       float64   `json:"psi"`
+	Threshold float64   `json:"threshold"`
+	Window    int       `json:"window"`
+	Time      time.Time `json:"time"`
+}
+
+// fire logs the alert and, when a webhook is configured, delivers it
+// detached and best-effort — drift alerting never delays a scan.
+func (d *Detector) fire(a Alert) {
+	a.Type = "drift"
+	d.log.Warn("score distribution drift detected",
+		"model", a.Model, "psi", a.PSI, "threshold", a.Threshold, "window", a.Window)
+	if d.webhookURL == "" {
+		return
+	}
+	body, err := json.Marshal(a)
+	if err != nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.webhookURL, bytes.NewReader(body))
+		if err != nil {
+			d.log.Error("drift webhook failed", "err", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			d.log.Error("drift webhook failed", "err", err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			d.log.Error("drift webhook failed", "err", fmt.Errorf("webhook returned %d: %s", resp.StatusCode, msg))
+		}
+	}()
+}
+
+// ModelStatus is one model's drift state as reported by /stats.
+type ModelStatus struct {
+	// Drifting is true while the latest window's PSI is at or above the
+	// threshold.
+	Drifting bool    `json:"drifting"`
+	PSI      float64 `json:"psi"`
+	// Windows is how many windows have been compared against the
+	// baseline so far; 0 means the baseline is still filling.
+	Windows   int    `json:"windows"`
+	CheckedAt string `json:"checked_at,omitempty"`
+}
+
+// Status reports the per-model drift state. Nil-safe: an unconfigured
+// detector reports nothing.
+func (d *Detector) Status() map[string]ModelStatus {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.models) == 0 {
+		return nil
+	}
+	out := make(map[string]ModelStatus, len(d.models))
+	for name, mw := range d.models {
+		st := ModelStatus{Drifting: mw.drifting, PSI: mw.psi, Windows: mw.windows}
+		if !mw.checkedAt.IsZero() {
+			st.CheckedAt = mw.checkedAt.Format(time.RFC3339)
+		}
+		out[name] = st
+	}
+	return out
+}
+
+// psi computes the Population Stability Index between two histograms of
+// equal sample count. Empty buckets are floored at a small epsilon so a
+// class appearing or vanishing entirely contributes a large, finite
+// term.
+func psi(baseline, current [buckets]int) float64 {
+	var bn, cn int
+	for i := 0; i < buckets; i++ {
+		bn += baseline[i]
+		cn += current[i]
+	}
+	const eps = 1e-4
+	var sum float64
+	for i := 0; i < buckets; i++ {
+		p := math.Max(float64(baseline[i])/float64(bn), eps)
+		q := math.Max(float64(current[i])/float64(cn), eps)
+		sum += (q - p) * math.Log(q/p)
+	}
+	return sum
+}
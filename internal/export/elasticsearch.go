@@ -0,0 +1,37 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// esSink indexes events into Elasticsearch or OpenSearch through the
+// _bulk API. Documents land in a single index so the T&S team can search
+// verdicts and detections by class, time range, and source.
+type esSink struct {
+	// baseURL is the cluster root, e.g. http://es:9200.
+	baseURL string
+	index   string
+	// username and password enable basic auth when non-empty.
+	username, password string
+}
+
+func (s *esSink) Name() string { return "elasticsearch" }
+
+func (s *esSink) Send(ctx context.Context, events []Event) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	action := fmt.Sprintf(`{"index":{"_index":%q}}`, s.index)
+	for _, ev := range events {
+		buf.WriteString(action)
+		buf.WriteByte('\n')
+		if err := enc.Encode(ev); err != nil {
+			return err
+		}
+	}
+	url := strings.TrimSuffix(s.baseURL, "/") + "/_bulk"
+	return postAuth(ctx, url, "application/x-ndjson", buf.Bytes(), s.username, s.password)
+}
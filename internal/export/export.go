@@ -0,0 +1,230 @@
+// Package export ships scan event records to an external analytics sink
+// in batches. Events carry metadata and verdicts only — never image
+// bytes — so the pipeline is safe to point at long-retention storage.
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Event is one scan's analytics record.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Source    string    `json:"source"` // "url" or "upload"
+	Format    string    `json:"format"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	NSFW      bool      `json:"nsfw"`
+	Score     float64   `json:"score"`
+	Threshold float64   `json:"threshold"`
+	// ModelScores maps model name to its nsfw score contribution.
+	ModelScores map[string]float64 `json:"model_scores"`
+	// Detections carries detector bounding boxes so downstream search
+	// (e.g. "all images flagged for class X") has the per-class detail.
+	Detections []EventDetection `json:"detections,omitempty"`
+	DurationMS float64          `json:"duration_ms"`
+	// ExternalID and BatchID echo the request's upstream correlation
+	// IDs, when the caller supplied them.
+	ExternalID string `json:"external_id,omitempty"`
+	BatchID    string `json:"batch_id,omitempty"`
+	// Tenant is the calling key's name; empty in single-key mode.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// EventDetection mirrors api.Detection for the analytics record.
+type EventDetection struct {
+	Model string  `json:"model"`
+	Label string  `json:"label"`
+	Score float64 `json:"score"`
+}
+
+// Sink delivers a batch of events.
+type Sink interface {
+	// Send delivers the batch; a non-nil error drops the batch (the
+	// exporter logs it but never blocks scanning on analytics).
+	Send(ctx context.Context, events []Event) error
+	Name() string
+}
+
+// Exporter buffers events and flushes them by size or interval.
+type Exporter struct {
+	sink      Sink
+	ch        chan Event
+	batchSize int
+	interval  time.Duration
+	log       *slog.Logger
+	done      chan struct{}
+}
+
+// New starts an exporter goroutine flushing to sink.
+func New(sink Sink, batchSize int, interval time.Duration, log *slog.Logger) *Exporter {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	e := &Exporter{
+		sink:      sink,
+		ch:        make(chan Event, batchSize*4),
+		batchSize: batchSize,
+		interval:  interval,
+		log:       log,
+		done:      make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Emit queues an event. It never blocks: when the buffer is full the
+// event is dropped and counted against the next flush log line. Safe on
+// a nil exporter so callers don't need to check whether export is
+// configured.
+func (e *Exporter) Emit(ev Event) {
+	if e == nil {
+		return
+	}
+	select {
+	case e.ch <- ev:
+	default:
+		e.log.Warn("export buffer full, dropping event")
+	}
+}
+
+// Close flushes buffered events and stops the exporter.
+func (e *Exporter) Close() {
+	if e == nil {
+		return
+	}
+	close(e.ch)
+	<-e.done
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	batch := make([]Event, 0, e.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := e.sink.Send(ctx, batch)
+		cancel()
+		if err != nil {
+			e.log.Error("export flush failed", "sink", e.sink.Name(), "events", len(batch), "err", err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case ev, ok := <-e.ch:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, ev)
+			if len(batch) >= e.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// NewSink builds a sink from config. kind is "clickhouse",
+// "elasticsearch", or "http". table doubles as the index name for
+// Elasticsearch; username and password enable basic auth where the sink
+// supports it.
+func NewSink(kind, rawURL, table, username, password string) (Sink, error) {
+	if rawURL == "" {
+		return nil, fmt.Errorf("export: sink %q requires NFWS_EXPORT_URL", kind)
+	}
+	switch kind {
+	case "clickhouse":
+		if table == "" {
+			table = "nfws_scan_events"
+		}
+		return &clickhouseSink{url: rawURL, table: table}, nil
+	case "elasticsearch":
+		if table == "" {
+			table = "nfws-scans"
+		}
+		return &esSink{baseURL: rawURL, index: table, username: username, password: password}, nil
+	case "http":
+		return &httpSink{url: rawURL}, nil
+	default:
+		return nil, fmt.Errorf("export: unknown sink kind %q", kind)
+	}
+}
+
+// httpSink POSTs the batch as a JSON array to a caller-provided endpoint.
+type httpSink struct {
+	url string
+}
+
+func (s *httpSink) Name() string { return "http" }
+
+func (s *httpSink) Send(ctx context.Context, events []Event) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	return post(ctx, s.url, "application/json", body)
+}
+
+// clickhouseSink inserts via the ClickHouse HTTP interface using the
+// JSONEachRow format, one JSON object per line.
+type clickhouseSink struct {
+	url   string
+	table string
+}
+
+func (s *clickhouseSink) Name() string { return "clickhouse" }
+
+func (s *clickhouseSink) Send(ctx context.Context, events []Event) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, ev := range events {
+		if err := enc.Encode(ev); err != nil {
+			return err
+		}
+	}
+	u := s.url + "?query=" + url.QueryEscape(fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", s.table))
+	return post(ctx, u, "application/x-ndjson", buf.Bytes())
+}
+
+func post(ctx context.Context, url, contentType string, body []byte) error {
+	return postAuth(ctx, url, contentType, body, "", "")
+}
+
+func postAuth(ctx context.Context, url, contentType string, body []byte, username, password string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if username != "" || password != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("export: sink returned %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+// Package flags is a lightweight feature-flag layer for rolling risky
+// behaviors out gradually. Flags come from three places, later ones
+// winning: a JSON file (NFWS_FLAGS_FILE), inline environment toggles
+// (NFWS_FLAGS), and runtime overrides persisted in the store via the
+// admin API — with a redis:// store those reach every replica on
+// restart. A feature with no flag at all counts as fully released, so
+// the layer costs nothing until a rollout needs it.
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/store"
+)
+
+// Bucket is the store bucket holding runtime flag overrides.
+const Bucket = "flags"
+
+// Flag is one feature's rollout state. Enabled, when set, decides for
+// every tenant. Otherwise Tenants is an explicit allowlist and Percent
+// admits that share of tenants by a stable hash, so a tenant stays in
+// or out as the percentage only moves monotonically.
+type Flag struct {
+	Enabled *bool    `json:"enabled,omitempty"`
+	Percent int      `json:"percent,omitempty"`
+	Tenants []string `json:"tenants,omitempty"`
+}
+
+// on evaluates the flag for one tenant.
+func (f Flag) on(name, tenant string) bool {
+	if f.Enabled != nil {
+		return *f.Enabled
+	}
+	for _, t := range f.Tenants {
+		if t == tenant {
+			return true
+		}
+	}
+	if f.Percent > 0 {
+		h := fnv.New32a()
+		h.Write([]byte(name + "\x00" + tenant))
+		return int(h.Sum32()%100) < f.Percent
+	}
+	return false
+}
+
+// Set holds the static flags from file and environment plus runtime
+// overrides. A nil Set treats every feature as released.
+type Set struct {
+	mu        sync.RWMutex
+	static    map[string]Flag
+	overrides map[string]Flag
+}
+
+// New builds a Set over the static flag table.
+func New(static map[string]Flag) *Set {
+	return &Set{static: static, overrides: make(map[string]Flag)}
+}
+
+// Enabled reports whether the named feature is on for a tenant. A
+// feature nobody has flagged is on: flags gate rollouts, not released
+// behavior.
+func (s *Set) Enabled(name, tenant string) bool {
+	if s == nil {
+		return true
+	}
+	s.mu.RLock()
+	f, ok := s.overrides[name]
+	if !ok {
+		f, ok = s.static[name]
+	}
+	s.mu.RUnlock()
+	if !ok {
+		return true
+	}
+	return f.on(name, tenant)
+}
+
+// Snapshot returns every configured flag with overrides applied, for
+// the admin listing.
+func (s *Set) Snapshot() map[string]Flag {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]Flag, len(s.static)+len(s.overrides))
+	for name, f := range s.static {
+		out[name] = f
+	}
+	for name, f := range s.overrides {
+		out[name] = f
+	}
+	return out
+}
+
+// Override replaces a flag at runtime.
+func (s *Set) Override(name string, f Flag) {
+	s.mu.Lock()
+	s.overrides[name] = f
+	s.mu.Unlock()
+}
+
+// ClearOverride drops a runtime override, reverting the flag to its
+// static value (or full release if it has none).
+func (s *Set) ClearOverride(name string) {
+	s.mu.Lock()
+	delete(s.overrides, name)
+	s.mu.Unlock()
+}
+
+// LoadStored applies the overrides persisted in the store, so runtime
+// changes survive a restart.
+func (s *Set) LoadStored(ctx context.Context, st store.Store) error {
+	records, err := st.List(ctx, Bucket, "", 0)
+	if err != nil {
+		return fmt.Errorf("flags: loading stored overrides: %w", err)
+	}
+	for _, rec := range records {
+		var f Flag
+		if err := json.Unmarshal(rec.Value, &f); err != nil {
+			return fmt.Errorf("flags: stored override %q: %w", rec.Key, err)
+		}
+		s.Override(rec.Key, f)
+	}
+	return nil
+}
+
+// ParseSpec parses the NFWS_FLAGS inline syntax: a comma-separated list
+// of "name" or "name=on" (fully on), "name=off", "name=25%" (percentage
+// rollout), or "name=tenantA+tenantB" (allowlist).
+func ParseSpec(spec string) (map[string]Flag, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	out := make(map[string]Flag)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, hasValue := strings.Cut(entry, "=")
+		if name == "" {
+			return nil, fmt.Errorf("flags: entry %q: missing flag name", entry)
+		}
+		if _, dup := out[name]; dup {
+			return nil, fmt.Errorf("flags: duplicate entry for %q", name)
+		}
+		var f Flag
+		switch {
+		case !hasValue, value == "on":
+			on := true
+			f.Enabled = &on
+		case value == "off":
+			off := false
+			f.Enabled = &off
+		case strings.HasSuffix(value, "%"):
+			pct, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+			if err != nil || pct < 0 || pct > 100 {
+				return nil, fmt.Errorf("flags: entry %q: percentage must be 0-100", entry)
+			}
+			f.Percent = pct
+		default:
+			f.Tenants = strings.Split(value, "+")
+			sort.Strings(f.Tenants)
+		}
+		out[name] = f
+	}
+	return out, nil
+}
+
+// LoadFile reads a JSON flag table mapping flag names to Flag objects.
+func LoadFile(path string) (map[string]Flag, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("flags: %w", err)
+	}
+	var out map[string]Flag
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("flags: parsing %s: %w", path, err)
+	}
+	for name, f := range out {
+		if f.Percent < 0 || f.Percent > 100 {
+			return nil, fmt.Errorf("flags: %s: %q percent must be 0-100", path, name)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,254 @@
+// Package hashlist matches scanned images against industry hash lists
+// (NCMEC/IWF-style) before any inference runs. Lists are newline-
+// delimited digests fetched from a configured source; the format of
+// each entry is inferred from its length: 32 hex characters is MD5, 40
+// is SHA-1, and 64 is a PDQ perceptual hash. Digest matching is exact;
+// PDQ entries match within a configurable per-list Hamming distance.
+// Known-content matching is cheap next to inference, so it always runs
+// ahead of the models.
+package hashlist
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/bits"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Formats of a list entry.
+const (
+	FormatMD5  = "md5"
+	FormatSHA1 = "sha1"
+	FormatPDQ  = "pdq"
+)
+
+// Match is one hash-list hit.
+type Match struct {
+	// List names the list the hash came from.
+	List string `json:"list"`
+	// Format is the matched hash's format.
+	Format string `json:"format"`
+	// Hash is the matched digest, hex-encoded.
+	Hash string `json:"hash"`
+	// Distance is the Hamming bit distance between the image's
+	// perceptual hash and the matched entry; 0 for exact matches.
+	Distance int `json:"distance"`
+}
+
+// Distance tunes one list's perceptual matching. Metric is "exact"
+// (identical hashes only, the default) or "hamming"; Max is the highest
+// Hamming bit distance that still counts as a match.
+type Distance struct {
+	Metric string
+	Max    int
+}
+
+// list holds one loaded hash list.
+type list struct {
+	name string
+	md5  map[string]bool
+	sha1 map[string]bool
+	pdq  []pdqEntry
+	// maxDistance is the list's Hamming match threshold; 0 means exact.
+	maxDistance int
+}
+
+// pdqEntry is one PDQ hash, kept in both forms: hex for reporting,
+// bits for distance computation.
+type pdqEntry struct {
+	hex  string
+	bits []byte
+}
+
+// Matcher matches image bytes against every loaded list. A nil Matcher
+// matches nothing, so the scan path doesn't branch on configuration.
+type Matcher struct {
+	lists []*list
+}
+
+// Load reads every named source: a local path or an http(s):// URL per
+// list, with each list's matching behavior taken from distances (exact
+// when absent). Returns nil when sources is empty.
+func Load(sources map[string]string, distances map[string]Distance) (*Matcher, error) {
+	if len(sources) == 0 {
+		return nil, nil
+	}
+	m := &Matcher{}
+	for name, location := range sources {
+		l, err := loadList(name, location)
+		if err != nil {
+			return nil, err
+		}
+		if d, ok := distances[name]; ok && d.Metric == "hamming" {
+			l.maxDistance = d.Max
+		}
+		m.lists = append(m.lists, l)
+	}
+	return m, nil
+}
+
+// Match checks the image against every list: its exact digests, and —
+// when pdq is non-nil — its perceptual hash against each list's PDQ
+// entries within that list's distance threshold. The first hit wins.
+func (m *Matcher) Match(data []byte, pdq []byte) *Match {
+	if m == nil {
+		return nil
+	}
+	md5sum := md5.Sum(data)
+	sha1sum := sha1.Sum(data)
+	md5hex := hex.EncodeToString(md5sum[:])
+	sha1hex := hex.EncodeToString(sha1sum[:])
+	for _, l := range m.lists {
+		if l.md5[md5hex] {
+			return &Match{List: l.name, Format: FormatMD5, Hash: md5hex}
+		}
+		if l.sha1[sha1hex] {
+			return &Match{List: l.name, Format: FormatSHA1, Hash: sha1hex}
+		}
+		if pdq == nil {
+			continue
+		}
+		for _, entry := range l.pdq {
+			if d := Hamming(entry.bits, pdq); d <= l.maxDistance {
+				return &Match{List: l.name, Format: FormatPDQ, Hash: entry.hex, Distance: d}
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup checks one precomputed hash against every list: md5 and sha1
+// digests exactly, pdq (bits is the decoded hash) within each list's
+// distance threshold. Unlike Match it touches no image bytes.
+func (m *Matcher) Lookup(format, hash string, bits []byte) *Match {
+	if m == nil {
+		return nil
+	}
+	for _, l := range m.lists {
+		switch format {
+		case FormatMD5:
+			if l.md5[hash] {
+				return &Match{List: l.name, Format: format, Hash: hash}
+			}
+		case FormatSHA1:
+			if l.sha1[hash] {
+				return &Match{List: l.name, Format: format, Hash: hash}
+			}
+		case FormatPDQ:
+			for _, entry := range l.pdq {
+				if d := Hamming(entry.bits, bits); d <= l.maxDistance {
+					return &Match{List: l.name, Format: format, Hash: entry.hex, Distance: d}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// NeedsPDQ reports whether any loaded list carries PDQ entries, so the
+// scan path only pays for perceptual hashing when it can match.
+func (m *Matcher) NeedsPDQ() bool {
+	if m == nil {
+		return false
+	}
+	for _, l := range m.lists {
+		if len(l.pdq) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Hamming returns the bit distance between two equal-length hashes, or
+// the full bit count when lengths differ.
+func Hamming(a, b []byte) int {
+	if len(a) != len(b) {
+		return 8 * len(a)
+	}
+	d := 0
+	for i := range a {
+		d += bits.OnesCount8(a[i] ^ b[i])
+	}
+	return d
+}
+
+// Entries reports how many hashes each list carries, per format, for
+// startup logging and health reporting.
+func (m *Matcher) Entries() map[string]int {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]int, len(m.lists))
+	for _, l := range m.lists {
+		out[l.name] = len(l.md5) + len(l.sha1) + len(l.pdq)
+	}
+	return out
+}
+
+// loadList fetches and parses one source.
+func loadList(name, location string) (*list, error) {
+	var r io.ReadCloser
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		client := &http.Client{Timeout: 30 * time.Second}
+		resp, err := client.Get(location)
+		if err != nil {
+			return nil, fmt.Errorf("hashlist: fetching %s: %w", name, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("hashlist: fetching %s: status %d", name, resp.StatusCode)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(location)
+		if err != nil {
+			return nil, fmt.Errorf("hashlist: %s: %w", name, err)
+		}
+		r = f
+	}
+	defer r.Close()
+
+	l := &list{
+		name: name,
+		md5:  make(map[string]bool),
+		sha1: make(map[string]bool),
+	}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		entry = strings.ToLower(entry)
+		decoded, err := hex.DecodeString(entry)
+		if err != nil {
+			return nil, fmt.Errorf("hashlist: %s line %d: not a hex digest", name, line)
+		}
+		switch len(entry) {
+		case 2 * md5.Size:
+			l.md5[entry] = true
+		case 2 * sha1.Size:
+			l.sha1[entry] = true
+		case 64:
+			l.pdq = append(l.pdq, pdqEntry{hex: entry, bits: decoded})
+		default:
+			return nil, fmt.Errorf("hashlist: %s line %d: unrecognized digest length %d", name, line, len(entry))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("hashlist: reading %s: %w", name, err)
+	}
+	if len(l.md5)+len(l.sha1)+len(l.pdq) == 0 {
+		return nil, fmt.Errorf("hashlist: %s contains no entries", name)
+	}
+	return l, nil
+}
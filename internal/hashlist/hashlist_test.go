@@ -0,0 +1,134 @@
+package hashlist
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeList writes one hash list to a temp file and returns its path.
+func writeList(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "list.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMatchExactDigests(t *testing.T) {
+	known := []byte("known bad content")
+	md5sum := md5.Sum(known)
+	sha1sum := sha1.Sum(known)
+	path := writeList(t,
+		"# comment and blank lines are skipped",
+		"",
+		hex.EncodeToString(md5sum[:]),
+		hex.EncodeToString(sha1sum[:]),
+	)
+	m, err := Load(map[string]string{"industry": path}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := m.Match(known, nil)
+	if got == nil || got.List != "industry" || got.Format != FormatMD5 {
+		t.Fatalf("Match(known) = %+v, want md5 hit on industry", got)
+	}
+	if got := m.Match([]byte("innocent content"), nil); got != nil {
+		t.Errorf("Match(innocent) = %+v, want nil", got)
+	}
+}
+
+func TestMatchPDQWithinDistance(t *testing.T) {
+	entry := strings.Repeat("00", 32)
+	path := writeList(t, entry)
+
+	// One flipped bit in the image's hash.
+	near := make([]byte, 32)
+	near[7] = 0x10
+	// Five flipped bits.
+	far := make([]byte, 32)
+	far[0] = 0x1f
+
+	m, err := Load(map[string]string{"pdqlist": path},
+		map[string]Distance{"pdqlist": {Metric: "hamming", Max: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := m.Match([]byte("payload"), near)
+	if got == nil || got.Format != FormatPDQ || got.Distance != 1 {
+		t.Fatalf("Match(near) = %+v, want pdq hit at distance 1", got)
+	}
+	if got := m.Match([]byte("payload"), far); got != nil {
+		t.Errorf("Match(far) = %+v, want nil past the distance cap", got)
+	}
+
+	// Exact metric (the default) must reject even a 1-bit neighbour.
+	exact, err := Load(map[string]string{"pdqlist": path}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := exact.Match([]byte("payload"), near); got != nil {
+		t.Errorf("exact Match(near) = %+v, want nil", got)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	entry := strings.Repeat("00", 32)
+	md5hex := strings.Repeat("11", 16)
+	path := writeList(t, entry, md5hex)
+	m, err := Load(map[string]string{"l": path},
+		map[string]Distance{"l": {Metric: "hamming", Max: 8}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Lookup(FormatMD5, md5hex, nil); got == nil {
+		t.Error("Lookup(md5) missed a listed digest")
+	}
+	if got := m.Lookup(FormatSHA1, md5hex, nil); got != nil {
+		t.Errorf("Lookup(sha1) = %+v, want nil for wrong format", got)
+	}
+	bits := make([]byte, 32)
+	bits[0] = 0x03
+	got := m.Lookup(FormatPDQ, strings.Repeat("00", 31)+"03", bits)
+	if got == nil || got.Distance != 2 {
+		t.Errorf("Lookup(pdq) = %+v, want hit at distance 2", got)
+	}
+}
+
+func TestNilMatcher(t *testing.T) {
+	var m *Matcher
+	if m.Match([]byte("x"), nil) != nil || m.Lookup(FormatMD5, "", nil) != nil || m.NeedsPDQ() {
+		t.Error("nil Matcher must match nothing")
+	}
+	if m, err := Load(nil, nil); err != nil || m != nil {
+		t.Errorf("Load(nil) = %v, %v; want nil, nil", m, err)
+	}
+}
+
+func TestLoadRejectsBadLists(t *testing.T) {
+	cases := [][]string{
+		{"zz" + strings.Repeat("00", 15)}, // not hex
+		{strings.Repeat("00", 10)},        // unrecognized length
+		{"# only a comment"},              // no entries
+	}
+	for _, lines := range cases {
+		path := writeList(t, lines...)
+		if _, err := Load(map[string]string{"l": path}, nil); err == nil {
+			t.Errorf("Load accepted bad list %q", lines)
+		}
+	}
+}
+
+func TestHamming(t *testing.T) {
+	if d := Hamming([]byte{0xff, 0x00}, []byte{0x00, 0x01}); d != 9 {
+		t.Errorf("Hamming = %d, want 9", d)
+	}
+	if d := Hamming([]byte{0xff}, []byte{0xff, 0x00}); d != 8 {
+		t.Errorf("length mismatch Hamming = %d, want full bit count 8", d)
+	}
+}
@@ -0,0 +1,198 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+)
+
+// APNG support: the stdlib png decoder returns only the default image,
+// so animated stickers would silently scan a single frame. This decoder
+// walks the chunk stream, reassembles each animation frame as a
+// standalone PNG, and composites frames onto the canvas according to
+// their dispose and blend operations.
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// isAPNG reports whether PNG data carries an animation control chunk.
+func isAPNG(data []byte) bool {
+	return bytes.HasPrefix(data, pngSignature) && bytes.Contains(data, []byte("acTL"))
+}
+
+// apng dispose operations.
+const (
+	apngDisposeNone       = 0
+	apngDisposeBackground = 1
+	apngDisposePrevious   = 2
+)
+
+// apng blend operations.
+const (
+	apngBlendSource = 0
+	apngBlendOver   = 1
+)
+
+type apngChunk struct {
+	typ  string
+	data []byte
+}
+
+type apngFrame struct {
+	x, y    int
+	w, h    int
+	dispose byte
+	blend   byte
+	idat    [][]byte
+}
+
+// decodeAPNG decodes every animation frame of an APNG, composited onto
+// the full canvas.
+func decodeAPNG(data []byte) ([]image.Image, error) {
+	chunks, err := apngChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		ihdr    []byte
+		shared  []apngChunk // PLTE, tRNS, gAMA, ... needed by every frame
+		frames  []apngFrame
+		current *apngFrame
+		sawFCTL bool
+	)
+	for _, c := range chunks {
+		switch c.typ {
+		case "IHDR":
+			ihdr = c.data
+		case "PLTE", "tRNS", "gAMA", "sRGB", "sBIT":
+			shared = append(shared, c)
+		case "fcTL":
+			if len(c.data) < 26 {
+				return nil, fmt.Errorf("imageproc: apng: short fcTL chunk")
+			}
+			frames = append(frames, apngFrame{
+				w:       int(binary.BigEndian.Uint32(c.data[4:8])),
+				h:       int(binary.BigEndian.Uint32(c.data[8:12])),
+				x:       int(binary.BigEndian.Uint32(c.data[12:16])),
+				y:       int(binary.BigEndian.Uint32(c.data[16:20])),
+				dispose: c.data[24],
+				blend:   c.data[25],
+			})
+			current = &frames[len(frames)-1]
+			sawFCTL = true
+		case "IDAT":
+			// The default image is an animation frame only when an fcTL
+			// precedes the IDAT stream.
+			if sawFCTL && current != nil {
+				current.idat = append(current.idat, c.data)
+			}
+		case "fdAT":
+			if current == nil || len(c.data) < 4 {
+				continue
+			}
+			// fdAT is IDAT with a 4-byte sequence number prefix.
+			current.idat = append(current.idat, c.data[4:])
+		}
+	}
+	if ihdr == nil || len(frames) == 0 {
+		return nil, fmt.Errorf("imageproc: apng: no animation frames")
+	}
+
+	canvasW := int(binary.BigEndian.Uint32(ihdr[0:4]))
+	canvasH := int(binary.BigEndian.Uint32(ihdr[4:8]))
+	canvas := image.NewNRGBA(image.Rect(0, 0, canvasW, canvasH))
+	var out []image.Image
+	for _, f := range frames {
+		if len(f.idat) == 0 {
+			continue
+		}
+		img, err := decodeAPNGFrame(ihdr, shared, f)
+		if err != nil {
+			return nil, err
+		}
+		var restore *image.NRGBA
+		if f.dispose == apngDisposePrevious {
+			restore = image.NewNRGBA(canvas.Bounds())
+			copy(restore.Pix, canvas.Pix)
+		}
+		region := image.Rect(f.x, f.y, f.x+f.w, f.y+f.h)
+		op := draw.Over
+		if f.blend == apngBlendSource {
+			op = draw.Src
+		}
+		draw.Draw(canvas, region, img, img.Bounds().Min, op)
+
+		snapshot := image.NewNRGBA(canvas.Bounds())
+		copy(snapshot.Pix, canvas.Pix)
+		out = append(out, snapshot)
+
+		switch f.dispose {
+		case apngDisposeBackground:
+			draw.Draw(canvas, region, image.Transparent, image.Point{}, draw.Src)
+		case apngDisposePrevious:
+			canvas = restore
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("imageproc: apng: no decodable frames")
+	}
+	return out, nil
+}
+
+// decodeAPNGFrame rebuilds one frame as a standalone PNG and decodes it.
+func decodeAPNGFrame(ihdr []byte, shared []apngChunk, f apngFrame) (image.Image, error) {
+	frameIHDR := append([]byte(nil), ihdr...)
+	binary.BigEndian.PutUint32(frameIHDR[0:4], uint32(f.w))
+	binary.BigEndian.PutUint32(frameIHDR[4:8], uint32(f.h))
+
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+	writeChunk(&buf, "IHDR", frameIHDR)
+	for _, c := range shared {
+		writeChunk(&buf, c.typ, c.data)
+	}
+	for _, d := range f.idat {
+		writeChunk(&buf, "IDAT", d)
+	}
+	writeChunk(&buf, "IEND", nil)
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("imageproc: apng: decoding frame: %w", err)
+	}
+	return img, nil
+}
+
+func apngChunks(data []byte) ([]apngChunk, error) {
+	rest := data[len(pngSignature):]
+	var chunks []apngChunk
+	for len(rest) >= 12 {
+		length := int(binary.BigEndian.Uint32(rest[0:4]))
+		if len(rest) < 12+length {
+			return nil, fmt.Errorf("imageproc: apng: truncated chunk")
+		}
+		chunks = append(chunks, apngChunk{
+			typ:  string(rest[4:8]),
+			data: rest[8 : 8+length],
+		})
+		rest = rest[12+length:]
+	}
+	return chunks, nil
+}
+
+func writeChunk(buf *bytes.Buffer, typ string, data []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	buf.Write(length[:])
+	buf.WriteString(typ)
+	buf.Write(data)
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(data)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	buf.Write(sum[:])
+}
@@ -0,0 +1,131 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// solidNRGBA returns a w x h image filled with c.
+func solidNRGBA(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+// pngChunksOf encodes img with the stdlib encoder and splits the result
+// back into chunks, for reassembly into an APNG.
+func pngChunksOf(t *testing.T, img image.Image) []apngChunk {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := apngChunks(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chunks
+}
+
+// fcTL builds a frame control chunk.
+func fcTL(seq, w, h, x, y uint32, dispose, blend byte) []byte {
+	data := make([]byte, 26)
+	binary.BigEndian.PutUint32(data[0:4], seq)
+	binary.BigEndian.PutUint32(data[4:8], w)
+	binary.BigEndian.PutUint32(data[8:12], h)
+	binary.BigEndian.PutUint32(data[12:16], x)
+	binary.BigEndian.PutUint32(data[16:20], y)
+	data[24], data[25] = dispose, blend
+	return data
+}
+
+// buildAPNG assembles a 4x4 two-frame APNG: a red base frame, then a
+// 2x2 blue patch at (1,1) blended over it.
+func buildAPNG(t *testing.T, dispose byte) []byte {
+	t.Helper()
+	base := pngChunksOf(t, solidNRGBA(4, 4, color.NRGBA{R: 255, A: 255}))
+	patch := pngChunksOf(t, solidNRGBA(2, 2, color.NRGBA{B: 255, A: 255}))
+
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+	for _, c := range base {
+		switch c.typ {
+		case "IHDR":
+			writeChunk(&buf, "IHDR", c.data)
+			acTL := make([]byte, 8)
+			binary.BigEndian.PutUint32(acTL[0:4], 2) // frames
+			writeChunk(&buf, "acTL", acTL)
+			writeChunk(&buf, "fcTL", fcTL(0, 4, 4, 0, 0, dispose, apngBlendSource))
+		case "IDAT":
+			writeChunk(&buf, "IDAT", c.data)
+		}
+	}
+	writeChunk(&buf, "fcTL", fcTL(1, 2, 2, 1, 1, apngDisposeNone, apngBlendOver))
+	for _, c := range patch {
+		if c.typ == "IDAT" {
+			// fdAT is IDAT with a sequence number prefix.
+			fdAT := append([]byte{0, 0, 0, 2}, c.data...)
+			writeChunk(&buf, "fdAT", fdAT)
+		}
+	}
+	writeChunk(&buf, "IEND", nil)
+	return buf.Bytes()
+}
+
+func TestIsAPNG(t *testing.T) {
+	if !isAPNG(buildAPNG(t, apngDisposeNone)) {
+		t.Error("isAPNG = false for an animated PNG")
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, solidNRGBA(4, 4, color.NRGBA{A: 255})); err != nil {
+		t.Fatal(err)
+	}
+	if isAPNG(buf.Bytes()) {
+		t.Error("isAPNG = true for a still PNG")
+	}
+}
+
+func TestDecodeAPNG(t *testing.T) {
+	frames, format, err := DecodeFrames(buildAPNG(t, apngDisposeNone))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "apng" || len(frames) != 2 {
+		t.Fatalf("DecodeFrames = %d %s frames, want 2 apng", len(frames), format)
+	}
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	if got := frames[0].At(2, 2); got != red {
+		t.Errorf("frame 0 at (2,2) = %v, want %v", got, red)
+	}
+	// Frame 1 composites the patch over the base frame.
+	if got := frames[1].At(2, 2); got != blue {
+		t.Errorf("frame 1 at (2,2) = %v, want %v", got, blue)
+	}
+	if got := frames[1].At(0, 0); got != red {
+		t.Errorf("frame 1 at (0,0) = %v, want %v", got, red)
+	}
+}
+
+// TestDecodeAPNGDisposeBackground checks a frame disposed to background
+// leaves transparency behind for the next composite.
+func TestDecodeAPNGDisposeBackground(t *testing.T) {
+	frames, _, err := DecodeFrames(buildAPNG(t, apngDisposeBackground))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The base frame was cleared before the patch drew, so only the
+	// patch region is opaque in frame 1.
+	if got := frames[1].At(0, 0); got != (color.NRGBA{}) {
+		t.Errorf("frame 1 at (0,0) = %v, want transparent after dispose", got)
+	}
+	if got := frames[1].At(1, 1); got != (color.NRGBA{B: 255, A: 255}) {
+		t.Errorf("frame 1 at (1,1) = %v, want blue", got)
+	}
+}
@@ -0,0 +1,183 @@
+// Package imageproc decodes images and turns them into model input tensors.
+package imageproc
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+
+	// Registered decoders for the formats the API accepts.
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/model"
+)
+
+// Decode parses image bytes into an image.Image, returning the detected
+// format name alongside. The result is normalized to 8-bit sRGB.
+func Decode(data []byte) (image.Image, string, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("imageproc: decoding image: %w", err)
+	}
+	return normalize(img), format, nil
+}
+
+// normalize converts color spaces the sampling paths would mishandle —
+// CMYK JPEG, grayscale, and 16-bit PNG — into 8-bit NRGBA upfront.
+// 8-bit RGB-family images (NRGBA, RGBA, YCbCr, paletted) pass through
+// untouched to keep the hot path allocation-free. Inputs are assumed
+// sRGB; the JPEG decoder already applies the Adobe CMYK inversion, and
+// embedded ICC profiles beyond that are not interpreted.
+func normalize(img image.Image) image.Image {
+	switch img.(type) {
+	case *image.CMYK, *image.Gray, *image.Gray16, *image.NRGBA64, *image.RGBA64:
+		return toNRGBA(img)
+	}
+	return img
+}
+
+// DecodeFrames parses image bytes into one image per frame. Animated
+// GIFs yield every frame composited onto the full canvas (frames are
+// often deltas against the previous one); static formats yield a single
+// frame.
+func DecodeFrames(data []byte) ([]image.Image, string, error) {
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("imageproc: decoding image: %w", err)
+	}
+	if format == "png" && isAPNG(data) {
+		frames, err := decodeAPNG(data)
+		if err != nil {
+			return nil, "", err
+		}
+		return frames, "apng", nil
+	}
+	if format == "webp" && isAnimatedWebP(data) {
+		frames, err := decodeAnimatedWebP(data)
+		if err != nil {
+			return nil, "", err
+		}
+		return frames, "webp", nil
+	}
+	if format != "gif" {
+		img, format, err := Decode(data)
+		if err != nil {
+			return nil, "", err
+		}
+		return []image.Image{img}, format, nil
+	}
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("imageproc: decoding gif: %w", err)
+	}
+	if len(g.Image) == 0 {
+		return nil, "", fmt.Errorf("imageproc: gif has no frames")
+	}
+	canvas := image.NewNRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	frames := make([]image.Image, 0, len(g.Image))
+	for _, frame := range g.Image {
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		snapshot := image.NewNRGBA(canvas.Bounds())
+		copy(snapshot.Pix, canvas.Pix)
+		frames = append(frames, snapshot)
+	}
+	return frames, "gif", nil
+}
+
+// Crop returns the part of img inside rect, sharing pixels with the
+// original when the decoder's type supports it.
+func Crop(img image.Image, rect image.Rectangle) image.Image {
+	type subImager interface {
+		SubImage(image.Rectangle) image.Image
+	}
+	if si, ok := img.(subImager); ok {
+		return si.SubImage(rect)
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	for y := 0; y < rect.Dy(); y++ {
+		for x := 0; x < rect.Dx(); x++ {
+			dst.Set(x, y, img.At(rect.Min.X+x, rect.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// AverageHash computes an 8x8 average perceptual hash: the image is
+// downscaled to 8x8 grayscale and each bit records whether that pixel is
+// brighter than the mean. Near-identical frames produce identical or
+// near-identical (low Hamming distance) hashes.
+func AverageHash(img image.Image) uint64 {
+	small := resize(img, 8, 8)
+	var lum [64]float32
+	var sum float32
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			r, g, b := rgbAt(small, x, y)
+			l := 0.299*r + 0.587*g + 0.114*b
+			lum[y*8+x] = l
+			sum += l
+		}
+	}
+	mean := sum / 64
+	var hash uint64
+	for i, l := range lum {
+		if l > mean {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// Tensorize resizes img to the spec's input size and produces an NCHW
+// float32 tensor with the spec's normalization applied.
+func Tensorize(img image.Image, spec model.Spec) ([]float32, []int64) {
+	size := spec.InputSize
+	resized := resize(img, size, size)
+	tensor := make([]float32, 3*size*size)
+	plane := size * size
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			r, g, b := rgbAt(resized, x, y)
+			ch := [3]float32{r, g, b}
+			if spec.BGR {
+				ch[0], ch[2] = ch[2], ch[0]
+			}
+			idx := y*size + x
+			for c := 0; c < 3; c++ {
+				tensor[c*plane+idx] = (ch[c] - spec.Mean[c]) * spec.Scale[c]
+			}
+		}
+	}
+	return tensor, []int64{1, 3, int64(size), int64(size)}
+}
+
+func rgbAt(img image.Image, x, y int) (float32, float32, float32) {
+	c := color.NRGBAModel.Convert(img.At(img.Bounds().Min.X+x, img.Bounds().Min.Y+y)).(color.NRGBA)
+	return float32(c.R), float32(c.G), float32(c.B)
+}
+
+// resize scales img to w x h using nearest-neighbour sampling. Model
+// inputs are small enough that the quality difference from bilinear is
+// negligible for score stability, and this keeps the hot path
+// allocation-light.
+func resize(img image.Image, w, h int) image.Image {
+	b := img.Bounds()
+	if b.Dx() == w && b.Dy() == h {
+		return img
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		sy := b.Min.Y + y*b.Dy()/h
+		for x := 0; x < w; x++ {
+			sx := b.Min.X + x*b.Dx()/w
+			dst.Set(x, y, img.At(sx, sy))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,103 @@
+package imageproc
+
+import (
+	"encoding/hex"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/hashlist"
+)
+
+// testPattern builds a synthetic image with a natural-looking 1/f
+// spectrum: energy spread over many frequencies, like a photograph,
+// so the median split over the DCT coefficients is stable. A single
+// sinusoid would leave most coefficients at numerical noise and the
+// hash degenerate — the kind of input PDQ's quality metric exists to
+// flag.
+func testPattern(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			v := 128.0
+			for k := 1.0; k <= 8; k++ {
+				v += 50 / k * math.Sin(2.3*k*math.Pi*fx+k) * math.Cos(1.7*k*math.Pi*fy+2*k)
+			}
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(v), G: uint8(v / 2), B: uint8(255 - v), A: 255})
+		}
+	}
+	return img
+}
+
+// TestQuantizePDQLayout pins the bit packing to the reference layout:
+// sixteen 16-bit words, highest word first, LSB-first bits within each
+// word — partner-produced hex strings only match byte-for-byte if this
+// holds.
+func TestQuantizePDQLayout(t *testing.T) {
+	var features [pdqDCT * pdqDCT]float64
+	for i := range features {
+		features[i] = float64(i)
+	}
+	// Features 128..255 exceed the median: words 8..15 all-ones, words
+	// 0..7 all-zero, and the high words lead the encoding.
+	hash := quantizePDQ(features)
+	want := "ffffffffffffffffffffffffffffffff00000000000000000000000000000000"
+	if got := hex.EncodeToString(hash[:]); got != want {
+		t.Errorf("hash = %s, want %s", got, want)
+	}
+
+	// A single above-median outlier at feature 0 lands in word 0, bit 0:
+	// the least significant bit of the final byte.
+	features = [pdqDCT * pdqDCT]float64{0: 1}
+	hash = quantizePDQ(features)
+	if hash[31] != 0x01 {
+		t.Errorf("feature 0 set byte %x, want bit 0 of byte 31", hash)
+	}
+}
+
+// TestQuantizePDQBalance checks the median split: distinct coefficients
+// must yield exactly 128 set bits.
+func TestQuantizePDQBalance(t *testing.T) {
+	var features [pdqDCT * pdqDCT]float64
+	for i := range features {
+		features[i] = math.Sin(float64(i))
+	}
+	hash := quantizePDQ(features)
+	if d := hashlist.Hamming(hash[:], make([]byte, 32)); d != 128 {
+		t.Errorf("popcount = %d, want 128", d)
+	}
+}
+
+// TestPDQResizeStability checks the property hash-sharing relies on: a
+// rescaled copy of an image hashes within matching distance of the
+// original.
+func TestPDQResizeStability(t *testing.T) {
+	img := testPattern(512, 384)
+	small := resize(img, 256, 192)
+	a, b := PDQ(img), PDQ(small)
+	if d := hashlist.Hamming(a[:], b[:]); d > 31 {
+		t.Errorf("Hamming(original, half-size) = %d, want <= 31", d)
+	}
+	// An unrelated image must not land nearby.
+	other := image.NewNRGBA(image.Rect(0, 0, 512, 384))
+	for i := range other.Pix {
+		other.Pix[i] = uint8(i * 7)
+	}
+	c := PDQ(other)
+	if d := hashlist.Hamming(a[:], c[:]); d < 64 {
+		t.Errorf("Hamming(original, unrelated) = %d, want >= 64", d)
+	}
+}
+
+// TestTMKMatchesStillHash checks the zero-period TMK core: a video whose
+// frames are identical hashes exactly like the still image.
+func TestTMKMatchesStillHash(t *testing.T) {
+	img := testPattern(128, 96)
+	still := PDQ(img)
+	video := TMK([]image.Image{img, img, img})
+	if still != video {
+		t.Errorf("TMK(3 identical frames) = %x, want still hash %x", video, still)
+	}
+}
@@ -0,0 +1,112 @@
+package imageproc
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+)
+
+// Thumbnail produces a JPEG preview of img with its longest side scaled
+// to size and a heavy box blur of the given radius applied, so review
+// tooling can show flagged content without handling the original asset.
+func Thumbnail(img image.Image, size, blur int) ([]byte, error) {
+	b := img.Bounds()
+	w, h := size, size
+	if b.Dx() > b.Dy() {
+		h = size * b.Dy() / b.Dx()
+	} else {
+		w = size * b.Dx() / b.Dy()
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	small := toNRGBA(resize(img, w, h))
+	if blur > 0 {
+		// Three box-blur passes approximate a Gaussian closely enough
+		// for an obscuring preview.
+		for i := 0; i < 3; i++ {
+			small = boxBlur(small, blur)
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, small, &jpeg.Options{Quality: 70}); err != nil {
+		return nil, fmt.Errorf("imageproc: encoding thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Grayscale returns a desaturated copy of img, using the same luma
+// weights as AverageHash. Review tooling layers it under the blur so
+// moderators see flagged content with as little of its impact as the
+// decision allows.
+func Grayscale(img image.Image) *image.NRGBA {
+	src := toNRGBA(img)
+	b := src.Bounds()
+	dst := image.NewNRGBA(b)
+	for i := 0; i < len(src.Pix); i += 4 {
+		l := uint8(0.299*float32(src.Pix[i]) + 0.587*float32(src.Pix[i+1]) + 0.114*float32(src.Pix[i+2]))
+		dst.Pix[i] = l
+		dst.Pix[i+1] = l
+		dst.Pix[i+2] = l
+		dst.Pix[i+3] = src.Pix[i+3]
+	}
+	return dst
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok {
+		return n
+	}
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			dst.Set(x, y, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// boxBlur applies one separable box-blur pass of the given radius.
+func boxBlur(src *image.NRGBA, radius int) *image.NRGBA {
+	horizontal := blurPass(src, radius, true)
+	return blurPass(horizontal, radius, false)
+}
+
+func blurPass(src *image.NRGBA, radius int, horizontal bool) *image.NRGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(b)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sumR, sumG, sumB, sumA, n int
+			for d := -radius; d <= radius; d++ {
+				sx, sy := x, y
+				if horizontal {
+					sx += d
+				} else {
+					sy += d
+				}
+				if sx < 0 || sx >= w || sy < 0 || sy >= h {
+					continue
+				}
+				i := src.PixOffset(sx, sy)
+				sumR += int(src.Pix[i])
+				sumG += int(src.Pix[i+1])
+				sumB += int(src.Pix[i+2])
+				sumA += int(src.Pix[i+3])
+				n++
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(sumR / n)
+			dst.Pix[i+1] = uint8(sumG / n)
+			dst.Pix[i+2] = uint8(sumB / n)
+			dst.Pix[i+3] = uint8(sumA / n)
+		}
+	}
+	return dst
+}
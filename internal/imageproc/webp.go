@@ -0,0 +1,175 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+
+	"golang.org/x/image/webp"
+)
+
+// Animated WebP support: the x/image decoder handles only still images,
+// so animated stickers would fail outright. Mirroring the APNG decoder,
+// this walks the RIFF chunk stream, reassembles each ANMF frame as a
+// standalone WebP, and composites frames onto the canvas according to
+// their blend and dispose flags.
+
+var webpFourCC = []byte("WEBP")
+
+// isAnimatedWebP reports whether WebP data carries animation frames.
+func isAnimatedWebP(data []byte) bool {
+	return len(data) >= 12 && bytes.HasPrefix(data, []byte("RIFF")) &&
+		bytes.Equal(data[8:12], webpFourCC) && bytes.Contains(data, []byte("ANMF"))
+}
+
+type webpChunk struct {
+	typ  string
+	data []byte
+}
+
+// decodeAnimatedWebP decodes every animation frame of an animated WebP,
+// composited onto the full canvas.
+func decodeAnimatedWebP(data []byte) ([]image.Image, error) {
+	chunks, err := webpChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	var canvasW, canvasH int
+	var out []image.Image
+	var canvas *image.NRGBA
+	for _, c := range chunks {
+		switch c.typ {
+		case "VP8X":
+			if len(c.data) < 10 {
+				return nil, fmt.Errorf("imageproc: webp: short VP8X chunk")
+			}
+			canvasW = int(le24(c.data[4:7])) + 1
+			canvasH = int(le24(c.data[7:10])) + 1
+			canvas = image.NewNRGBA(image.Rect(0, 0, canvasW, canvasH))
+		case "ANMF":
+			if len(c.data) < 16 {
+				return nil, fmt.Errorf("imageproc: webp: short ANMF chunk")
+			}
+			if canvas == nil {
+				return nil, fmt.Errorf("imageproc: webp: ANMF before VP8X")
+			}
+			x := int(le24(c.data[0:3])) * 2
+			y := int(le24(c.data[3:6])) * 2
+			w := int(le24(c.data[6:9])) + 1
+			h := int(le24(c.data[9:12])) + 1
+			flags := c.data[15]
+			img, err := decodeWebPFrame(c.data[16:], w, h)
+			if err != nil {
+				return nil, err
+			}
+			region := image.Rect(x, y, x+w, y+h)
+			op := draw.Over
+			if flags&0x02 != 0 { // blending method 1: do not blend
+				op = draw.Src
+			}
+			draw.Draw(canvas, region, img, img.Bounds().Min, op)
+
+			snapshot := image.NewNRGBA(canvas.Bounds())
+			copy(snapshot.Pix, canvas.Pix)
+			out = append(out, snapshot)
+
+			if flags&0x01 != 0 { // dispose to background
+				draw.Draw(canvas, region, image.Transparent, image.Point{}, draw.Src)
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("imageproc: webp: no animation frames")
+	}
+	return out, nil
+}
+
+// decodeWebPFrame rebuilds one ANMF payload — its ALPH and VP8/VP8L
+// sub-chunks — as a standalone WebP and decodes it. Frames carrying a
+// separate alpha plane get a synthesized VP8X header, which the still
+// decoder requires before it accepts an ALPH chunk.
+func decodeWebPFrame(payload []byte, w, h int) (image.Image, error) {
+	chunks, err := webpBodyChunks(payload)
+	if err != nil {
+		return nil, err
+	}
+	hasAlpha := false
+	for _, c := range chunks {
+		if c.typ == "ALPH" {
+			hasAlpha = true
+		}
+	}
+	var body bytes.Buffer
+	if hasAlpha {
+		vp8x := make([]byte, 10)
+		vp8x[0] = 0x10 // alpha flag
+		putLE24(vp8x[4:7], uint32(w-1))
+		putLE24(vp8x[7:10], uint32(h-1))
+		writeWebPChunk(&body, "VP8X", vp8x)
+	}
+	for _, c := range chunks {
+		writeWebPChunk(&body, c.typ, c.data)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(4+body.Len()))
+	buf.Write(webpFourCC)
+	buf.Write(body.Bytes())
+
+	img, err := webp.Decode(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("imageproc: webp: decoding frame: %w", err)
+	}
+	return img, nil
+}
+
+// webpChunks parses the top-level RIFF chunk stream of a WebP file.
+func webpChunks(data []byte) ([]webpChunk, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("imageproc: webp: truncated header")
+	}
+	return webpBodyChunks(data[12:])
+}
+
+// webpBodyChunks parses a sequence of RIFF chunks: fourcc, 32-bit
+// little-endian length, payload, and a pad byte after odd lengths.
+func webpBodyChunks(rest []byte) ([]webpChunk, error) {
+	var chunks []webpChunk
+	for len(rest) >= 8 {
+		length := int(binary.LittleEndian.Uint32(rest[4:8]))
+		if len(rest) < 8+length {
+			return nil, fmt.Errorf("imageproc: webp: truncated chunk")
+		}
+		chunks = append(chunks, webpChunk{
+			typ:  string(rest[0:4]),
+			data: rest[8 : 8+length],
+		})
+		rest = rest[8+length:]
+		if length%2 == 1 && len(rest) > 0 {
+			rest = rest[1:] // pad byte
+		}
+	}
+	return chunks, nil
+}
+
+func writeWebPChunk(buf *bytes.Buffer, typ string, data []byte) {
+	buf.WriteString(typ)
+	binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	if len(data)%2 == 1 {
+		buf.WriteByte(0)
+	}
+}
+
+func le24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+func putLE24(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
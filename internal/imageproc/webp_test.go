@@ -0,0 +1,177 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+)
+
+// bitWriter packs bits LSB-first, the VP8L bitstream order.
+type bitWriter struct {
+	buf  []byte
+	nbit uint
+}
+
+func (w *bitWriter) write(v uint32, n uint) {
+	for i := uint(0); i < n; i++ {
+		if w.nbit%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		if v&(1<<i) != 0 {
+			w.buf[len(w.buf)-1] |= 1 << (w.nbit % 8)
+		}
+		w.nbit++
+	}
+}
+
+// vp8lSolid encodes a w x h solid-color lossless VP8L chunk payload.
+// Every prefix code is a simple one-symbol code, so the per-pixel
+// literals cost zero bits — the smallest legal VP8L stream.
+func vp8lSolid(w, h int, c color.NRGBA) []byte {
+	bw := &bitWriter{}
+	bw.write(uint32(w-1), 14)
+	bw.write(uint32(h-1), 14)
+	bw.write(0, 1) // alpha hint
+	bw.write(0, 3) // version
+	bw.write(0, 1) // no transforms
+	bw.write(0, 1) // no color cache
+	bw.write(0, 1) // no meta prefix codes
+	// One-symbol simple codes for green, red, blue, alpha, distance.
+	for _, sym := range []uint32{uint32(c.G), uint32(c.R), uint32(c.B), uint32(c.A), 0} {
+		bw.write(1, 1) // simple code
+		bw.write(0, 1) // one symbol
+		if sym < 2 {
+			bw.write(0, 1) // symbol fits one bit
+			bw.write(sym, 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(sym, 8)
+		}
+	}
+	return append([]byte{0x2f}, bw.buf...)
+}
+
+// anmf wraps a frame payload in an ANMF chunk at (x, y); flags is the
+// blend/dispose byte.
+func anmf(x, y, w, h int, flags byte, payload []byte) []byte {
+	data := make([]byte, 16)
+	putLE24(data[0:3], uint32(x/2))
+	putLE24(data[3:6], uint32(y/2))
+	putLE24(data[6:9], uint32(w-1))
+	putLE24(data[9:12], uint32(h-1))
+	data[15] = flags
+	return append(data, payload...)
+}
+
+// buildAnimatedWebP assembles a 4x4 canvas: a full-canvas red frame,
+// then a 2x2 blue frame at (2,2) with the given flags.
+func buildAnimatedWebP(t *testing.T, frame2Flags byte) []byte {
+	t.Helper()
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+
+	var body bytes.Buffer
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x02 // animation flag
+	putLE24(vp8x[4:7], 3)
+	putLE24(vp8x[7:10], 3)
+	writeWebPChunk(&body, "VP8X", vp8x)
+
+	var f1 bytes.Buffer
+	writeWebPChunk(&f1, "VP8L", vp8lSolid(4, 4, red))
+	writeWebPChunk(&body, "ANMF", anmf(0, 0, 4, 4, 0x02, f1.Bytes()))
+
+	var f2 bytes.Buffer
+	writeWebPChunk(&f2, "VP8L", vp8lSolid(2, 2, blue))
+	writeWebPChunk(&body, "ANMF", anmf(2, 2, 2, 2, frame2Flags, f2.Bytes()))
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(4+body.Len()))
+	buf.Write(webpFourCC)
+	buf.Write(body.Bytes())
+	return buf.Bytes()
+}
+
+func TestIsAnimatedWebP(t *testing.T) {
+	if !isAnimatedWebP(buildAnimatedWebP(t, 0)) {
+		t.Error("isAnimatedWebP = false for an animated file")
+	}
+	var still bytes.Buffer
+	still.WriteString("RIFF\x00\x00\x00\x00WEBP")
+	if isAnimatedWebP(still.Bytes()) {
+		t.Error("isAnimatedWebP = true for a still file")
+	}
+}
+
+func TestDecodeAnimatedWebP(t *testing.T) {
+	frames, format, err := DecodeFrames(buildAnimatedWebP(t, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "webp" || len(frames) != 2 {
+		t.Fatalf("DecodeFrames = %d %s frames, want 2 webp", len(frames), format)
+	}
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	if got := frames[0].At(3, 3); got != red {
+		t.Errorf("frame 0 at (3,3) = %v, want %v", got, red)
+	}
+	// Frame 1 composites the 2x2 blue patch over the red canvas.
+	if got := frames[1].At(3, 3); got != blue {
+		t.Errorf("frame 1 at (3,3) = %v, want %v", got, blue)
+	}
+	if got := frames[1].At(0, 0); got != red {
+		t.Errorf("frame 1 at (0,0) = %v, want %v", got, red)
+	}
+}
+
+// TestDecodeAnimatedWebPDispose checks the dispose-to-background flag
+// clears the frame's region from the canvas after it shows.
+func TestDecodeAnimatedWebPDispose(t *testing.T) {
+	// Frame 2 disposes to background; a third frame would composite
+	// against transparency there, which the demuxer models by clearing
+	// the region. Rebuild with a trailing full-canvas over-blend frame.
+	data := buildAnimatedWebP(t, 0x03) // no blend + dispose
+	chunks, err := webpChunks(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body bytes.Buffer
+	for _, c := range chunks {
+		writeWebPChunk(&body, c.typ, c.data)
+	}
+	var f3 bytes.Buffer
+	writeWebPChunk(&f3, "VP8L", vp8lSolid(1, 1, color.NRGBA{G: 255, A: 255}))
+	writeWebPChunk(&body, "ANMF", anmf(0, 0, 1, 1, 0x00, f3.Bytes()))
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(4+body.Len()))
+	buf.Write(webpFourCC)
+	buf.Write(body.Bytes())
+
+	frames, err := decodeAnimatedWebP(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 3 {
+		t.Fatalf("got %d frames, want 3", len(frames))
+	}
+	if got := frames[2].At(3, 3); got != (color.NRGBA{}) {
+		t.Errorf("frame 2 at (3,3) = %v, want transparent after dispose", got)
+	}
+	if got := frames[2].At(0, 0); got != (color.NRGBA{G: 255, A: 255}) {
+		t.Errorf("frame 2 at (0,0) = %v, want green", got)
+	}
+}
+
+func TestWebPChunksRejectTruncated(t *testing.T) {
+	data := buildAnimatedWebP(t, 0)
+	if _, err := webpChunks(data[:len(data)-3]); err == nil {
+		t.Error("webpChunks accepted a truncated chunk stream")
+	}
+	if _, err := webpChunks(data[:8]); err == nil {
+		t.Error("webpChunks accepted a truncated header")
+	}
+}
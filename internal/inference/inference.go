@@ -0,0 +1,55 @@
+// Package inference abstracts the ONNX runtime behind a small interface so
+// the rest of the service never touches runtime-specific types.
+package inference
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrRuntimeUnavailable is returned by Open when the binary was built
+// without an inference backend (see the ort build tag).
+var ErrRuntimeUnavailable = errors.New("inference: no runtime backend compiled in (build with -tags ort)")
+
+// Session is a loaded model ready to run.
+type Session interface {
+	// Run executes the model on a single NCHW float32 input tensor and
+	// returns the raw output tensors, flattened.
+	Run(ctx context.Context, input []float32, shape []int64) ([][]float32, error)
+	// Close releases runtime resources held by the session.
+	Close() error
+}
+
+// Engine creates sessions from model files on disk.
+type Engine interface {
+	// Name identifies the backend, e.g. "onnxruntime".
+	Name() string
+	// Open loads the model at path.
+	Open(path string) (Session, error)
+	// OpenBytes loads a model from an in-memory ONNX blob that never
+	// touches disk; name only labels errors.
+	OpenBytes(name string, data []byte) (Session, error)
+	// Provider reports the execution provider that runs the named
+	// model, e.g. "cpu" or "cuda".
+	Provider(model string) string
+	// Version reports the backend library version, for provenance
+	// records that must pin the exact runtime a verdict came from.
+	Version() string
+}
+
+// GPUConfig places inference on the CUDA execution provider.
+type GPUConfig struct {
+	// Enabled turns on CUDA placement. A failed CUDA initialization
+	// falls back to the CPU provider rather than failing scans.
+	Enabled bool
+	// Models limits placement to the named models; empty places every
+	// model on the GPU.
+	Models map[string]bool
+	// Device is the CUDA device ordinal.
+	Device int
+}
+
+// placed reports whether model belongs on the GPU under this config.
+func (g GPUConfig) placed(model string) bool {
+	return g.Enabled && (len(g.Models) == 0 || g.Models[model])
+}
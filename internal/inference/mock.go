@@ -0,0 +1,72 @@
+package inference
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+// mockOutputLen is how many pseudo-scores a mock run produces. It is
+// larger than any model's class count so callers can slice what they
+// need; detector decoding sees a single low-confidence box.
+const mockOutputLen = 32
+
+// NewMockEngine returns a deterministic engine that never touches ONNX.
+// Scores are derived purely from a hash of the input tensor, so the same
+// image always produces the same result — integration tests can assert
+// on exact values without model files or native runtimes.
+func NewMockEngine() Engine {
+	return mockEngine{}
+}
+
+type mockEngine struct{}
+
+func (mockEngine) Name() string { return "mock" }
+
+func (mockEngine) Provider(model string) string { return "mock" }
+
+func (mockEngine) Version() string { return "mock" }
+
+func (mockEngine) Open(path string) (Session, error) {
+	return mockSession{}, nil
+}
+
+func (mockEngine) OpenBytes(name string, data []byte) (Session, error) {
+	return mockSession{}, nil
+}
+
+type mockSession struct{}
+
+func (mockSession) Run(ctx context.Context, input []float32, shape []int64) ([][]float32, error) {
+	h := fnv.New64a()
+	var buf [4]byte
+	for _, v := range input {
+		binary.LittleEndian.PutUint32(buf[:], math.Float32bits(v))
+		h.Write(buf[:])
+	}
+	seed := h.Sum64()
+
+	// Simulate a small, hash-dependent inference latency (2-12ms) so
+	// client timeout and latency-percentile logic sees realistic shape.
+	delay := time.Duration(2+seed%11) * time.Millisecond
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	out := make([]float32, mockOutputLen)
+	state := seed
+	for i := range out {
+		// xorshift64 keeps the sequence deterministic per seed.
+		state ^= state << 13
+		state ^= state >> 7
+		state ^= state << 17
+		out[i] = float32(state%1000) / 1000
+	}
+	return [][]float32{out}, nil
+}
+
+func (mockSession) Close() error { return nil }
@@ -0,0 +1,36 @@
+//go:build !ort
+
+package inference
+
+import "context"
+
+// NewEngine returns the engine for builds without an ONNX runtime. Models
+// still register so the service starts and non-inference endpoints stay
+// usable, but every Run fails with ErrRuntimeUnavailable.
+func NewEngine(gpu GPUConfig) (Engine, error) {
+	return stubEngine{}, nil
+}
+
+type stubEngine struct{}
+
+func (stubEngine) Name() string { return "none" }
+
+func (stubEngine) Provider(model string) string { return "none" }
+
+func (stubEngine) Version() string { return "" }
+
+func (stubEngine) Open(path string) (Session, error) {
+	return stubSession{}, nil
+}
+
+func (stubEngine) OpenBytes(name string, data []byte) (Session, error) {
+	return stubSession{}, nil
+}
+
+type stubSession struct{}
+
+func (stubSession) Run(ctx context.Context, input []float32, shape []int64) ([][]float32, error) {
+	return nil, ErrRuntimeUnavailable
+}
+
+func (stubSession) Close() error { return nil }
@@ -0,0 +1,232 @@
+//go:build ort
+
+package inference
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+var ortInit sync.Once
+
+// NewEngine returns the onnxruntime-backed engine. The shared library
+// location can be overridden with ORT_SHARED_LIB before the first Open.
+func NewEngine(gpu GPUConfig) (Engine, error) {
+	var initErr error
+	ortInit.Do(func() {
+		initErr = ort.InitializeEnvironment()
+	})
+	if initErr != nil {
+		return nil, fmt.Errorf("inference: initializing onnxruntime: %w", initErr)
+	}
+	return &ortEngine{gpu: gpu}, nil
+}
+
+type ortEngine struct {
+	gpu GPUConfig
+
+	// cudaOpts is created lazily on the first GPU-placed session; a
+	// failed initialization is remembered so every later session falls
+	// back to CPU instead of retrying a broken driver.
+	cudaMu    sync.Mutex
+	cudaTried bool
+	cudaOpts  *ort.CUDAProviderOptions
+}
+
+func (e *ortEngine) Name() string { return "onnxruntime" }
+
+// Version reports the onnxruntime_go binding version from build info;
+// the binding pins the runtime ABI it was built against.
+func (e *ortEngine) Version() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, d := range info.Deps {
+			if d.Path == "github.com/yalue/onnxruntime_go" {
+				return d.Version
+			}
+		}
+	}
+	return ""
+}
+
+// cuda returns the shared CUDA provider options, or nil when CUDA is
+// unavailable.
+func (e *ortEngine) cuda() *ort.CUDAProviderOptions {
+	e.cudaMu.Lock()
+	defer e.cudaMu.Unlock()
+	if e.cudaTried {
+		return e.cudaOpts
+	}
+	e.cudaTried = true
+	opts, err := ort.NewCUDAProviderOptions()
+	if err != nil {
+		return nil
+	}
+	if err := opts.Update(map[string]string{"device_id": strconv.Itoa(e.gpu.Device)}); err != nil {
+		opts.Destroy()
+		return nil
+	}
+	e.cudaOpts = opts
+	return opts
+}
+
+func (e *ortEngine) Provider(model string) string {
+	if e.gpu.placed(model) && e.cuda() != nil {
+		return "cuda"
+	}
+	return "cpu"
+}
+
+// modelName maps a session path like dir/nudenet@v2.onnx back to the
+// model name used for GPU placement.
+func modelName(path string) string {
+	base := filepath.Base(path)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	if i := strings.IndexByte(base, '@'); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+func (e *ortEngine) Open(path string) (Session, error) {
+	inputs, outputs, err := ort.GetInputOutputInfo(path)
+	if err != nil {
+		return nil, fmt.Errorf("inference: reading model info for %s: %w", path, err)
+	}
+	return newOrtSession(e, path, nil, inputs, outputs)
+}
+
+func (e *ortEngine) OpenBytes(name string, data []byte) (Session, error) {
+	inputs, outputs, err := ort.GetInputOutputInfoWithONNXData(data)
+	if err != nil {
+		return nil, fmt.Errorf("inference: reading model info for %s: %w", name, err)
+	}
+	return newOrtSession(e, name, data, inputs, outputs)
+}
+
+func newOrtSession(e *ortEngine, name string, data []byte, inputs, outputs []ort.InputOutputInfo) (Session, error) {
+	if len(inputs) != 1 {
+		return nil, fmt.Errorf("inference: %s: expected exactly one input, got %d", name, len(inputs))
+	}
+	outNames := make([]string, len(outputs))
+	for i, o := range outputs {
+		outNames[i] = o.Name
+	}
+	return &ortSession{engine: e, path: name, model: modelName(name), data: data, inputName: inputs[0].Name, outputNames: outNames}, nil
+}
+
+type ortSession struct {
+	engine    *ortEngine
+	path      string
+	model     string
+	inputName string
+	// data holds the in-memory ONNX blob for models opened with
+	// OpenBytes; nil sessions load from path instead.
+	data        []byte
+	outputNames []string
+
+	// mu guards lazy construction of sess only; onnxruntime sessions
+	// support concurrent Run, so inference itself is never serialized.
+	mu   sync.Mutex
+	sess *ort.DynamicAdvancedSession
+}
+
+// sessionOptions builds the one-time construction options with CUDA
+// appended for GPU-placed models, so the model is uploaded to the
+// device once and stays resident. nil means runtime defaults (CPU);
+// any CUDA failure degrades to that rather than erroring the scan.
+func (s *ortSession) sessionOptions() *ort.SessionOptions {
+	if !s.engine.gpu.placed(s.model) {
+		return nil
+	}
+	cuda := s.engine.cuda()
+	if cuda == nil {
+		return nil
+	}
+	opts, err := ort.NewSessionOptions()
+	if err != nil {
+		return nil
+	}
+	if err := opts.AppendExecutionProviderCUDA(cuda); err != nil {
+		opts.Destroy()
+		return nil
+	}
+	return opts
+}
+
+// session returns the shared onnxruntime session, building it on first
+// use so Open stays cheap. The graph is parsed and placed once; every
+// later Run reuses it. A failed build is retried on the next Run.
+func (s *ortSession) session() (*ort.DynamicAdvancedSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sess != nil {
+		return s.sess, nil
+	}
+	opts := s.sessionOptions()
+	if opts != nil {
+		// The session keeps its own copy of the placement; the options
+		// object is only needed for construction.
+		defer opts.Destroy()
+	}
+	var sess *ort.DynamicAdvancedSession
+	var err error
+	if s.data != nil {
+		sess, err = ort.NewDynamicAdvancedSessionWithONNXData(s.data, []string{s.inputName}, s.outputNames, opts)
+	} else {
+		sess, err = ort.NewDynamicAdvancedSession(s.path, []string{s.inputName}, s.outputNames, opts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("inference: opening session for %s: %w", s.path, err)
+	}
+	s.sess = sess
+	return sess, nil
+}
+
+func (s *ortSession) Run(ctx context.Context, input []float32, shape []int64) ([][]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sess, err := s.session()
+	if err != nil {
+		return nil, err
+	}
+	in, err := ort.NewTensor(ort.NewShape(shape...), input)
+	if err != nil {
+		return nil, fmt.Errorf("inference: creating input tensor: %w", err)
+	}
+	defer in.Destroy()
+
+	outs := make([]ort.ArbitraryTensor, len(s.outputNames))
+	if err := sess.Run([]ort.ArbitraryTensor{in}, outs); err != nil {
+		return nil, fmt.Errorf("inference: running %s: %w", s.path, err)
+	}
+	results := make([][]float32, len(outs))
+	for i, o := range outs {
+		t, ok := o.(*ort.Tensor[float32])
+		if !ok {
+			o.Destroy()
+			return nil, fmt.Errorf("inference: %s: output %d is not float32", s.path, i)
+		}
+		data := t.GetData()
+		results[i] = append([]float32(nil), data...)
+		o.Destroy()
+	}
+	return results, nil
+}
+
+func (s *ortSession) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sess != nil {
+		s.sess.Destroy()
+		s.sess = nil
+	}
+	return nil
+}
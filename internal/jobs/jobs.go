@@ -0,0 +1,191 @@
+// Package jobs is the in-process queue behind asynchronous scans:
+// POST /scan/async enqueues work here and returns a job ID the caller
+// polls. A fixed worker pool drains the queue, finished jobs keep
+// their result for the configured TTL, and a full queue rejects new
+// work rather than buffering without bound — the same backpressure
+// stance as the scan concurrency ceiling.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Job statuses, in lifecycle order.
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// ErrFull is returned by Enqueue when the queue has no room; callers
+// translate it into a 503 so clients retry later.
+var ErrFull = errors.New("jobs: queue is full")
+
+// queueBuffer is how many jobs may wait beyond the ones the workers
+// hold.
+const queueBuffer = 256
+
+// Fn is the work one job performs. The context carries the queue's
+// lifetime, not any request's.
+type Fn func(ctx context.Context) (any, error)
+
+// Job is one unit of queued work and its outcome. Result and Error are
+// set only in the done and failed states respectively.
+type Job struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Result     any        `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+type item struct {
+	job *Job
+	fn  Fn
+}
+
+// Queue runs jobs on a fixed worker pool and retains finished jobs for
+// the TTL so pollers can fetch results.
+type Queue struct {
+	log *slog.Logger
+	ttl time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+
+	work   chan item
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+// NewQueue starts workers goroutines draining the queue. Finished jobs
+// expire ttl after completion.
+func NewQueue(workers int, ttl time.Duration, log *slog.Logger) *Queue {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue{
+		log:    log,
+		ttl:    ttl,
+		jobs:   make(map[string]*Job),
+		work:   make(chan item, queueBuffer),
+		cancel: cancel,
+	}
+	for i := 0; i < workers; i++ {
+		q.done.Add(1)
+		go q.worker(ctx)
+	}
+	q.done.Add(1)
+	go q.janitor(ctx)
+	return q
+}
+
+// Enqueue adds one job, or reports ErrFull when the buffer has no room.
+func (q *Queue) Enqueue(fn Fn) (Job, error) {
+	var id [8]byte
+	rand.Read(id[:])
+	job := &Job{
+		ID:        hex.EncodeToString(id[:]),
+		Status:    StatusQueued,
+		CreatedAt: time.Now().UTC(),
+	}
+	select {
+	case q.work <- item{job: job, fn: fn}:
+	default:
+		return Job{}, ErrFull
+	}
+	q.mu.Lock()
+	q.jobs[job.ID] = job
+	q.mu.Unlock()
+	return *job, nil
+}
+
+// Get returns a snapshot of one job, or false for IDs that are unknown
+// or whose retention expired.
+func (q *Queue) Get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Close stops the workers and the janitor. Queued jobs that have not
+// started are dropped.
+func (q *Queue) Close() {
+	q.cancel()
+	q.done.Wait()
+}
+
+func (q *Queue) worker(ctx context.Context) {
+	defer q.done.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case it := <-q.work:
+			q.run(ctx, it)
+		}
+	}
+}
+
+func (q *Queue) run(ctx context.Context, it item) {
+	now := time.Now().UTC()
+	q.mu.Lock()
+	it.job.Status = StatusRunning
+	it.job.StartedAt = &now
+	q.mu.Unlock()
+
+	result, err := it.fn(ctx)
+
+	finished := time.Now().UTC()
+	q.mu.Lock()
+	it.job.FinishedAt = &finished
+	if err != nil {
+		it.job.Status = StatusFailed
+		it.job.Error = err.Error()
+	} else {
+		it.job.Status = StatusDone
+		it.job.Result = result
+	}
+	q.mu.Unlock()
+	if err != nil {
+		q.log.Warn("async job failed", "job", it.job.ID, "err", err)
+	}
+}
+
+// janitor drops finished jobs once their retention expires, so a
+// poller that never collects its result doesn't pin it forever.
+func (q *Queue) janitor(ctx context.Context) {
+	defer q.done.Done()
+	interval := q.ttl / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			cutoff := time.Now().UTC().Add(-q.ttl)
+			q.mu.Lock()
+			for id, job := range q.jobs {
+				if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+					delete(q.jobs, id)
+				}
+			}
+			q.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Package labels rewrites raw model class names into the service's
+// policy labels and, for moderator UIs, localized display names. Raw
+// names like FEMALE_BREAST_EXPOSED are a NudeNet implementation detail;
+// the mapping table keeps them out of responses without touching the
+// model specs.
+package labels
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+)
+
+// Entry maps one raw class name.
+type Entry struct {
+	// Label is the policy label that replaces the raw class name in
+	// responses; empty keeps the raw name.
+	Label string `json:"label,omitempty"`
+	// Display holds localized display names keyed by language tag
+	// ("en", "de", ...).
+	Display map[string]string `json:"display,omitempty"`
+}
+
+// Table is the full mapping: model name -> raw class name -> Entry.
+// A nil Table applies no rewrites.
+type Table map[string]map[string]Entry
+
+// Load reads a mapping table from a JSON file.
+func Load(path string) (Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("labels: reading %s: %w", path, err)
+	}
+	var t Table
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("labels: parsing %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// entry looks up the mapping for a model's raw class name.
+func (t Table) entry(modelName, raw string) (Entry, bool) {
+	if t == nil {
+		return Entry{}, false
+	}
+	e, ok := t[modelName][raw]
+	return e, ok
+}
+
+// Rename returns the policy label for a raw class name, or the raw name
+// when unmapped.
+func (t Table) Rename(modelName, raw string) string {
+	if e, ok := t.entry(modelName, raw); ok && e.Label != "" {
+		return e.Label
+	}
+	return raw
+}
+
+// Display returns the localized display name for a raw class name, or
+// "" when no translation exists for lang.
+func (t Table) Display(modelName, raw, lang string) string {
+	if e, ok := t.entry(modelName, raw); ok {
+		return e.Display[lang]
+	}
+	return ""
+}
+
+// Apply rewrites one model's result in place: classifier score keys and
+// detection labels become policy labels, and detections gain a display
+// name when lang has a translation. Called after scoring and
+// categorization, so verdicts always work on raw class names.
+func (t Table) Apply(modelName string, result *api.ModelResult, lang string) {
+	if t == nil || t[modelName] == nil {
+		return
+	}
+	if len(result.Scores) > 0 {
+		renamed := make(map[string]float64, len(result.Scores))
+		for raw, score := range result.Scores {
+			renamed[t.Rename(modelName, raw)] = score
+		}
+		result.Scores = renamed
+	}
+	for i := range result.Detections {
+		raw := result.Detections[i].Label
+		result.Detections[i].Label = t.Rename(modelName, raw)
+		if lang != "" {
+			result.Detections[i].Display = t.Display(modelName, raw, lang)
+		}
+	}
+}
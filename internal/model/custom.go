@@ -0,0 +1,124 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sidecarSuffix marks the JSON spec written next to an uploaded model
+// file.
+const sidecarSuffix = ".json"
+
+// testInferenceTimeout bounds the smoke inference run on an uploaded
+// model before it is accepted.
+const testInferenceTimeout = 30 * time.Second
+
+// discoverSpecs reads every "<name>.json" sidecar in dir, so models
+// beyond the bundled set — uploads from a previous run, or files an
+// operator dropped in by hand — survive restarts. ONNX files without a
+// sidecar or a bundled spec are ignored: without classes and
+// preprocessing there is no way to interpret their output.
+func discoverSpecs(dir string) ([]Spec, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		// A missing directory surfaces as "no models loaded" later, with
+		// the more useful path error attached.
+		return nil, nil
+	}
+	var out []Spec
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), sidecarSuffix) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("model: reading sidecar %s: %w", e.Name(), err)
+		}
+		var spec Spec
+		if err := json.Unmarshal(data, &spec); err != nil {
+			return nil, fmt.Errorf("model: sidecar %s: %w", e.Name(), err)
+		}
+		if err := spec.Validate(); err != nil {
+			return nil, fmt.Errorf("model: sidecar %s: %w", e.Name(), err)
+		}
+		if _, ok := SpecByName(spec.Name); ok {
+			return nil, fmt.Errorf("model: sidecar %s redefines bundled model %q", e.Name(), spec.Name)
+		}
+		out = append(out, spec)
+	}
+	return out, nil
+}
+
+// AddModel registers a model uploaded at runtime. The ONNX bytes are
+// written into the models directory together with a JSON sidecar
+// holding the spec, the model is opened, and a zero tensor is run
+// through it so a broken or mislabeled file is rejected before it can
+// serve scans. On any failure the files are removed again.
+func (r *Registry) AddModel(spec Spec, data []byte) (*Loaded, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	if _, ok := r.specByName(spec.Name); ok {
+		return nil, fmt.Errorf("model %s: already exists", spec.Name)
+	}
+	path := filepath.Join(r.dir, spec.FileName)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return nil, fmt.Errorf("model %s: writing model file: %w", spec.Name, err)
+	}
+	sidecar, err := json.MarshalIndent(spec, "", "\t")
+	if err == nil {
+		err = os.WriteFile(strings.TrimSuffix(path, filepath.Ext(path))+sidecarSuffix, append(sidecar, '\n'), 0o644)
+	}
+	if err != nil {
+		r.removeCustomFiles(spec)
+		return nil, fmt.Errorf("model %s: writing spec sidecar: %w", spec.Name, err)
+	}
+	m, err := r.open(spec, BaseVersion)
+	if err != nil {
+		r.removeCustomFiles(spec)
+		return nil, fmt.Errorf("model %s: %w", spec.Name, err)
+	}
+	if err := r.testInference(m); err != nil {
+		m.Session.Close()
+		r.removeCustomFiles(spec)
+		return nil, fmt.Errorf("model %s: test inference failed: %w", spec.Name, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.custom[spec.Name] = spec
+	r.models[spec.Name] = map[string]*Loaded{BaseVersion: m}
+	r.active[spec.Name] = BaseVersion
+	return m, nil
+}
+
+// testInference runs a zero tensor through the model and checks that it
+// yields enough scores for the declared classes.
+func (r *Registry) testInference(m *Loaded) error {
+	ctx, cancel := context.WithTimeout(context.Background(), testInferenceTimeout)
+	defer cancel()
+	size := m.Spec.InputSize
+	input := make([]float32, 3*size*size)
+	outs, err := m.Session.Run(ctx, input, []int64{1, 3, int64(size), int64(size)})
+	if err != nil {
+		return err
+	}
+	if len(outs) == 0 || len(outs[0]) == 0 {
+		return fmt.Errorf("model produced no output")
+	}
+	if m.Spec.Kind == KindClassifier && len(outs[0]) < len(m.Spec.Classes) {
+		return fmt.Errorf("model produced %d scores for %d classes", len(outs[0]), len(m.Spec.Classes))
+	}
+	return nil
+}
+
+// removeCustomFiles deletes a rejected upload's model file and sidecar.
+func (r *Registry) removeCustomFiles(spec Spec) {
+	path := filepath.Join(r.dir, spec.FileName)
+	os.Remove(path)
+	os.Remove(strings.TrimSuffix(path, filepath.Ext(path)) + sidecarSuffix)
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+)
+
+// encSuffix marks an encrypted model file: "<file>.onnx.enc" sits in the
+// models directory in place of the plaintext "<file>.onnx" and is
+// decrypted into memory at load time — the plaintext never touches disk.
+const encSuffix = ".enc"
+
+// encMagic heads every encrypted model file so a truncated or foreign
+// file fails with a clear error instead of a GCM authentication one.
+var encMagic = []byte("nfws-onnx\x01")
+
+// EncryptModel seals plaintext ONNX bytes for at-rest storage:
+// magic header, random GCM nonce, then AES-256-GCM ciphertext. The
+// encrypt-model subcommand uses it to prepare model files for
+// deployments that set NFWS_MODEL_KEY.
+func EncryptModel(key, plain []byte) ([]byte, error) {
+	aead, err := modelAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("model: %w", err)
+	}
+	out := append([]byte(nil), encMagic...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plain, nil), nil
+}
+
+// decryptModel reverses EncryptModel.
+func decryptModel(key, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, encMagic) {
+		return nil, fmt.Errorf("model: not an encrypted model file")
+	}
+	aead, err := modelAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	data = data[len(encMagic):]
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("model: encrypted model file truncated")
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("model: decrypting model: %w", err)
+	}
+	return plain, nil
+}
+
+func modelAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("model: model key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("model: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,253 @@
+// Package model knows the concrete models this service ships, how each one
+// wants its input prepared, and how to interpret its raw output.
+package model
+
+import (
+	"fmt"
+	"math"
+)
+
+// Kind distinguishes classifiers (whole-image scores) from detectors
+// (bounding boxes per class).
+type Kind string
+
+const (
+	KindClassifier Kind = "classifier"
+	KindDetector   Kind = "detector"
+)
+
+// Spec describes one model's file, preprocessing, and output layout.
+// The JSON tags are the sidecar format written next to uploaded models
+// ("<name>.json") so specs survive outside this source file.
+type Spec struct {
+	// Name is the external identifier used in requests and responses.
+	Name string `json:"name"`
+	// FileName is the model file inside the models directory.
+	FileName string `json:"file_name"`
+	Kind     Kind   `json:"kind"`
+	// InputSize is the square side length the image is resized to.
+	InputSize int `json:"input_size"`
+	// Mean and Scale are applied per channel as (pixel - Mean) * Scale.
+	Mean  [3]float32 `json:"mean"`
+	Scale [3]float32 `json:"scale"`
+	// BGR flips the channel order during tensorization.
+	BGR bool `json:"bgr,omitempty"`
+	// Classes are the output class labels in model order.
+	Classes []string `json:"classes"`
+	// NSFWClasses is the subset of Classes that count toward the NSFW
+	// verdict.
+	NSFWClasses []string `json:"nsfw_classes"`
+	// Categories maps class labels into the shared taxonomy used by the
+	// schema=v2 response shape. Unmapped classes fall into
+	// CategoryNeutral.
+	Categories map[string]string `json:"categories,omitempty"`
+	// Severity maps class labels to severity tiers so downstream logic
+	// can tell "bikini" from "explicit nudity" without hardcoding class
+	// lists. Unmapped classes carry no severity.
+	Severity map[string]string `json:"severity,omitempty"`
+}
+
+// The shared category taxonomy for schema=v2 responses. Every model's
+// classes map into these so callers don't interpret per-model labels.
+const (
+	CategoryNudity     = "nudity"
+	CategoryHentai     = "hentai"
+	CategorySuggestive = "suggestive"
+	CategoryDrawing    = "drawing"
+	CategoryNeutral    = "neutral"
+)
+
+// Severity tiers, from most to least explicit.
+const (
+	SeverityExplicit   = "explicit"
+	SeveritySuggestive = "suggestive"
+	SeverityContextual = "contextual"
+)
+
+// SeverityRank orders tiers for max-severity aggregation; higher is more
+// explicit, unknown tiers rank lowest.
+func SeverityRank(tier string) int {
+	switch tier {
+	case SeverityExplicit:
+		return 3
+	case SeveritySuggestive:
+		return 2
+	case SeverityContextual:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Specs lists the models bundled with the service, keyed by Name.
+//
+// The file names match the ONNX files checked into models/.
+var Specs = []Spec{
+	{
+		Name:      "nsfw_squeezenet",
+		FileName:  "nsfw_squeezenet.onnx",
+		Kind:      KindClassifier,
+		InputSize: 224,
+		Mean:      [3]float32{104, 117, 123},
+		Scale:     [3]float32{1, 1, 1},
+		BGR:       true,
+		Classes:   []string{"sfw", "nsfw"},
+		NSFWClasses: []string{
+			"nsfw",
+		},
+		Categories: map[string]string{
+			"nsfw": CategoryNudity,
+		},
+		Severity: map[string]string{
+			"nsfw": SeverityExplicit,
+		},
+	},
+	{
+		Name:      "nsfw_mobilenet",
+		FileName:  "mobilenetv2-7.onnx",
+		Kind:      KindClassifier,
+		InputSize: 224,
+		Mean:      [3]float32{127.5, 127.5, 127.5},
+		Scale:     [3]float32{1 / 127.5, 1 / 127.5, 1 / 127.5},
+		Classes:   []string{"drawings", "hentai", "neutral", "porn", "sexy"},
+		NSFWClasses: []string{
+			"hentai", "porn", "sexy",
+		},
+		Categories: map[string]string{
+			"drawings": CategoryDrawing,
+			"hentai":   CategoryHentai,
+			"porn":     CategoryNudity,
+			"sexy":     CategorySuggestive,
+		},
+		Severity: map[string]string{
+			"hentai": SeverityExplicit,
+			"porn":   SeverityExplicit,
+			"sexy":   SeveritySuggestive,
+		},
+	},
+	{
+		Name:      "nudenet",
+		FileName:  "NudeNet-320n.onnx",
+		Kind:      KindDetector,
+		InputSize: 320,
+		Mean:      [3]float32{0, 0, 0},
+		Scale:     [3]float32{1 / 255.0, 1 / 255.0, 1 / 255.0},
+		Classes: []string{
+			"FEMALE_GENITALIA_COVERED", "FACE_FEMALE", "BUTTOCKS_EXPOSED",
+			"FEMALE_BREAST_EXPOSED", "FEMALE_GENITALIA_EXPOSED",
+			"MALE_BREAST_EXPOSED", "ANUS_EXPOSED", "FEET_EXPOSED",
+			"BELLY_COVERED", "FEET_COVERED", "ARMPITS_COVERED",
+			"ARMPITS_EXPOSED", "FACE_MALE", "BELLY_EXPOSED",
+			"MALE_GENITALIA_EXPOSED", "ANUS_COVERED",
+			"FEMALE_BREAST_COVERED", "BUTTOCKS_COVERED",
+		},
+		NSFWClasses: []string{
+			"BUTTOCKS_EXPOSED", "FEMALE_BREAST_EXPOSED",
+			"FEMALE_GENITALIA_EXPOSED", "ANUS_EXPOSED",
+			"MALE_GENITALIA_EXPOSED",
+		},
+		Categories: map[string]string{
+			"BUTTOCKS_EXPOSED":         CategoryNudity,
+			"FEMALE_BREAST_EXPOSED":    CategoryNudity,
+			"FEMALE_GENITALIA_EXPOSED": CategoryNudity,
+			"ANUS_EXPOSED":             CategoryNudity,
+			"MALE_GENITALIA_EXPOSED":   CategoryNudity,
+			"FEMALE_GENITALIA_COVERED": CategorySuggestive,
+			"FEMALE_BREAST_COVERED":    CategorySuggestive,
+			"BUTTOCKS_COVERED":         CategorySuggestive,
+			"ANUS_COVERED":             CategorySuggestive,
+			"MALE_BREAST_EXPOSED":      CategorySuggestive,
+			"BELLY_EXPOSED":            CategorySuggestive,
+			"ARMPITS_EXPOSED":          CategorySuggestive,
+			"FEET_EXPOSED":             CategorySuggestive,
+		},
+		Severity: map[string]string{
+			"BUTTOCKS_EXPOSED":         SeverityExplicit,
+			"FEMALE_BREAST_EXPOSED":    SeverityExplicit,
+			"FEMALE_GENITALIA_EXPOSED": SeverityExplicit,
+			"ANUS_EXPOSED":             SeverityExplicit,
+			"MALE_GENITALIA_EXPOSED":   SeverityExplicit,
+			"FEMALE_GENITALIA_COVERED": SeveritySuggestive,
+			"FEMALE_BREAST_COVERED":    SeveritySuggestive,
+			"BUTTOCKS_COVERED":         SeveritySuggestive,
+			"ANUS_COVERED":             SeveritySuggestive,
+			"MALE_BREAST_EXPOSED":      SeveritySuggestive,
+			"BELLY_EXPOSED":            SeverityContextual,
+			"ARMPITS_EXPOSED":          SeverityContextual,
+			"FEET_EXPOSED":             SeverityContextual,
+		},
+	},
+}
+
+// CategoryOf returns the taxonomy category for a class label, falling
+// back to CategoryNeutral for unmapped classes.
+func (s Spec) CategoryOf(label string) string {
+	if c, ok := s.Categories[label]; ok {
+		return c
+	}
+	return CategoryNeutral
+}
+
+// SeverityOf returns the severity tier for a class label, or "" for
+// classes that carry no severity.
+func (s Spec) SeverityOf(label string) string {
+	return s.Severity[label]
+}
+
+// SpecByName returns the spec with the given Name.
+func SpecByName(name string) (Spec, bool) {
+	for _, s := range Specs {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Spec{}, false
+}
+
+// IsNSFWClass reports whether label counts toward the NSFW verdict for
+// this spec.
+func (s Spec) IsNSFWClass(label string) bool {
+	for _, c := range s.NSFWClasses {
+		if c == label {
+			return true
+		}
+	}
+	return false
+}
+
+// Softmax converts raw logits to probabilities in place and returns them.
+func Softmax(logits []float32) []float32 {
+	if len(logits) == 0 {
+		return logits
+	}
+	max := logits[0]
+	for _, v := range logits[1:] {
+		if v > max {
+			max = v
+		}
+	}
+	var sum float64
+	for i, v := range logits {
+		e := math.Exp(float64(v - max))
+		logits[i] = float32(e)
+		sum += e
+	}
+	for i := range logits {
+		logits[i] = float32(float64(logits[i]) / sum)
+	}
+	return logits
+}
+
+// Validate sanity-checks a spec; used at startup.
+func (s Spec) Validate() error {
+	if s.Name == "" || s.FileName == "" {
+		return fmt.Errorf("model: spec missing name or file name")
+	}
+	if s.InputSize <= 0 {
+		return fmt.Errorf("model %s: invalid input size %d", s.Name, s.InputSize)
+	}
+	if len(s.Classes) == 0 {
+		return fmt.Errorf("model %s: no classes defined", s.Name)
+	}
+	return nil
+}
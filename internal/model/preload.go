@@ -0,0 +1,85 @@
+package model
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Window is the daily hour range [From,To) during which an on-schedule
+// model stays loaded. Ranges may wrap midnight, e.g. {22, 6}.
+type Window struct {
+	From, To int
+}
+
+// contains reports whether hour falls inside the window.
+func (w Window) contains(hour int) bool {
+	if w.From < w.To {
+		return hour >= w.From && hour < w.To
+	}
+	return hour >= w.From || hour < w.To
+}
+
+// PreloadScheduler loads and unloads models marked preload=on-schedule
+// so their memory follows traffic patterns: a heavy detector that only
+// earns its keep during peak hours is opened at the window start and
+// closed at the end.
+type PreloadScheduler struct {
+	reg     *Registry
+	windows map[string]Window
+	// onLoad, when non-nil, is called after each scheduled load so the
+	// caller can announce the model (e.g. a model.loaded event).
+	onLoad func(name, version string)
+	log    *slog.Logger
+}
+
+// NewPreloadScheduler builds a scheduler over the given per-model
+// windows. Hours are in the server's local time.
+func NewPreloadScheduler(reg *Registry, windows map[string]Window, onLoad func(name, version string), log *slog.Logger) *PreloadScheduler {
+	return &PreloadScheduler{reg: reg, windows: windows, onLoad: onLoad, log: log}
+}
+
+// Start applies the windows immediately, then re-checks every minute
+// until ctx is cancelled.
+func (p *PreloadScheduler) Start(ctx context.Context) {
+	go func() {
+		p.apply(time.Now())
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				p.apply(now)
+			}
+		}
+	}()
+}
+
+// apply brings each scheduled model in line with its window at the given
+// time.
+func (p *PreloadScheduler) apply(now time.Time) {
+	hour := now.Hour()
+	for name, w := range p.windows {
+		_, loaded := p.reg.Get(name)
+		switch {
+		case w.contains(hour) && !loaded:
+			m, err := p.reg.Load(name)
+			if err != nil {
+				p.log.Error("preload: loading model failed", "model", name, "err", err)
+				continue
+			}
+			p.log.Info("preload: model loaded", "model", name, "from", w.From, "to", w.To)
+			if p.onLoad != nil {
+				p.onLoad(name, m.Version)
+			}
+		case !w.contains(hour) && loaded:
+			if err := p.reg.Unload(name); err != nil {
+				p.log.Error("preload: unloading model failed", "model", name, "err", err)
+				continue
+			}
+			p.log.Info("preload: model unloaded", "model", name, "from", w.From, "to", w.To)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package model
+
+import "os"
+
+// Quantized model variants live next to the base file under the common
+// exporter naming "<file>-int8.onnx" / "<file>-fp16.onnx". They plug
+// into the version machinery with the quantization label as the version
+// name, so a tenant can pin "name@int8" explicitly while
+// NFWS_PREFER_QUANTIZED switches whole deployments over for throughput.
+
+// quantizations are the recognized variant labels, in preference order:
+// int8 gives the bigger speedup, fp16 the smaller accuracy loss.
+var quantizations = []string{"int8", "fp16"}
+
+// isQuantization reports whether a version label names a quantized
+// variant file rather than an "@" version file.
+func isQuantization(version string) bool {
+	for _, q := range quantizations {
+		if version == q {
+			return true
+		}
+	}
+	return false
+}
+
+// Quantization returns the variant label a loaded version runs with, or
+// "" for full precision.
+func (m *Loaded) Quantization() string {
+	if isQuantization(m.Version) {
+		return m.Version
+	}
+	return ""
+}
+
+// PreferQuantized activates the quantized variant of every loaded model
+// whose variant file is present, trading some accuracy for latency.
+// Models without a variant file keep serving at full precision.
+func (r *Registry) PreferQuantized() error {
+	for _, name := range r.Names() {
+		spec, ok := r.specByName(name)
+		if !ok {
+			continue
+		}
+		for _, q := range quantizations {
+			path := r.versionPath(spec, q)
+			if _, err := os.Stat(path); err != nil {
+				if _, encErr := os.Stat(path + encSuffix); encErr != nil {
+					continue
+				}
+			}
+			if err := r.Activate(name, q); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,522 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/inference"
+)
+
+// BaseVersion is the version label of the unversioned model file each
+// spec names; additional versions live alongside it as
+// "<file>@<version>.onnx" and are loaded lazily when first pinned.
+const BaseVersion = "base"
+
+// Preload modes control when a model's session is opened. Always is the
+// default: the model loads at startup and stays resident. Lazy defers
+// the load to the first scan that uses the model. OnSchedule leaves
+// loading and unloading to the PreloadScheduler, so a heavy model only
+// occupies memory during its configured hours.
+const (
+	PreloadAlways     = "always"
+	PreloadLazy       = "lazy"
+	PreloadOnSchedule = "on-schedule"
+)
+
+// Loaded is a model version that has been opened by the inference engine.
+type Loaded struct {
+	Spec      Spec
+	Version   string
+	Session   inference.Session
+	Path      string
+	SizeBytes int64
+	// Checksum is the hex SHA-256 of the model bytes handed to the
+	// engine (the plaintext, for encrypted files).
+	Checksum string
+	LoadedAt time.Time
+	// LoadDuration is how long the engine took to open the session.
+	LoadDuration time.Duration
+}
+
+// Registry owns the set of loaded models. Each model name can have
+// several versions loaded at once; the active version serves unpinned
+// requests while tenants with strict change control pin name@version.
+type Registry struct {
+	mu     sync.RWMutex
+	engine inference.Engine
+	dir    string
+	// models is name -> version -> loaded session.
+	models map[string]map[string]*Loaded
+	// active is the version serving unpinned requests for each name.
+	active map[string]string
+	// previous remembers the last active version per name so a bad
+	// model push can be rolled back in one call.
+	previous map[string]string
+	// preload holds the per-model preload mode; models without an entry
+	// behave as PreloadAlways.
+	preload map[string]string
+	// key decrypts ".enc" model files into memory at load time; nil
+	// restricts the registry to plaintext files.
+	key []byte
+	// maintenance maps a disabled model to whether default-set scans may
+	// silently fall back to the remaining models. The sessions stay
+	// loaded either way, so re-enabling is instant.
+	maintenance map[string]bool
+	// custom holds specs registered at runtime via AddModel, keyed like
+	// Specs by Name.
+	custom map[string]Spec
+}
+
+// NewRegistry loads every bundled model found in dir, plus any model a
+// JSON sidecar describes (see discoverSpecs), except those whose
+// preload hint defers loading. Missing files are skipped with an error
+// only if no model could be loaded at all. A non-nil key enables loading
+// encrypted "<file>.onnx.enc" models (see EncryptModel).
+func NewRegistry(engine inference.Engine, dir string, preload map[string]string, key []byte) (*Registry, error) {
+	r := &Registry{
+		engine:      engine,
+		dir:         dir,
+		models:      make(map[string]map[string]*Loaded),
+		active:      make(map[string]string),
+		previous:    make(map[string]string),
+		preload:     preload,
+		key:         key,
+		maintenance: make(map[string]bool),
+		custom:      make(map[string]Spec),
+	}
+	// Sidecar-described models found in the directory join the bundled
+	// specs; preload hints apply to both.
+	discovered, err := discoverSpecs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, spec := range discovered {
+		r.custom[spec.Name] = spec
+	}
+	for name, mode := range preload {
+		if _, ok := r.specByName(name); !ok {
+			return nil, fmt.Errorf("model: preload hint for unknown model %q", name)
+		}
+		switch mode {
+		case PreloadAlways, PreloadLazy, PreloadOnSchedule:
+		default:
+			return nil, fmt.Errorf("model %s: unknown preload mode %q", name, mode)
+		}
+	}
+	var firstErr error
+	for _, spec := range append(append([]Spec(nil), Specs...), discovered...) {
+		if err := spec.Validate(); err != nil {
+			return nil, err
+		}
+		if mode := preload[spec.Name]; mode == PreloadLazy || mode == PreloadOnSchedule {
+			continue
+		}
+		m, err := r.open(spec, BaseVersion)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("model %s: %w", spec.Name, err)
+			}
+			continue
+		}
+		r.models[spec.Name] = map[string]*Loaded{BaseVersion: m}
+		r.active[spec.Name] = BaseVersion
+	}
+	if len(r.models) == 0 && firstErr != nil {
+		return nil, fmt.Errorf("model: no models loaded: %w", firstErr)
+	}
+	return r, nil
+}
+
+// specByName resolves bundled specs plus runtime-registered ones.
+func (r *Registry) specByName(name string) (Spec, bool) {
+	if s, ok := SpecByName(name); ok {
+		return s, true
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.custom[name]
+	return s, ok
+}
+
+// versionPath maps a spec and version to the file inside the models
+// directory: the spec's own file for BaseVersion,
+// "<file>-<label>.onnx" for quantization labels, and
+// "<file>@<version>.onnx" otherwise.
+func (r *Registry) versionPath(spec Spec, version string) string {
+	if version == BaseVersion {
+		return filepath.Join(r.dir, spec.FileName)
+	}
+	ext := filepath.Ext(spec.FileName)
+	if isQuantization(version) {
+		return filepath.Join(r.dir, strings.TrimSuffix(spec.FileName, ext)+"-"+version+ext)
+	}
+	return filepath.Join(r.dir, strings.TrimSuffix(spec.FileName, ext)+"@"+version+ext)
+}
+
+// open loads one version of a model. When the plaintext file is absent
+// but "<file>.enc" exists, the encrypted bytes are decrypted into memory
+// and handed to the engine without ever writing plaintext to disk.
+// Callers hold no lock; the result is not yet registered.
+func (r *Registry) open(spec Spec, version string) (*Loaded, error) {
+	path := r.versionPath(spec, version)
+	fi, err := os.Stat(path)
+	if err != nil {
+		encFi, encErr := os.Stat(path + encSuffix)
+		if encErr != nil {
+			return nil, err
+		}
+		path, fi = path+encSuffix, encFi
+	}
+	var sess inference.Session
+	var sum [sha256.Size]byte
+	start := time.Now()
+	if strings.HasSuffix(path, encSuffix) {
+		if r.key == nil {
+			return nil, fmt.Errorf("model file %s is encrypted; set NFWS_MODEL_KEY", path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		plain, err := decryptModel(r.key, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		sum = sha256.Sum256(plain)
+		sess, err = r.engine.OpenBytes(path, plain)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		sum = sha256.Sum256(data)
+		if sess, err = r.engine.Open(path); err != nil {
+			return nil, err
+		}
+	}
+	return &Loaded{
+		Spec:         spec,
+		Version:      version,
+		Session:      sess,
+		Path:         path,
+		SizeBytes:    fi.Size(),
+		Checksum:     hex.EncodeToString(sum[:]),
+		LoadedAt:     time.Now(),
+		LoadDuration: time.Since(start),
+	}, nil
+}
+
+// Get returns the active version of the named model. Models marked
+// preload=lazy are loaded here on first use.
+func (r *Registry) Get(name string) (*Loaded, bool) {
+	r.mu.RLock()
+	m, ok := r.models[name][r.active[name]]
+	mode := r.preload[name]
+	r.mu.RUnlock()
+	if ok || mode != PreloadLazy {
+		return m, ok
+	}
+	m, err := r.Load(name)
+	return m, err == nil
+}
+
+// Load opens the named model's base version and makes it active if no
+// version is. It is idempotent and backs both lazy first use and the
+// preload scheduler.
+func (r *Registry) Load(name string) (*Loaded, error) {
+	spec, ok := r.specByName(name)
+	if !ok {
+		return nil, fmt.Errorf("model: unknown model %q", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m, ok := r.models[name][r.active[name]]; ok {
+		return m, nil
+	}
+	m, err := r.open(spec, BaseVersion)
+	if err != nil {
+		return nil, fmt.Errorf("model %s: %w", name, err)
+	}
+	if r.models[name] == nil {
+		r.models[name] = make(map[string]*Loaded)
+	}
+	r.models[name][BaseVersion] = m
+	if r.active[name] == "" {
+		r.active[name] = BaseVersion
+	}
+	return m, nil
+}
+
+// Unload closes and drops every version of the named model, freeing its
+// memory until the next Load. Scans that run the default model set skip
+// unloaded models; explicitly naming one reports it as unknown.
+func (r *Registry) Unload(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	versions, ok := r.models[name]
+	if !ok {
+		return fmt.Errorf("model %s: not loaded", name)
+	}
+	var firstErr error
+	for _, m := range versions {
+		if err := m.Session.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	delete(r.models, name)
+	delete(r.active, name)
+	delete(r.previous, name)
+	return firstErr
+}
+
+// MaintenanceError reports a scan touching a model an operator has
+// disabled. The well-known code lets clients distinguish maintenance
+// from a genuinely unknown model.
+type MaintenanceError struct {
+	Name string
+}
+
+func (e *MaintenanceError) Error() string {
+	return fmt.Sprintf("MODEL_IN_MAINTENANCE: model %s is disabled for maintenance", e.Name)
+}
+
+// SetMaintenance disables a model for scanning while keeping its
+// sessions loaded. With fallback, default-set scans silently continue
+// on the remaining models; without it they fail, as explicitly naming
+// the model always does.
+func (r *Registry) SetMaintenance(name string, fallback bool) error {
+	if _, ok := r.specByName(name); !ok {
+		return fmt.Errorf("model: unknown model %q", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maintenance[name] = fallback
+	return nil
+}
+
+// ClearMaintenance re-enables a disabled model.
+func (r *Registry) ClearMaintenance(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.maintenance[name]; !ok {
+		return fmt.Errorf("model %s: not in maintenance", name)
+	}
+	delete(r.maintenance, name)
+	return nil
+}
+
+// Maintenance reports whether a model is disabled and, if so, whether
+// default-set scans may fall back past it.
+func (r *Registry) Maintenance(name string) (disabled, fallback bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fallback, disabled = r.maintenance[name]
+	return disabled, fallback
+}
+
+// GetVersion returns a specific version, lazily loading its file on
+// first use so pinned tenants don't pay load time at startup.
+func (r *Registry) GetVersion(name, version string) (*Loaded, error) {
+	r.mu.RLock()
+	m, ok := r.models[name][version]
+	r.mu.RUnlock()
+	if ok {
+		return m, nil
+	}
+	spec, ok := r.specByName(name)
+	if !ok {
+		return nil, fmt.Errorf("model: unknown model %q", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m, ok := r.models[name][version]; ok {
+		return m, nil // loaded by a concurrent request
+	}
+	m, err := r.open(spec, version)
+	if err != nil {
+		return nil, fmt.Errorf("model %s@%s: %w", name, version, err)
+	}
+	if r.models[name] == nil {
+		r.models[name] = make(map[string]*Loaded)
+	}
+	r.models[name][version] = m
+	return m, nil
+}
+
+// Versions lists the loadable versions of a model: loaded ones plus
+// version files present in the models directory.
+func (r *Registry) Versions(name string) []string {
+	spec, ok := r.specByName(name)
+	if !ok {
+		return nil
+	}
+	set := make(map[string]bool)
+	r.mu.RLock()
+	for v := range r.models[name] {
+		set[v] = true
+	}
+	r.mu.RUnlock()
+	ext := filepath.Ext(spec.FileName)
+	prefix := strings.TrimSuffix(spec.FileName, ext) + "@"
+	entries, _ := os.ReadDir(r.dir)
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), encSuffix)
+		if strings.HasPrefix(name, prefix) && strings.HasSuffix(name, ext) {
+			set[strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)] = true
+		}
+	}
+	for _, q := range quantizations {
+		path := r.versionPath(spec, q)
+		if _, err := os.Stat(path); err == nil {
+			set[q] = true
+		} else if _, err := os.Stat(path + encSuffix); err == nil {
+			set[q] = true
+		}
+	}
+	out := make([]string, 0, len(set))
+	for v := range set {
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Activate atomically switches the version serving unpinned requests,
+// loading it first if needed, and remembers the outgoing version for
+// Rollback.
+func (r *Registry) Activate(name, version string) error {
+	if _, err := r.GetVersion(name, version); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.active[name] == version {
+		return nil
+	}
+	r.previous[name] = r.active[name]
+	r.active[name] = version
+	return nil
+}
+
+// Rollback atomically switches the alias back to the previously active
+// version, returning the version pair (from, to).
+func (r *Registry) Rollback(name string) (from, to string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.models[name]; !ok {
+		return "", "", fmt.Errorf("model: unknown model %q", name)
+	}
+	prev, ok := r.previous[name]
+	if !ok {
+		return "", "", fmt.Errorf("model %s: no previous version to roll back to", name)
+	}
+	from, to = r.active[name], prev
+	r.active[name], r.previous[name] = prev, r.active[name]
+	return from, to, nil
+}
+
+// ActiveVersion returns the version serving unpinned requests.
+func (r *Registry) ActiveVersion(name string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active[name]
+}
+
+// Names returns the loaded model names in sorted order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.models))
+	for n := range r.models {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// All returns the active version of every loaded model in name order.
+// Models marked preload=lazy join on first call; ones whose files are
+// missing stay out, as at startup.
+func (r *Registry) All() []*Loaded {
+	r.mu.RLock()
+	var missing []string
+	for name, mode := range r.preload {
+		if mode == PreloadLazy {
+			if _, ok := r.models[name]; !ok {
+				missing = append(missing, name)
+			}
+		}
+	}
+	r.mu.RUnlock()
+	for _, name := range missing {
+		r.Load(name)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.models))
+	for n := range r.models {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	out := make([]*Loaded, 0, len(names))
+	for _, n := range names {
+		if m, ok := r.models[n][r.active[n]]; ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Fingerprint identifies the active model set: any change to the names,
+// versions, files, or file sizes produces a different value. Stored
+// verdicts are stamped with it so they can be invalidated when models
+// change.
+func (r *Registry) Fingerprint() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.models))
+	for n := range r.models {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, n := range names {
+		m, ok := r.models[n][r.active[n]]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(h, "%s@%s|%s|%d\n", n, m.Version, m.Spec.FileName, m.SizeBytes)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// Engine exposes the backing inference engine.
+func (r *Registry) Engine() inference.Engine { return r.engine }
+
+// Dir returns the models directory the registry was loaded from.
+func (r *Registry) Dir() string { return r.dir }
+
+// Close releases every session of every version.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var firstErr error
+	for _, versions := range r.models {
+		for _, m := range versions {
+			if err := m.Session.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,108 @@
+// Package plugin runs deployment-supplied WebAssembly for
+// customer-specific scan logic, sandboxed with wazero. A plugin is a
+// WASI command module: it reads its input from stdin, writes its output
+// to stdout, and exits — so rules can be written in any language with a
+// wasip1 target and can neither touch the filesystem nor the network.
+//
+// Two hooks exist. The verdict hook receives the scan verdict as JSON
+// and may override the overall score and flag; the preprocess hook
+// receives the raw image bytes and returns the (possibly rewritten)
+// bytes to scan. Either way a failing or slow plugin fails open: the
+// scan proceeds as if the plugin weren't there.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// The hook points a plugin can attach to.
+const (
+	// HookVerdict runs after scoring: stdin is the verdict JSON, stdout
+	// the adjustment.
+	HookVerdict = "verdict"
+	// HookPreprocess runs before decoding: stdin is the raw image,
+	// stdout the image to scan instead.
+	HookPreprocess = "preprocess"
+)
+
+// ValidHook reports whether hook names a supported hook point.
+func ValidHook(hook string) bool {
+	return hook == HookVerdict || hook == HookPreprocess
+}
+
+// Plugin is one compiled WASM module ready to instantiate per scan.
+type Plugin struct {
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	hook     string
+	timeout  time.Duration
+}
+
+// Load compiles the module at path for the given hook. timeout bounds
+// one execution; the sandbox is torn down when it expires.
+func Load(ctx context.Context, path, hook string, timeout time.Duration) (*Plugin, error) {
+	if !ValidHook(hook) {
+		return nil, fmt.Errorf("plugin: unknown hook %q", hook)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("plugin: %w", err)
+	}
+	// CloseOnContextDone is what makes the per-run timeout enforceable
+	// inside guest code, not just between host calls.
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+	wasi_snapshot_preview1.MustInstantiate(ctx, rt)
+	compiled, err := rt.CompileModule(ctx, data)
+	if err != nil {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("plugin: compiling %s: %w", path, err)
+	}
+	return &Plugin{runtime: rt, compiled: compiled, hook: hook, timeout: timeout}, nil
+}
+
+// Hook returns the hook point this plugin attaches to.
+func (p *Plugin) Hook() string { return p.hook }
+
+// Run executes one sandboxed instantiation with input on stdin and
+// returns what the module wrote to stdout. A non-zero exit, trap, or
+// timeout is an error; the caller decides what failing open means.
+func (p *Plugin) Run(ctx context.Context, input []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cfg := wazero.NewModuleConfig().
+		WithStdin(bytes.NewReader(input)).
+		WithStdout(&stdout).
+		WithStderr(&stderr).
+		WithName("")
+	mod, err := p.runtime.InstantiateModule(ctx, p.compiled, cfg)
+	if err != nil {
+		var exit *sys.ExitError
+		if errors.As(err, &exit) && exit.ExitCode() == 0 {
+			return stdout.Bytes(), nil
+		}
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("plugin: %w (stderr: %s)", err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return nil, fmt.Errorf("plugin: %w", err)
+	}
+	mod.Close(ctx)
+	return stdout.Bytes(), nil
+}
+
+// Close releases the runtime.
+func (p *Plugin) Close(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	p.runtime.Close(ctx)
+}
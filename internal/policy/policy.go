@@ -0,0 +1,121 @@
+// Package policy evaluates deployment-defined CEL expressions against
+// each scan's outputs. Rules see the verdict, the per-category and
+// per-model scores, detector boxes, image metadata, and the caller's
+// tenant and declared surface, so policies like
+//
+//	nudity.score > 0.8 || (suggestive.score > 0.6 && surface == "minors_app")
+//
+// live in configuration instead of code. Expressions are compiled and
+// type-checked at load, so a typo'd variable or a non-boolean rule
+// fails startup rather than a scan.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/google/cel-go/cel"
+)
+
+// Rule is one named policy expression. A scan that makes the
+// expression true is flagged regardless of the threshold verdict.
+type Rule struct {
+	Name string `json:"name"`
+	Expr string `json:"expr"`
+
+	prog cel.Program
+}
+
+// Set is the compiled rule set, evaluated in file order.
+type Set struct {
+	rules []Rule
+}
+
+// Load reads a JSON array of rules and compiles every expression
+// against the scan environment. Any compile or type error fails the
+// load with the offending rule named.
+func Load(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("policy: parsing %s: %w", path, err)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("policy: %s defines no rules", path)
+	}
+	env, err := scanEnv()
+	if err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	seen := make(map[string]bool, len(rules))
+	for i := range rules {
+		r := &rules[i]
+		if r.Name == "" {
+			return nil, fmt.Errorf("policy: rule %d has no name", i)
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("policy: duplicate rule name %q", r.Name)
+		}
+		seen[r.Name] = true
+		ast, iss := env.Compile(r.Expr)
+		if iss.Err() != nil {
+			return nil, fmt.Errorf("policy: rule %q: %w", r.Name, iss.Err())
+		}
+		if ast.OutputType() != cel.BoolType {
+			return nil, fmt.Errorf("policy: rule %q must evaluate to a boolean, not %s", r.Name, ast.OutputType())
+		}
+		r.prog, err = env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("policy: rule %q: %w", r.Name, err)
+		}
+	}
+	return &Set{rules: rules}, nil
+}
+
+// scanEnv declares the variables a rule may reference. The five
+// category variables each carry {score: double, flagged: bool}.
+func scanEnv() (*cel.Env, error) {
+	category := cel.MapType(cel.StringType, cel.DynType)
+	return cel.NewEnv(
+		cel.Variable("nsfw", cel.BoolType),
+		cel.Variable("score", cel.DoubleType),
+		cel.Variable("threshold", cel.DoubleType),
+		cel.Variable("severity", cel.StringType),
+		cel.Variable("format", cel.StringType),
+		cel.Variable("width", cel.IntType),
+		cel.Variable("height", cel.IntType),
+		cel.Variable("tenant", cel.StringType),
+		cel.Variable("surface", cel.StringType),
+		cel.Variable("models", cel.MapType(cel.StringType, cel.DoubleType)),
+		cel.Variable("detections", cel.ListType(cel.MapType(cel.StringType, cel.DynType))),
+		cel.Variable("nudity", category),
+		cel.Variable("hentai", category),
+		cel.Variable("suggestive", category),
+		cel.Variable("drawing", category),
+		cel.Variable("neutral", category),
+	)
+}
+
+// Len returns the number of loaded rules.
+func (s *Set) Len() int { return len(s.rules) }
+
+// Eval runs every rule against one scan's input and returns the names
+// of the rules that fired, in file order.
+func (s *Set) Eval(input map[string]any) ([]string, error) {
+	var fired []string
+	for i := range s.rules {
+		r := &s.rules[i]
+		out, _, err := r.prog.Eval(input)
+		if err != nil {
+			return nil, fmt.Errorf("policy: rule %q: %w", r.Name, err)
+		}
+		if b, ok := out.Value().(bool); ok && b {
+			fired = append(fired, r.Name)
+		}
+	}
+	return fired, nil
+}
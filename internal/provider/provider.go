@@ -0,0 +1,226 @@
+// Package provider adapts external moderation services for the
+// borderline-escalation path: scans whose local score lands near the
+// threshold can be forwarded for a cloud second opinion, keeping the
+// deployment local-first.
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Provider scores an image with an external moderation service.
+type Provider interface {
+	Name() string
+	// Score returns the provider's NSFW probability in [0,1].
+	Score(ctx context.Context, image []byte) (float64, error)
+}
+
+// New builds a provider adapter. Supported kinds: "http" (a generic
+// endpoint returning {"score": x}), "vision" (Google Cloud Vision
+// SafeSearch via API key), and "hive" (Hive's sync classification API).
+func New(kind, rawURL, key string, timeout time.Duration) (Provider, error) {
+	client := &http.Client{Timeout: timeout}
+	switch kind {
+	case "http":
+		if rawURL == "" {
+			return nil, fmt.Errorf("provider: kind %q requires NFWS_PROVIDER_URL", kind)
+		}
+		return &httpProvider{url: rawURL, key: key, client: client}, nil
+	case "vision":
+		if key == "" {
+			return nil, fmt.Errorf("provider: kind %q requires NFWS_PROVIDER_KEY", kind)
+		}
+		if rawURL == "" {
+			rawURL = "https://vision.googleapis.com/v1/images:annotate"
+		}
+		return &visionProvider{url: rawURL, key: key, client: client}, nil
+	case "hive":
+		if key == "" {
+			return nil, fmt.Errorf("provider: kind %q requires NFWS_PROVIDER_KEY", kind)
+		}
+		if rawURL == "" {
+			rawURL = "https://api.thehive.ai/api/v2/task/sync"
+		}
+		return &hiveProvider{url: rawURL, key: key, client: client}, nil
+	default:
+		return nil, fmt.Errorf("provider: unknown provider kind %q", kind)
+	}
+}
+
+// httpProvider POSTs the raw image to a caller-provided endpoint and
+// expects {"score": x} back. The shim keeps SDK-heavy providers (e.g.
+// Rekognition's SigV4 signing) out of this service: run their SDK in a
+// tiny proxy and point NFWS_PROVIDER_URL at it.
+type httpProvider struct {
+	url    string
+	key    string
+	client *http.Client
+}
+
+func (p *httpProvider) Name() string { return "http" }
+
+func (p *httpProvider) Score(ctx context.Context, image []byte) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(image))
+	if err != nil {
+		return 0, fmt.Errorf("provider: building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if p.key != "" {
+		req.Header.Set("X-API-Key", p.key)
+	}
+	var out struct {
+		Score float64 `json:"score"`
+	}
+	if err := p.do(req, &out); err != nil {
+		return 0, err
+	}
+	return out.Score, nil
+}
+
+func (p *httpProvider) do(req *http.Request, out any) error { return doJSON(p.client, req, out) }
+
+// visionProvider calls Google Cloud Vision SafeSearch and maps the
+// adult/racy likelihood ladder onto a score.
+type visionProvider struct {
+	url    string
+	key    string
+	client *http.Client
+}
+
+func (p *visionProvider) Name() string { return "vision" }
+
+func (p *visionProvider) Score(ctx context.Context, image []byte) (float64, error) {
+	body, err := json.Marshal(map[string]any{
+		"requests": []map[string]any{{
+			"image":    map[string]string{"content": base64.StdEncoding.EncodeToString(image)},
+			"features": []map[string]string{{"type": "SAFE_SEARCH_DETECTION"}},
+		}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("provider: encoding vision request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url+"?key="+p.key, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("provider: building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var out struct {
+		Responses []struct {
+			SafeSearchAnnotation struct {
+				Adult string `json:"adult"`
+				Racy  string `json:"racy"`
+			} `json:"safeSearchAnnotation"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"responses"`
+	}
+	if err := doJSON(p.client, req, &out); err != nil {
+		return 0, err
+	}
+	if len(out.Responses) == 0 {
+		return 0, fmt.Errorf("provider: vision returned no responses")
+	}
+	r := out.Responses[0]
+	if r.Error != nil {
+		return 0, fmt.Errorf("provider: vision: %s", r.Error.Message)
+	}
+	score := likelihoodScore(r.SafeSearchAnnotation.Adult)
+	if racy := likelihoodScore(r.SafeSearchAnnotation.Racy); racy > score {
+		score = racy
+	}
+	return score, nil
+}
+
+// likelihoodScore maps Vision's likelihood ladder to the middle of each
+// bucket, the inverse of our own bucketing.
+func likelihoodScore(l string) float64 {
+	switch l {
+	case "VERY_UNLIKELY":
+		return 0.1
+	case "UNLIKELY":
+		return 0.3
+	case "POSSIBLE":
+		return 0.5
+	case "LIKELY":
+		return 0.7
+	case "VERY_LIKELY":
+		return 0.9
+	default:
+		return 0
+	}
+}
+
+// hiveProvider calls Hive's synchronous classification API and returns
+// the general_nsfw class score.
+type hiveProvider struct {
+	url    string
+	key    string
+	client *http.Client
+}
+
+func (p *hiveProvider) Name() string { return "hive" }
+
+func (p *hiveProvider) Score(ctx context.Context, image []byte) (float64, error) {
+	body, err := json.Marshal(map[string]string{
+		"media_base64": base64.StdEncoding.EncodeToString(image),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("provider: encoding hive request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("provider: building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "token "+p.key)
+	var out struct {
+		Status []struct {
+			Response struct {
+				Output []struct {
+					Classes []struct {
+						Class string  `json:"class"`
+						Score float64 `json:"score"`
+					} `json:"classes"`
+				} `json:"output"`
+			} `json:"response"`
+		} `json:"status"`
+	}
+	if err := doJSON(p.client, req, &out); err != nil {
+		return 0, err
+	}
+	for _, st := range out.Status {
+		for _, o := range st.Response.Output {
+			for _, c := range o.Classes {
+				if c.Class == "general_nsfw" {
+					return c.Score, nil
+				}
+			}
+		}
+	}
+	return 0, fmt.Errorf("provider: hive response carried no general_nsfw class")
+}
+
+// doJSON executes req and decodes the JSON response into out.
+func doJSON(client *http.Client, req *http.Request, out any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("provider: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("provider: unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("provider: decoding response: %w", err)
+	}
+	return nil
+}
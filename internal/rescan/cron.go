@@ -0,0 +1,87 @@
+package rescan
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronExpr is a parsed five-field cron expression (minute, hour, day of
+// month, month, day of week) supporting *, */step, comma lists, and
+// single values — enough for rescan schedules without pulling in a cron
+// library.
+type cronExpr struct {
+	minute, hour, dom, month, dow map[int]bool
+}
+
+// parseCron parses "m h dom mon dow".
+func parseCron(expr string) (*cronExpr, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("rescan: cron %q: expected 5 fields, got %d", expr, len(fields))
+	}
+	ranges := []struct {
+		min, max int
+	}{
+		{0, 59}, // minute
+		{0, 23}, // hour
+		{1, 31}, // day of month
+		{1, 12}, // month
+		{0, 6},  // day of week
+	}
+	sets := make([]map[int]bool, 5)
+	for i, field := range fields {
+		set, err := parseCronField(field, ranges[i].min, ranges[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("rescan: cron %q field %d: %w", expr, i+1, err)
+		}
+		sets[i] = set
+	}
+	return &cronExpr{minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4]}, nil
+}
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	set := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if base, stepStr, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid step %q", stepStr)
+			}
+			step, part = n, base
+		}
+		if part == "*" {
+			for v := min; v <= max; v += step {
+				set[v] = true
+			}
+			continue
+		}
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q", part)
+		}
+		if v < min || v > max {
+			return nil, fmt.Errorf("value %d out of range [%d,%d]", v, min, max)
+		}
+		if step != 1 {
+			for ; v <= max; v += step {
+				set[v] = true
+			}
+			continue
+		}
+		set[v] = true
+	}
+	return set, nil
+}
+
+// matches reports whether t (truncated to the minute) satisfies the
+// expression.
+func (c *cronExpr) matches(t time.Time) bool {
+	return c.minute[t.Minute()] &&
+		c.hour[t.Hour()] &&
+		c.dom[t.Day()] &&
+		c.month[int(t.Month())] &&
+		c.dow[int(t.Weekday())]
+}
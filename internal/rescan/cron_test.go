@@ -0,0 +1,61 @@
+package rescan
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	cases := []string{
+		"* * * *",      // four fields
+		"* * * * * *",  // six fields
+		"60 * * * *",   // minute out of range
+		"* 24 * * *",   // hour out of range
+		"* * 0 * *",    // day of month out of range
+		"* * * 13 *",   // month out of range
+		"* * * * 7",    // day of week out of range
+		"a * * * *",    // not a number
+		"*/0 * * * *",  // zero step
+		"*/x * * * *",  // non-numeric step
+		"1,60 * * * *", // bad list member
+		"",             // empty
+	}
+	for _, expr := range cases {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parseCron(%q) accepted an invalid expression", expr)
+		}
+	}
+}
+
+func TestCronMatches(t *testing.T) {
+	// Monday 2024-01-15 03:30 UTC.
+	at := time.Date(2024, 1, 15, 3, 30, 0, 0, time.UTC)
+	cases := []struct {
+		expr string
+		want bool
+	}{
+		{"* * * * *", true},
+		{"30 3 * * *", true},
+		{"30 3 15 1 *", true},
+		{"30 3 * * 1", true},  // Monday
+		{"30 3 * * 0", false}, // Sunday
+		{"0 3 * * *", false},
+		{"30 4 * * *", false},
+		{"*/15 * * * *", true}, // 0,15,30,45
+		{"*/7 * * * *", false}, // 0,7,14,21,28,35,...
+		{"10,30,50 * * * *", true},
+		{"10,20,50 * * * *", false},
+		{"30 */3 * * *", true}, // hours 0,3,6,...
+		{"30 1/2 * * *", true}, // hours 1,3,5,...
+		{"* * * 2 *", false},   // wrong month
+	}
+	for _, c := range cases {
+		expr, err := parseCron(c.expr)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", c.expr, err)
+		}
+		if got := expr.matches(at); got != c.want {
+			t.Errorf("%q matches %v = %v, want %v", c.expr, at, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Package rescan periodically re-runs moderation over a manifest of
+// content with the currently loaded models. Verdicts go stale as models
+// improve; a scheduled rescan catches images that an older model version
+// waved through. Leader election through the shared store ensures only
+// one replica executes each scheduled run.
+package rescan
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/s3"
+	"github.com/illussioon/NFWS-Moderations-API/internal/scan"
+	"github.com/illussioon/NFWS-Moderations-API/internal/store"
+)
+
+// leaseTTL bounds how long a crashed leader blocks the next run.
+const leaseTTL = 5 * time.Minute
+
+// Runner executes scheduled rescans.
+type Runner struct {
+	schedule *cronExpr
+	manifest string
+	scanner  *scan.Scanner
+	store    store.Store
+	s3       *s3.Client
+	holder   string
+	log      *slog.Logger
+}
+
+// New builds a Runner. schedule is a five-field cron expression;
+// manifest is an http(s)://, s3://, or local path to a newline-delimited
+// list of image URLs.
+func New(schedule, manifest string, scanner *scan.Scanner, st store.Store, s3c *s3.Client, log *slog.Logger) (*Runner, error) {
+	expr, err := parseCron(schedule)
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	return &Runner{
+		schedule: expr,
+		manifest: manifest,
+		scanner:  scanner,
+		store:    st,
+		s3:       s3c,
+		holder:   fmt.Sprintf("%s-%d", host, os.Getpid()),
+		log:      log,
+	}, nil
+}
+
+// Start runs the schedule loop until ctx is cancelled.
+func (r *Runner) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		var lastRun time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				minute := now.Truncate(time.Minute)
+				if !r.schedule.matches(minute) || minute.Equal(lastRun) {
+					continue
+				}
+				lastRun = minute
+				ok, err := r.store.Lease(ctx, "rescan", r.holder, leaseTTL)
+				if err != nil {
+					r.log.Error("rescan leader election failed", "err", err)
+					continue
+				}
+				if !ok {
+					continue // another replica is leader this round
+				}
+				r.runOnce(ctx)
+			}
+		}
+	}()
+}
+
+// runOnce rescans every manifest entry with all loaded models.
+func (r *Runner) runOnce(ctx context.Context) {
+	start := time.Now()
+	urls, err := r.loadManifest(ctx)
+	if err != nil {
+		r.log.Error("rescan: loading manifest failed", "manifest", r.manifest, "err", err)
+		return
+	}
+	var scanned, flagged, failed int
+	for _, url := range urls {
+		if ctx.Err() != nil {
+			return
+		}
+		resp, err := r.scanOne(ctx, url)
+		if err != nil {
+			failed++
+			r.log.Warn("rescan: scan failed", "url", url, "err", err)
+			continue
+		}
+		scanned++
+		if resp {
+			flagged++
+			r.log.Info("rescan: content flagged", "url", url)
+		}
+	}
+	r.log.Info("rescan complete",
+		"manifest", r.manifest,
+		"scanned", scanned,
+		"flagged", flagged,
+		"failed", failed,
+		"duration", time.Since(start).Round(time.Millisecond),
+	)
+}
+
+func (r *Runner) scanOne(ctx context.Context, url string) (nsfw bool, err error) {
+	var data []byte
+	if bucket, key, ok := s3.ParseURL(url); ok {
+		if !r.s3.Configured() {
+			return false, fmt.Errorf("s3:// manifest entry but S3 not configured")
+		}
+		data, err = r.s3.GetObject(ctx, bucket, key, 0)
+	} else {
+		data, err = r.scanner.Fetch(ctx, url)
+	}
+	if err != nil {
+		return false, err
+	}
+	resp, err := r.scanner.ScanBytes(ctx, data, scan.Options{})
+	if err != nil {
+		return false, err
+	}
+	return resp.NSFW, nil
+}
+
+// loadManifest reads the newline-delimited URL list, skipping blanks and
+// #-comments.
+func (r *Runner) loadManifest(ctx context.Context) ([]string, error) {
+	var data []byte
+	var err error
+	switch {
+	case strings.HasPrefix(r.manifest, "s3://"):
+		bucket, key, ok := s3.ParseURL(r.manifest)
+		if !ok {
+			return nil, fmt.Errorf("invalid s3:// manifest URL")
+		}
+		if !r.s3.Configured() {
+			return nil, fmt.Errorf("s3:// manifest but S3 not configured")
+		}
+		data, err = r.s3.GetObject(ctx, bucket, key, 0)
+	case strings.HasPrefix(r.manifest, "http://"), strings.HasPrefix(r.manifest, "https://"):
+		data, err = fetchHTTP(ctx, r.manifest)
+	default:
+		data, err = os.ReadFile(r.manifest)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var urls []string
+	sc := bufio.NewScanner(strings.NewReader(string(data)))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	return urls, sc.Err()
+}
+
+func fetchHTTP(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("manifest fetch: status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,51 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+)
+
+// Writeback modes.
+const (
+	WritebackOff     = ""
+	WritebackTags    = "tags"
+	WritebackSidecar = "sidecar"
+)
+
+// ValidWritebackMode reports whether mode is a known writeback setting.
+func ValidWritebackMode(mode string) bool {
+	switch mode {
+	case WritebackOff, WritebackTags, WritebackSidecar:
+		return true
+	}
+	return false
+}
+
+// Writeback records a verdict on the scanned object so downstream
+// consumers can filter objects without calling the API. With "tags" the
+// object's tag set is replaced by nsfw/nfws_score/nfws_scanned_at tags;
+// with "sidecar" a <key>.nfws.json object is written next to it holding
+// the full scan response.
+func (c *Client) Writeback(ctx context.Context, mode, bucket, key string, resp *api.ScanResponse) error {
+	switch mode {
+	case WritebackTags:
+		return c.PutObjectTagging(ctx, bucket, key, map[string]string{
+			"nsfw":            strconv.FormatBool(resp.NSFW),
+			"nfws_score":      strconv.FormatFloat(resp.Score, 'f', 4, 64),
+			"nfws_scanned_at": time.Now().UTC().Format(time.RFC3339),
+		})
+	case WritebackSidecar:
+		body, err := json.Marshal(resp)
+		if err != nil {
+			return err
+		}
+		return c.PutObject(ctx, bucket, key+".nfws.json", "application/json", body)
+	default:
+		return fmt.Errorf("s3: unknown writeback mode %q", mode)
+	}
+}
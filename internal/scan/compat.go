@@ -0,0 +1,113 @@
+package scan
+
+import (
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/model"
+)
+
+// Compatibility modes mirror the response shapes of the cloud
+// moderation APIs callers migrate from.
+const (
+	// CompatSafeSearch selects the Google Cloud Vision SafeSearch
+	// response shape.
+	CompatSafeSearch = "safesearch"
+	// CompatRekognition selects the AWS Rekognition
+	// DetectModerationLabels response shape.
+	CompatRekognition = "rekognition"
+)
+
+// ValidCompat reports whether mode is a known compatibility mode.
+func ValidCompat(mode string) bool {
+	switch mode {
+	case "", CompatSafeSearch, CompatRekognition:
+		return true
+	}
+	return false
+}
+
+// likelihood maps a score onto Vision's likelihood ladder.
+func likelihood(score float64) string {
+	switch {
+	case score < 0.2:
+		return "VERY_UNLIKELY"
+	case score < 0.4:
+		return "UNLIKELY"
+	case score < 0.6:
+		return "POSSIBLE"
+	case score < 0.8:
+		return "LIKELY"
+	default:
+		return "VERY_LIKELY"
+	}
+}
+
+// safeSearch folds per-model class scores into Vision SafeSearch
+// buckets: explicit-tier classes drive adult, suggestive and contextual
+// tiers drive racy. Spoof, medical, and violence have no counterpart in
+// the loaded models and report UNKNOWN, which is what Vision itself
+// returns when it can't tell.
+func safeSearch(models []*model.Loaded, results map[string]api.ModelResult) *api.SafeSearchResult {
+	var adult, racy float64
+	consider := func(spec model.Spec, label string, score float64) {
+		switch spec.SeverityOf(label) {
+		case model.SeverityExplicit:
+			if score > adult {
+				adult = score
+			}
+		case model.SeveritySuggestive, model.SeverityContextual:
+			if score > racy {
+				racy = score
+			}
+		}
+	}
+	for _, m := range models {
+		result, ok := results[m.Spec.Name]
+		if !ok {
+			continue
+		}
+		for label, score := range result.Scores {
+			consider(m.Spec, label, score)
+		}
+		for _, d := range result.Detections {
+			consider(m.Spec, d.Label, d.Score)
+		}
+	}
+	return &api.SafeSearchResult{
+		Adult:    likelihood(adult),
+		Racy:     likelihood(racy),
+		Spoof:    "UNKNOWN",
+		Medical:  "UNKNOWN",
+		Violence: "UNKNOWN",
+	}
+}
+
+// rekognitionMinConfidence matches Rekognition's default MinConfidence:
+// labels below it are omitted, like Rekognition omits them.
+const rekognitionMinConfidence = 50
+
+// rekognition folds category scores into Rekognition moderation labels:
+// nudity maps to "Explicit Nudity", hentai to "Illustrated Explicit
+// Nudity" under it, and suggestive to "Suggestive". Confidence is the
+// category score on Rekognition's 0-100 scale.
+func rekognition(models []*model.Loaded, results map[string]api.ModelResult, modelVersion string) *api.RekognitionResult {
+	cats := categorize(models, results, 1) // threshold only affects Flagged, unused here
+	confidence := func(cat string) float64 { return cats[cat].Score * 100 }
+
+	explicit := confidence(model.CategoryNudity)
+	if h := confidence(model.CategoryHentai); h > explicit {
+		explicit = h
+	}
+	var labels []api.ModerationLabel
+	add := func(name, parent string, conf float64) {
+		if conf >= rekognitionMinConfidence {
+			labels = append(labels, api.ModerationLabel{Name: name, ParentName: parent, Confidence: conf})
+		}
+	}
+	add("Explicit Nudity", "", explicit)
+	add("Illustrated Explicit Nudity", "Explicit Nudity", confidence(model.CategoryHentai))
+	add("Suggestive", "", confidence(model.CategorySuggestive))
+	return &api.RekognitionResult{
+		ModerationLabels:       labels,
+		ModerationModelVersion: modelVersion,
+	}
+}
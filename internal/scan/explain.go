@@ -0,0 +1,47 @@
+package scan
+
+import (
+	"fmt"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/model"
+)
+
+// explain turns a flagged verdict into plain sentences for appeal
+// messages: which detections and class scores crossed the threshold,
+// how much of the image an offending detection covers, and which policy
+// rules fired. It runs before label mapping, so the sentences carry the
+// raw class names the thresholds were evaluated against.
+func explain(models []*model.Loaded, resp *api.ScanResponse, threshold float64) []string {
+	var out []string
+	area := float64(resp.Width * resp.Height)
+	for _, m := range models {
+		result, ok := resp.Models[m.Spec.Name]
+		if !ok {
+			continue
+		}
+		for _, d := range result.Detections {
+			if !m.Spec.IsNSFWClass(d.Label) || d.Score < threshold {
+				continue
+			}
+			pct := 0.0
+			if area > 0 {
+				pct = float64(d.Box[2]*d.Box[3]) / area * 100
+			}
+			out = append(out, fmt.Sprintf("flagged: %s at %.2f covering %.0f%% of image", d.Label, d.Score, pct))
+		}
+		// Classes iterate in spec order so the sentences come out stable
+		// across runs.
+		for _, label := range m.Spec.Classes {
+			score, ok := result.Scores[label]
+			if !ok || !m.Spec.IsNSFWClass(label) || score < threshold {
+				continue
+			}
+			out = append(out, fmt.Sprintf("flagged: %s scored %.2f (%s)", label, score, m.Spec.Name))
+		}
+	}
+	for _, rule := range resp.Policies {
+		out = append(out, fmt.Sprintf("flagged: policy rule %s matched", rule))
+	}
+	return out
+}
@@ -0,0 +1,132 @@
+package scan
+
+import (
+	"image"
+	"math/bits"
+	"sort"
+)
+
+// Aggregation strategies for multi-frame (GIF) scans. Different policies
+// want different sensitivity to single-frame spikes: max catches one bad
+// frame, mean ignores it, top-k and streak sit in between.
+const (
+	// AggMax takes the highest frame score. The default.
+	AggMax = "max"
+	// AggMean averages all frame scores.
+	AggMean = "mean"
+	// AggTopK averages the k highest frame scores.
+	AggTopK = "topk"
+	// AggStreak is the highest score sustained across k consecutive
+	// frames (the max over all k-length windows of the window minimum),
+	// so a single-frame spike can't flag on its own.
+	AggStreak = "streak"
+)
+
+// defaultAggK is the k used by AggTopK and AggStreak when the request
+// doesn't supply one.
+const defaultAggK = 3
+
+// maxFrames caps how many frames of an animation are scanned; longer
+// animations are sampled evenly.
+const maxFrames = 64
+
+// dedupeDistance is the maximum Hamming distance between average hashes
+// at which a frame counts as a duplicate of the previous one.
+// Consecutive frames are often near-identical, so skipping their
+// inference cuts animation scanning cost dramatically.
+const dedupeDistance = 2
+
+// dupeOf returns the index of the already-scanned frame this hash
+// duplicates, or -1. Exact matches dedupe against any earlier frame;
+// near matches (within dedupeDistance) only against the immediately
+// preceding one, where "near-identical" is a safe assumption.
+func dupeOf(hash uint64, seen map[uint64]int, prev int, prevHash uint64) int {
+	if i, ok := seen[hash]; ok {
+		return i
+	}
+	if prev >= 0 && hammingDistance(hash, prevHash) <= dedupeDistance {
+		return prev
+	}
+	return -1
+}
+
+func hammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// ValidAggregation reports whether name is a known strategy ("" means
+// the default).
+func ValidAggregation(name string) bool {
+	switch name {
+	case "", AggMax, AggMean, AggTopK, AggStreak:
+		return true
+	}
+	return false
+}
+
+// aggregate folds per-frame scores into one according to the strategy.
+func aggregate(scores []float64, strategy string, k int) float64 {
+	if len(scores) == 0 {
+		return 0
+	}
+	if k <= 0 {
+		k = defaultAggK
+	}
+	switch strategy {
+	case AggMean:
+		var sum float64
+		for _, s := range scores {
+			sum += s
+		}
+		return sum / float64(len(scores))
+	case AggTopK:
+		sorted := append([]float64(nil), scores...)
+		sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+		if k > len(sorted) {
+			k = len(sorted)
+		}
+		var sum float64
+		for _, s := range sorted[:k] {
+			sum += s
+		}
+		return sum / float64(k)
+	case AggStreak:
+		if k > len(scores) {
+			k = len(scores)
+		}
+		best := 0.0
+		for i := 0; i+k <= len(scores); i++ {
+			windowMin := scores[i]
+			for _, s := range scores[i+1 : i+k] {
+				if s < windowMin {
+					windowMin = s
+				}
+			}
+			if windowMin > best {
+				best = windowMin
+			}
+		}
+		return best
+	default: // AggMax
+		best := scores[0]
+		for _, s := range scores[1:] {
+			if s > best {
+				best = s
+			}
+		}
+		return best
+	}
+}
+
+// sampleFrames picks at most limit frames, spread evenly across the
+// animation so the cap doesn't bias toward the start.
+func sampleFrames(frames []image.Image, limit int) []image.Image {
+	if len(frames) <= limit {
+		return frames
+	}
+	out := make([]image.Image, 0, limit)
+	for i := 0; i < limit; i++ {
+		out = append(out, frames[i*len(frames)/limit])
+	}
+	return out
+}
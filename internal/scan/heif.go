@@ -0,0 +1,64 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// HEIC (iPhone uploads) and AVIF (modern web images) carry HEVC and
+// AV1 payloads no pure-Go decoder handles, so the scanner shells out
+// to a configured converter command — the same bundling tradeoff as
+// the Lottie renderer and the video decoder. The command reads the
+// HEIF container on stdin and writes a jpeg or png raster on stdout,
+// which then flows through the normal decode pipeline.
+
+// heifBrands maps ISO-BMFF ftyp major brands to the format name the
+// scan response reports.
+var heifBrands = map[string]string{
+	"heic": "heic",
+	"heix": "heic",
+	"hevc": "heic",
+	"heim": "heic",
+	"heis": "heic",
+	"mif1": "heic",
+	"msf1": "heic",
+	"avif": "avif",
+	"avis": "avif",
+}
+
+// sniffHEIF identifies HEIC and AVIF containers by their ftyp brand,
+// or "" for anything else. It must run before the video sniffer: both
+// formats share the ISO-BMFF layout mp4 detection keys on.
+func sniffHEIF(data []byte) string {
+	if len(data) < 12 || string(data[4:8]) != "ftyp" {
+		return ""
+	}
+	return heifBrands[string(data[8:12])]
+}
+
+// SetImageConverter wires the external converter for HEIC and AVIF
+// input; empty rejects both formats.
+func (s *Scanner) SetImageConverter(cmd string) { s.imageConverter = cmd }
+
+// convertHEIF runs the configured converter over the container bytes.
+func (s *Scanner) convertHEIF(ctx context.Context, data []byte, format string) ([]byte, error) {
+	if s.imageConverter == "" {
+		return nil, fmt.Errorf("scan: %s input requires a configured converter (NFWS_IMAGE_CONVERTER)", format)
+	}
+	args := strings.Fields(s.imageConverter)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("scan: image converter: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if int64(out.Len()) > s.maxImageBytes {
+		return nil, fmt.Errorf("%w: converted %s over %d bytes", ErrImageTooLarge, format, s.maxImageBytes)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+package scan
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// Lottie stickers are vector animations; scanning them needs a
+// rasterizer. Rather than bundling one, the scanner shells out to a
+// configured renderer command that reads the Lottie JSON (or gzipped
+// .tgs) on stdin and writes a GIF or APNG raster on stdout, which then
+// flows through the normal multi-frame pipeline.
+
+// isLottie sniffs Lottie animation JSON, including the gzipped .tgs
+// wrapping Telegram uses.
+func isLottie(data []byte) bool {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return false
+		}
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(zr, head)
+		zr.Close()
+		data = head[:n]
+	}
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return bytes.HasPrefix(trimmed, []byte("{")) &&
+		bytes.Contains(trimmed, []byte(`"layers"`)) &&
+		bytes.Contains(trimmed, []byte(`"v"`))
+}
+
+// renderLottie runs the configured renderer over the animation bytes.
+func (s *Scanner) renderLottie(ctx context.Context, data []byte) ([]byte, error) {
+	if s.lottieRenderer == "" {
+		return nil, fmt.Errorf("scan: lottie input requires a configured renderer (NFWS_LOTTIE_RENDERER)")
+	}
+	args := strings.Fields(s.lottieRenderer)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("scan: lottie renderer: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if int64(out.Len()) > s.maxImageBytes {
+		return nil, fmt.Errorf("%w: rendered lottie over %d bytes", ErrImageTooLarge, s.maxImageBytes)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,48 @@
+package scan
+
+import (
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/model"
+)
+
+// policyInput assembles the CEL activation for one scan: the overall
+// verdict, the taxonomy categories as {score, flagged} maps, per-model
+// scores, detector boxes, image metadata, and the caller's tenant and
+// surface.
+func (s *Scanner) policyInput(models []*model.Loaded, resp *api.ScanResponse, opts Options) map[string]any {
+	cats := categorize(models, resp.Models, resp.Threshold)
+	category := func(name string) map[string]any {
+		c := cats[name]
+		return map[string]any{"score": c.Score, "flagged": c.Flagged}
+	}
+	modelScores := make(map[string]float64, len(resp.Models))
+	var detections []map[string]any
+	for name, m := range resp.Models {
+		modelScores[name] = m.NSFWScore
+		for _, d := range m.Detections {
+			detections = append(detections, map[string]any{
+				"model": name,
+				"label": d.Label,
+				"score": d.Score,
+			})
+		}
+	}
+	return map[string]any{
+		"nsfw":       resp.NSFW,
+		"score":      resp.Score,
+		"threshold":  resp.Threshold,
+		"severity":   resp.Severity,
+		"format":     resp.Format,
+		"width":      resp.Width,
+		"height":     resp.Height,
+		"tenant":     opts.Tenant,
+		"surface":    opts.Surface,
+		"models":     modelScores,
+		"detections": detections,
+		"nudity":     category(model.CategoryNudity),
+		"hentai":     category(model.CategoryHentai),
+		"suggestive": category(model.CategorySuggestive),
+		"drawing":    category(model.CategoryDrawing),
+		"neutral":    category(model.CategoryNeutral),
+	}
+}
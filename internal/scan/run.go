@@ -0,0 +1,193 @@
+package scan
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/imageproc"
+	"github.com/illussioon/NFWS-Moderations-API/internal/model"
+)
+
+// detectionThreshold is the minimum confidence for a detector box to be
+// reported at all; the NSFW verdict still applies the request threshold on
+// top of this.
+const detectionThreshold = 0.25
+
+// nmsIOU is the intersection-over-union above which overlapping boxes of
+// the same class are merged during non-maximum suppression.
+const nmsIOU = 0.45
+
+// runModel executes one model on a decoded image. When timings is
+// non-nil the per-stage wall times are accumulated into it.
+func (s *Scanner) runModel(ctx context.Context, m *model.Loaded, img image.Image, timings *api.Timings) (api.ModelResult, error) {
+	if err := s.chaos.ModelFailure(); err != nil {
+		return api.ModelResult{}, err
+	}
+	preStart := time.Now()
+	tensor, shape := imageproc.Tensorize(img, m.Spec)
+	preElapsed := time.Since(preStart)
+	digest := ""
+	if s.reproducible {
+		digest = tensorDigest(tensor)
+	}
+
+	start := time.Now()
+	outputs, err := m.Session.Run(ctx, tensor, shape)
+	elapsed := time.Since(start)
+	if err != nil {
+		return api.ModelResult{}, err
+	}
+	if len(outputs) == 0 {
+		return api.ModelResult{}, fmt.Errorf("model produced no outputs")
+	}
+
+	result := api.ModelResult{
+		Kind:         string(m.Spec.Kind),
+		DurationMS:   millis(elapsed),
+		TensorSHA256: digest,
+	}
+	postStart := time.Now()
+	switch m.Spec.Kind {
+	case model.KindClassifier:
+		s.fillClassifier(&result, m.Spec, outputs[0])
+	case model.KindDetector:
+		s.fillDetector(&result, m.Spec, outputs[0], img.Bounds())
+	default:
+		return api.ModelResult{}, fmt.Errorf("unknown model kind %q", m.Spec.Kind)
+	}
+	if timings != nil {
+		timings.PreprocessMS += millis(preElapsed)
+		timings.InferenceMS += millis(elapsed)
+		timings.PostprocessMS += millis(time.Since(postStart))
+	}
+	return result, nil
+}
+
+// millis converts a duration to fractional milliseconds for responses.
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// tensorDigest hashes the preprocessed input tensor bit-exactly
+// (little-endian float32), the ground truth a reproducibility replay
+// compares against.
+func tensorDigest(tensor []float32) string {
+	h := sha256.New()
+	var buf [4]byte
+	for _, v := range tensor {
+		binary.LittleEndian.PutUint32(buf[:], math.Float32bits(v))
+		h.Write(buf[:])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *Scanner) fillClassifier(result *api.ModelResult, spec model.Spec, raw []float32) {
+	probs := raw
+	if len(probs) > len(spec.Classes) {
+		probs = probs[:len(spec.Classes)]
+	}
+	probs = model.Softmax(append([]float32(nil), probs...))
+	result.Scores = make(map[string]float64, len(spec.Classes))
+	for i, label := range spec.Classes {
+		if i >= len(probs) {
+			break
+		}
+		p := float64(probs[i])
+		result.Scores[label] = p
+		if spec.IsNSFWClass(label) && p > result.NSFWScore {
+			result.NSFWScore = p
+		}
+	}
+}
+
+// fillDetector decodes a YOLO-style output laid out as [1, 4+nc, boxes]
+// (cx, cy, w, h followed by per-class confidences), scales the boxes back
+// to the original image, and applies per-class NMS.
+func (s *Scanner) fillDetector(result *api.ModelResult, spec model.Spec, raw []float32, bounds image.Rectangle) {
+	nc := len(spec.Classes)
+	stride := 4 + nc
+	boxes := len(raw) / stride
+	if boxes == 0 {
+		return
+	}
+	// The runtime flattens [1, stride, boxes]; attribute i of box j lives
+	// at raw[i*boxes+j].
+	at := func(attr, box int) float32 { return raw[attr*boxes+box] }
+
+	sx := float64(bounds.Dx()) / float64(spec.InputSize)
+	sy := float64(bounds.Dy()) / float64(spec.InputSize)
+
+	var dets []api.Detection
+	for j := 0; j < boxes; j++ {
+		best, bestClass := float32(0), -1
+		for c := 0; c < nc; c++ {
+			if v := at(4+c, j); v > best {
+				best, bestClass = v, c
+			}
+		}
+		if bestClass < 0 || float64(best) < detectionThreshold {
+			continue
+		}
+		cx, cy := float64(at(0, j)), float64(at(1, j))
+		w, h := float64(at(2, j)), float64(at(3, j))
+		dets = append(dets, api.Detection{
+			Label: spec.Classes[bestClass],
+			Score: float64(best),
+			Box: [4]int{
+				int((cx - w/2) * sx),
+				int((cy - h/2) * sy),
+				int(w * sx),
+				int(h * sy),
+			},
+		})
+	}
+	dets = nonMaxSuppress(dets)
+	result.Detections = dets
+	for _, d := range dets {
+		if spec.IsNSFWClass(d.Label) && d.Score > result.NSFWScore {
+			result.NSFWScore = d.Score
+		}
+	}
+}
+
+func nonMaxSuppress(dets []api.Detection) []api.Detection {
+	sort.Slice(dets, func(i, j int) bool { return dets[i].Score > dets[j].Score })
+	var kept []api.Detection
+	for _, d := range dets {
+		suppressed := false
+		for _, k := range kept {
+			if k.Label == d.Label && iou(k.Box, d.Box) > nmsIOU {
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+func iou(a, b [4]int) float64 {
+	ax1, ay1, ax2, ay2 := a[0], a[1], a[0]+a[2], a[1]+a[3]
+	bx1, by1, bx2, by2 := b[0], b[1], b[0]+b[2], b[1]+b[3]
+	ix := min(ax2, bx2) - max(ax1, bx1)
+	iy := min(ay2, by2) - max(ay1, by1)
+	if ix <= 0 || iy <= 0 {
+		return 0
+	}
+	inter := float64(ix * iy)
+	union := float64(a[2]*a[3]+b[2]*b[3]) - inter
+	if union <= 0 {
+		return 0
+	}
+	return inter / union
+}
@@ -0,0 +1,598 @@
+// Package scan orchestrates fetching, decoding, and running images through
+// the loaded models to produce a verdict.
+package scan
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/chaos"
+	"github.com/illussioon/NFWS-Moderations-API/internal/imageproc"
+	"github.com/illussioon/NFWS-Moderations-API/internal/labels"
+	"github.com/illussioon/NFWS-Moderations-API/internal/model"
+	"github.com/illussioon/NFWS-Moderations-API/internal/policy"
+)
+
+// Sentinel errors for the failure classes callers map onto transport
+// codes with errors.Is. Returned errors wrap these with the specifics.
+var (
+	// ErrModelNotFound reports a request naming a model the registry
+	// doesn't know.
+	ErrModelNotFound = errors.New("scan: unknown model")
+	// ErrImageTooLarge reports input over the byte limit.
+	ErrImageTooLarge = errors.New("scan: image exceeds size limit")
+	// ErrDecode reports bytes no image decoder recognized.
+	ErrDecode = errors.New("scan: undecodable image")
+	// ErrInferenceTimeout reports a model run cut short by the request
+	// deadline.
+	ErrInferenceTimeout = errors.New("scan: inference timed out")
+)
+
+// Scanner runs images through the model registry.
+type Scanner struct {
+	registry       *model.Registry
+	client         *http.Client
+	maxImageBytes  int64
+	threshold      float64
+	adultThreshold float64
+	chaos          *chaos.Injector
+	labels         labels.Table
+	thumbSize      int
+	thumbBlur      int
+	ignoreMargin   float64
+	cascadeMargin  float64
+	lottieRenderer string
+	imageConverter string
+	ffmpeg         string
+	videoFPS       float64
+	policy         *policy.Set
+	reproducible   bool
+}
+
+// SetChaos wires a fault injector into the model-run path. A nil injector
+// (the default) injects nothing.
+func (s *Scanner) SetChaos(inj *chaos.Injector) { s.chaos = inj }
+
+// SetLabels wires a label-mapping table into responses. A nil table (the
+// default) leaves raw class names untouched.
+func (s *Scanner) SetLabels(t labels.Table) { s.labels = t }
+
+// SetReproducible turns on reproducibility mode: every model result
+// carries the digest of its preprocessed input tensor, so disputed
+// verdicts can later be replayed and proven bit-identical.
+func (s *Scanner) SetReproducible(on bool) { s.reproducible = on }
+
+// SetThumbnailParams overrides the size and blur radius of the previews
+// generated for flagged scans.
+func (s *Scanner) SetThumbnailParams(size, blur int) { s.thumbSize, s.thumbBlur = size, blur }
+
+// SetIgnoreMargin excludes frac of each image edge from scoring, so
+// border watermarks can't trip the classifiers. frac must be in [0,0.5).
+func (s *Scanner) SetIgnoreMargin(frac float64) { s.ignoreMargin = frac }
+
+// SetLottieRenderer configures the external command that rasterizes
+// Lottie/.tgs animations into a scannable GIF or APNG.
+func (s *Scanner) SetLottieRenderer(cmd string) { s.lottieRenderer = cmd }
+
+// SetAdultThreshold overrides the flagging threshold for the
+// adult-verified surface of age-gated scans.
+func (s *Scanner) SetAdultThreshold(t float64) { s.adultThreshold = t }
+
+// SetCascadeMargin sets the half-width of the uncertain band around the
+// threshold inside which cascade scans invoke the detector stage.
+func (s *Scanner) SetCascadeMargin(m float64) { s.cascadeMargin = m }
+
+// SetPolicy wires a compiled CEL rule set into verdict computation. A
+// nil set (the default) leaves verdicts purely threshold-based.
+func (s *Scanner) SetPolicy(p *policy.Set) { s.policy = p }
+
+// New creates a Scanner.
+func New(registry *model.Registry, downloadTimeout time.Duration, maxImageBytes int64, threshold float64) *Scanner {
+	return &Scanner{
+		registry:       registry,
+		client:         &http.Client{Timeout: downloadTimeout},
+		maxImageBytes:  maxImageBytes,
+		threshold:      threshold,
+		adultThreshold: 0.9,
+		cascadeMargin:  0.15,
+		thumbSize:      128,
+		thumbBlur:      12,
+	}
+}
+
+// Registry exposes the scanner's model registry.
+func (s *Scanner) Registry() *model.Registry { return s.registry }
+
+// Threshold returns the server-wide default NSFW threshold.
+func (s *Scanner) Threshold() float64 { return s.threshold }
+
+// Fetch downloads an image URL, enforcing the configured size cap.
+func (s *Scanner) Fetch(ctx context.Context, url string) ([]byte, error) {
+	return s.FetchLimit(ctx, url, s.maxImageBytes)
+}
+
+// FetchLimit downloads an image URL with an explicit size cap, for
+// callers whose limit differs from the server-wide one.
+func (s *Scanner) FetchLimit(ctx context.Context, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("scan: building download request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("scan: downloading image: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scan: downloading image: unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("scan: reading image body: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w of %d bytes", ErrImageTooLarge, limit)
+	}
+	return data, nil
+}
+
+// Options tunes a single scan.
+type Options struct {
+	// Models restricts the run to these model names; empty means all.
+	Models []string
+	// Threshold overrides the server default when > 0.
+	Threshold float64
+	// Debug attaches the per-stage timings breakdown to the response.
+	Debug bool
+	// Schema selects the response shape; api.SchemaV2 adds the
+	// categorized breakdown.
+	Schema string
+	// Lang requests localized detection display names.
+	Lang string
+	// Aggregation folds per-frame scores for animated inputs; see the
+	// Agg constants. Empty means AggMax.
+	Aggregation string
+	// AggregationK is the k for AggTopK and AggStreak; 0 means the
+	// default.
+	AggregationK int
+	// Thumbnail attaches a blurred preview when the scan is flagged.
+	Thumbnail bool
+	// Hashes attaches perceptual hashes to the response: PDQ always,
+	// plus TMK for multi-frame inputs.
+	Hashes bool
+	// AgeGate adds per-surface verdicts for the general-audience and
+	// adult-verified thresholds.
+	AgeGate bool
+	// AdultThreshold overrides the server's adult-surface threshold
+	// when > 0.
+	AdultThreshold float64
+	// Compat adds a compatibility block; see CompatSafeSearch.
+	Compat string
+	// Tenant is the calling key's name, exposed to policy rules.
+	Tenant string
+	// Surface is the caller-declared destination surface, exposed to
+	// policy rules.
+	Surface string
+	// OnFrame, when set, is called with each frame's score as it is
+	// computed, in frame order; skipped marks near-duplicate frames that
+	// reused an earlier score. Returning an error aborts the scan. The
+	// streaming video endpoint uses this to emit per-frame records.
+	OnFrame func(index int, score float64, skipped bool) error
+	// StopOnFirstHit ends a multi-frame scan at the first frame scoring
+	// at or above the threshold, leaving the remaining frames unscanned.
+	StopOnFirstHit bool
+	// Cascade runs classifiers first and invokes detectors only when the
+	// classifier score lands inside the uncertain band around the
+	// threshold, trading a little sensitivity for a lot of cost.
+	Cascade bool
+}
+
+// ScanBytes decodes data and runs it through the selected models. For
+// animated inputs every (sampled) frame is scanned and the per-frame
+// scores fold into the overall score via the requested aggregation.
+func (s *Scanner) ScanBytes(ctx context.Context, data []byte, opts Options) (*api.ScanResponse, error) {
+	if !ValidAggregation(opts.Aggregation) {
+		return nil, fmt.Errorf("scan: unknown aggregation %q", opts.Aggregation)
+	}
+	var timings *api.Timings
+	if opts.Debug {
+		timings = &api.Timings{}
+	}
+	decodeStart := time.Now()
+	if isLottie(data) {
+		rendered, err := s.renderLottie(ctx, data)
+		if err != nil {
+			return nil, err
+		}
+		data = rendered
+	}
+	heif := sniffHEIF(data)
+	if heif != "" {
+		converted, err := s.convertHEIF(ctx, data, heif)
+		if err != nil {
+			return nil, err
+		}
+		data = converted
+	}
+	var frames []image.Image
+	var format string
+	var err error
+	container := sniffVideo(data)
+	if container != "" {
+		if frames, err = s.decodeVideoFrames(ctx, data); err != nil {
+			return nil, err
+		}
+		format = container
+	} else if frames, format, err = imageproc.DecodeFrames(data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecode, err)
+	}
+	if heif != "" {
+		// Report the format the client actually sent, not what the
+		// converter emitted.
+		format = heif
+	}
+	if timings != nil {
+		timings.DecodeMS = millis(time.Since(decodeStart))
+	}
+	threshold := s.threshold
+	if opts.Threshold > 0 {
+		threshold = opts.Threshold
+	}
+
+	models, err := s.selectModels(opts.Models)
+	if err != nil {
+		return nil, err
+	}
+	// The cascade splits the run: classifiers first, detectors only for
+	// frames whose classifier score lands inside the uncertain band
+	// around the threshold. With only one kind selected there is nothing
+	// to gate and the scan runs as usual.
+	var stage1, stage2 []*model.Loaded
+	if opts.Cascade {
+		for _, m := range models {
+			if m.Spec.Kind == model.KindDetector {
+				stage2 = append(stage2, m)
+			} else {
+				stage1 = append(stage1, m)
+			}
+		}
+		if len(stage1) == 0 || len(stage2) == 0 {
+			stage1, stage2 = models, nil
+		}
+	}
+	detectorRan := false
+
+	frames = sampleFrames(frames, maxFrames)
+	bounds := frames[0].Bounds()
+	// Perceptual hashes are computed before the margin crop: they
+	// describe the content as submitted, not as scored.
+	var phashes *api.PerceptualHashes
+	if opts.Hashes {
+		pdq := imageproc.PDQ(frames[0])
+		phashes = &api.PerceptualHashes{PDQ: hex.EncodeToString(pdq[:])}
+		if len(frames) > 1 {
+			tmk := imageproc.TMK(frames)
+			phashes.TMK = hex.EncodeToString(tmk[:])
+		}
+	}
+	// Crop away the ignored margin before scoring; detection boxes are
+	// shifted back to full-image coordinates afterwards.
+	offX, offY := 0, 0
+	if s.ignoreMargin > 0 {
+		offX = int(float64(bounds.Dx()) * s.ignoreMargin)
+		offY = int(float64(bounds.Dy()) * s.ignoreMargin)
+		inset := image.Rect(bounds.Min.X+offX, bounds.Min.Y+offY, bounds.Max.X-offX, bounds.Max.Y-offY)
+		if !inset.Empty() {
+			for i := range frames {
+				frames[i] = imageproc.Crop(frames[i], inset)
+			}
+		} else {
+			offX, offY = 0, 0
+		}
+	}
+	resp := &api.ScanResponse{
+		Threshold: threshold,
+		Format:    format,
+		Width:     bounds.Dx(),
+		Height:    bounds.Dy(),
+		Timings:   timings,
+		Hashes:    phashes,
+	}
+	frameScores := make([]float64, len(frames))
+	bestScore := -1.0
+	bestFrame := frames[0]
+	bestIdx := 0
+	skipped := 0
+	stopped := false
+	seen := make(map[uint64]int, len(frames))
+	prev, prevHash := -1, uint64(0)
+	for i, frame := range frames {
+		if len(frames) > 1 {
+			hash := imageproc.AverageHash(frame)
+			if j := dupeOf(hash, seen, prev, prevHash); j >= 0 {
+				frameScores[i] = frameScores[j]
+				skipped++
+				prev, prevHash = j, hash
+				if opts.OnFrame != nil {
+					if err := opts.OnFrame(i, frameScores[i], true); err != nil {
+						return nil, err
+					}
+				}
+				continue
+			}
+			seen[hash] = i
+			prev, prevHash = i, hash
+		}
+		var results map[string]api.ModelResult
+		var frameScore float64
+		if opts.Cascade {
+			results, frameScore, err = s.runModels(ctx, stage1, frame, timings)
+			if err == nil && len(stage2) > 0 && frameScore >= threshold-s.cascadeMargin && frameScore < threshold+s.cascadeMargin {
+				var detResults map[string]api.ModelResult
+				var detScore float64
+				detResults, detScore, err = s.runModels(ctx, stage2, frame, timings)
+				for name, result := range detResults {
+					results[name] = result
+				}
+				if detScore > frameScore {
+					frameScore = detScore
+				}
+				detectorRan = true
+			}
+		} else {
+			results, frameScore, err = s.runModels(ctx, models, frame, timings)
+		}
+		if err != nil {
+			return nil, err
+		}
+		frameScores[i] = frameScore
+		// The highest-scoring frame's results represent the animation.
+		if frameScore > bestScore {
+			bestScore = frameScore
+			bestFrame = frame
+			bestIdx = i
+			resp.Models = results
+		}
+		if opts.OnFrame != nil {
+			if err := opts.OnFrame(i, frameScore, false); err != nil {
+				return nil, err
+			}
+		}
+		if opts.StopOnFirstHit && frameScore >= threshold {
+			frameScores = frameScores[:i+1]
+			stopped = i+1 < len(frames)
+			break
+		}
+	}
+	if len(frames) > 1 {
+		strategy := opts.Aggregation
+		if strategy == "" {
+			strategy = AggMax
+		}
+		resp.Score = aggregate(frameScores, strategy, opts.AggregationK)
+		resp.Frames = &api.FrameStats{
+			Count:       len(frameScores),
+			Skipped:     skipped,
+			Aggregation: strategy,
+			Scores:      frameScores,
+			TopFrame:    bestIdx,
+			Stopped:     stopped,
+		}
+	} else {
+		resp.Score = frameScores[0]
+	}
+	if container != "" && s.videoFPS > 0 {
+		resp.Segments = nsfwSegments(frameScores, threshold, s.videoFPS)
+	}
+	if offX > 0 || offY > 0 {
+		for name, result := range resp.Models {
+			for i := range result.Detections {
+				result.Detections[i].Box[0] += offX
+				result.Detections[i].Box[1] += offY
+			}
+			resp.Models[name] = result
+		}
+	}
+	if opts.Cascade {
+		resp.Stages = []string{string(model.KindClassifier)}
+		if detectorRan {
+			resp.Stages = append(resp.Stages, string(model.KindDetector))
+		}
+	}
+	resp.NSFW = resp.Score >= threshold
+	resp.Severity = maxSeverity(models, resp.Models, threshold)
+	if s.policy != nil {
+		fired, err := s.policy.Eval(s.policyInput(models, resp, opts))
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		if len(fired) > 0 {
+			resp.NSFW = true
+			resp.Policies = fired
+		}
+	}
+	if resp.NSFW {
+		resp.Explanations = explain(models, resp, threshold)
+	}
+	if opts.AgeGate {
+		adult := s.adultThreshold
+		if opts.AdultThreshold > 0 {
+			adult = opts.AdultThreshold
+		}
+		resp.Surfaces = map[string]api.SurfaceVerdict{
+			"general": {NSFW: resp.NSFW, Threshold: threshold, Severity: resp.Severity},
+			"adult":   {NSFW: resp.Score >= adult, Threshold: adult, Severity: maxSeverity(models, resp.Models, adult)},
+		}
+	}
+	if opts.Thumbnail && resp.NSFW {
+		thumb, err := imageproc.Thumbnail(bestFrame, s.thumbSize, s.thumbBlur)
+		if err != nil {
+			return nil, err
+		}
+		resp.ThumbnailBase64 = base64.StdEncoding.EncodeToString(thumb)
+	}
+	if opts.Schema == api.SchemaV2 {
+		resp.Categories = categorize(models, resp.Models, threshold)
+	}
+	switch opts.Compat {
+	case CompatSafeSearch:
+		resp.SafeSearch = safeSearch(models, resp.Models)
+	case CompatRekognition:
+		resp.Rekognition = rekognition(models, resp.Models, s.registry.Fingerprint())
+	}
+	// Label mapping runs last so verdicts and categories always see the
+	// raw class names.
+	if s.labels != nil {
+		for name, result := range resp.Models {
+			s.labels.Apply(name, &result, opts.Lang)
+			resp.Models[name] = result
+		}
+	}
+	return resp, nil
+}
+
+// runModels runs one decoded frame through every selected model,
+// returning the per-model results and the frame's overall score (the
+// highest NSFW-relevant score across models).
+func (s *Scanner) runModels(ctx context.Context, models []*model.Loaded, img image.Image, timings *api.Timings) (map[string]api.ModelResult, float64, error) {
+	results := make(map[string]api.ModelResult, len(models))
+	var score float64
+	for _, m := range models {
+		result, err := s.runModel(ctx, m, img, timings)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = fmt.Errorf("%w: %v", ErrInferenceTimeout, err)
+			}
+			return nil, 0, fmt.Errorf("scan: model %s: %w", m.Spec.Name, err)
+		}
+		results[m.Spec.Name] = result
+		if result.NSFWScore > score {
+			score = result.NSFWScore
+		}
+	}
+	return results, score, nil
+}
+
+// maxSeverity returns the most explicit severity tier among classes and
+// detections that scored at or above the threshold, or "" when none did.
+func maxSeverity(models []*model.Loaded, results map[string]api.ModelResult, threshold float64) string {
+	var best string
+	consider := func(spec model.Spec, label string, score float64) {
+		if score < threshold {
+			return
+		}
+		if tier := spec.SeverityOf(label); model.SeverityRank(tier) > model.SeverityRank(best) {
+			best = tier
+		}
+	}
+	for _, m := range models {
+		result, ok := results[m.Spec.Name]
+		if !ok {
+			continue
+		}
+		for label, score := range result.Scores {
+			consider(m.Spec, label, score)
+		}
+		for _, d := range result.Detections {
+			consider(m.Spec, d.Label, d.Score)
+		}
+	}
+	return best
+}
+
+// categorize folds per-model class scores into the shared taxonomy for
+// schema v2 responses: each category carries the highest score any model
+// assigned to one of its classes.
+func categorize(models []*model.Loaded, results map[string]api.ModelResult, threshold float64) map[string]api.CategoryResult {
+	out := make(map[string]api.CategoryResult)
+	record := func(spec model.Spec, label string, score float64) {
+		cat := spec.CategoryOf(label)
+		if cur, ok := out[cat]; !ok || score > cur.Score {
+			out[cat] = api.CategoryResult{
+				Score:   score,
+				Flagged: cat != model.CategoryNeutral && score >= threshold,
+			}
+		}
+	}
+	for _, m := range models {
+		result, ok := results[m.Spec.Name]
+		if !ok {
+			continue
+		}
+		for label, score := range result.Scores {
+			record(m.Spec, label, score)
+		}
+		for _, d := range result.Detections {
+			record(m.Spec, d.Label, d.Score)
+		}
+	}
+	return out
+}
+
+// selectModels resolves requested model names, honoring name@version
+// pins; bare names get the registry's active version.
+func (s *Scanner) selectModels(names []string) ([]*model.Loaded, error) {
+	if len(names) == 0 {
+		all := s.registry.All()
+		out := make([]*model.Loaded, 0, len(all))
+		for _, m := range all {
+			// A disabled model drops out of the default set when its
+			// maintenance allows fallback; otherwise the scan fails so
+			// callers see the outage instead of silently thinner results.
+			if disabled, fallback := s.registry.Maintenance(m.Spec.Name); disabled {
+				if fallback {
+					continue
+				}
+				return nil, &model.MaintenanceError{Name: m.Spec.Name}
+			}
+			out = append(out, m)
+		}
+		if len(out) == 0 {
+			return nil, fmt.Errorf("scan: no models loaded")
+		}
+		return out, nil
+	}
+	out := make([]*model.Loaded, 0, len(names))
+	for _, n := range names {
+		name, version, pinned := strings.Cut(n, "@")
+		if disabled, _ := s.registry.Maintenance(name); disabled {
+			// Explicitly requesting a disabled model always errors;
+			// fallback only applies to the default set.
+			return nil, &model.MaintenanceError{Name: name}
+		}
+		if pinned {
+			m, err := s.registry.GetVersion(name, version)
+			if err != nil {
+				return nil, fmt.Errorf("scan: %w", err)
+			}
+			out = append(out, m)
+			continue
+		}
+		m, ok := s.registry.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("%w %q", ErrModelNotFound, name)
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// RunModel runs a single already-decoded image through one model. It is
+// exported for the admin benchmark and self-test paths, which need
+// per-model runs without verdict aggregation.
+func (s *Scanner) RunModel(ctx context.Context, m *model.Loaded, data []byte) (api.ModelResult, error) {
+	img, _, err := imageproc.Decode(data)
+	if err != nil {
+		return api.ModelResult{}, err
+	}
+	return s.runModel(ctx, m, img, nil)
+}
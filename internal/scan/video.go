@@ -0,0 +1,115 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/imageproc"
+)
+
+// Video containers are decoded by shelling out to a configured ffmpeg
+// binary, the same bundling tradeoff as the Lottie renderer: the
+// command reads the container on stdin and writes an MJPEG stream of
+// frames sampled at the configured rate on stdout. Each sampled frame
+// then flows through the normal multi-frame pipeline, and runs of
+// flagged frames come back as timestamped segments.
+
+// jpegSOI starts every JPEG in the MJPEG stream ffmpeg emits.
+var jpegSOI = []byte{0xff, 0xd8, 0xff}
+
+// sniffVideo identifies the common video containers, or "" for
+// anything else.
+func sniffVideo(data []byte) string {
+	switch {
+	case len(data) >= 12 && string(data[4:8]) == "ftyp":
+		return "mp4"
+	case len(data) >= 4 && data[0] == 0x1a && data[1] == 0x45 && data[2] == 0xdf && data[3] == 0xa3:
+		return "webm"
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "AVI ":
+		return "avi"
+	}
+	return ""
+}
+
+// SetVideoDecoder wires the external video decoder: the ffmpeg command
+// to run (empty rejects video input) and the frame sampling rate in
+// frames per second.
+func (s *Scanner) SetVideoDecoder(ffmpeg string, fps float64) {
+	s.ffmpeg, s.videoFPS = ffmpeg, fps
+}
+
+// decodeVideoFrames runs the configured decoder over the container
+// bytes and returns the sampled frames. The decoder itself caps the
+// frame count, so timestamps stay a plain index/fps mapping.
+func (s *Scanner) decodeVideoFrames(ctx context.Context, data []byte) ([]image.Image, error) {
+	if s.ffmpeg == "" {
+		return nil, fmt.Errorf("scan: video input requires a configured decoder (NFWS_VIDEO_FFMPEG)")
+	}
+	args := append(strings.Fields(s.ffmpeg),
+		"-hide_banner", "-loglevel", "error",
+		"-i", "pipe:0",
+		"-vf", "fps="+strconv.FormatFloat(s.videoFPS, 'f', -1, 64),
+		"-frames:v", strconv.Itoa(maxFrames),
+		"-f", "image2pipe", "-c:v", "mjpeg", "pipe:1")
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("scan: video decoder: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	var frames []image.Image
+	stream := out.Bytes()
+	for len(stream) > 0 {
+		start := bytes.Index(stream, jpegSOI)
+		if start < 0 {
+			break
+		}
+		next := bytes.Index(stream[start+len(jpegSOI):], jpegSOI)
+		chunk := stream[start:]
+		if next >= 0 {
+			chunk = stream[start : start+len(jpegSOI)+next]
+			stream = stream[start+len(jpegSOI)+next:]
+		} else {
+			stream = nil
+		}
+		frame, _, err := imageproc.Decode(chunk)
+		if err != nil {
+			return nil, fmt.Errorf("scan: decoding video frame %d: %w", len(frames), err)
+		}
+		frames = append(frames, frame)
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("scan: video decoder produced no frames")
+	}
+	return frames, nil
+}
+
+// nsfwSegments folds per-frame scores into contiguous runs at or above
+// the threshold, stamped with start/end times from the sampling rate.
+func nsfwSegments(scores []float64, threshold, fps float64) []api.Segment {
+	var segs []api.Segment
+	for i := 0; i < len(scores); {
+		if scores[i] < threshold {
+			i++
+			continue
+		}
+		seg := api.Segment{StartS: float64(i) / fps, Peak: scores[i]}
+		for i < len(scores) && scores[i] >= threshold {
+			if scores[i] > seg.Peak {
+				seg.Peak = scores[i]
+			}
+			i++
+		}
+		seg.EndS = float64(i) / fps
+		segs = append(segs, seg)
+	}
+	return segs
+}
@@ -0,0 +1,167 @@
+// Package seal encrypts persisted payloads — thumbnails, inline dataset
+// images, and any future stored artifacts — so only ciphertext ever
+// reaches the store or object bucket.
+//
+// Envelopes are AES-256-GCM and self-describing: a text prefix, the ID
+// of the key that sealed them, and the base64 nonce-plus-ciphertext.
+// The sealer holds every configured key for opening but seals with a
+// single active one, so rotation is: add the new key, flip the active
+// ID, and reseal (or age out) payloads still under the old key.
+package seal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// prefix marks a sealed envelope; anything without it is plaintext.
+const prefix = "nfws-sealed:v1:"
+
+// Sealer seals and opens payload envelopes. A nil Sealer is valid and
+// passes payloads through unchanged, so callers need no guards when
+// sealing is not configured.
+type Sealer struct {
+	keys   map[string]cipher.AEAD
+	active string
+}
+
+// New builds a Sealer from keys mapping key IDs to 64-character hex
+// AES-256 key material, sealing with the active ID. With a single key
+// active may be empty; with several it must name one of them. Returns
+// nil when no keys are configured.
+func New(keys map[string]string, active string) (*Sealer, error) {
+	if len(keys) == 0 {
+		if active != "" {
+			return nil, fmt.Errorf("seal: active key %q set but no keys configured", active)
+		}
+		return nil, nil
+	}
+	s := &Sealer{keys: make(map[string]cipher.AEAD, len(keys)), active: active}
+	for id, material := range keys {
+		if strings.Contains(id, ":") {
+			return nil, fmt.Errorf("seal: key ID %q must not contain %q", id, ":")
+		}
+		raw, err := hex.DecodeString(material)
+		if err != nil || len(raw) != 32 {
+			return nil, fmt.Errorf("seal: key %q must be 64 hex characters (32 bytes)", id)
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, fmt.Errorf("seal: key %q: %w", id, err)
+		}
+		if s.keys[id], err = cipher.NewGCM(block); err != nil {
+			return nil, fmt.Errorf("seal: key %q: %w", id, err)
+		}
+	}
+	if s.active == "" {
+		if len(keys) > 1 {
+			ids := make([]string, 0, len(keys))
+			for id := range keys {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			return nil, fmt.Errorf("seal: several keys configured (%s); the active key must be named", strings.Join(ids, ", "))
+		}
+		for id := range keys {
+			s.active = id
+		}
+	}
+	if _, ok := s.keys[s.active]; !ok {
+		return nil, fmt.Errorf("seal: active key %q is not among the configured keys", s.active)
+	}
+	return s, nil
+}
+
+// Active returns the ID of the key new envelopes are sealed with.
+func (s *Sealer) Active() string {
+	if s == nil {
+		return ""
+	}
+	return s.active
+}
+
+// Sealed reports whether data is a sealed envelope.
+func Sealed(data []byte) bool {
+	return strings.HasPrefix(string(data), prefix)
+}
+
+// KeyID returns the ID of the key an envelope was sealed with, and
+// false for plaintext.
+func KeyID(data []byte) (string, bool) {
+	rest, ok := strings.CutPrefix(string(data), prefix)
+	if !ok {
+		return "", false
+	}
+	id, _, ok := strings.Cut(rest, ":")
+	return id, ok
+}
+
+// Seal encrypts plain under the active key. On a nil Sealer it returns
+// plain unchanged.
+func (s *Sealer) Seal(plain []byte) ([]byte, error) {
+	if s == nil {
+		return plain, nil
+	}
+	aead := s.keys[s.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("seal: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, plain, nil)
+	return []byte(prefix + s.active + ":" + base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// Open decrypts a sealed envelope with the key its ID names. Plaintext
+// input passes through unchanged, so stores written before sealing was
+// enabled stay readable. A nil Sealer opens plaintext only.
+func (s *Sealer) Open(data []byte) ([]byte, error) {
+	rest, ok := strings.CutPrefix(string(data), prefix)
+	if !ok {
+		return data, nil
+	}
+	id, body, ok := strings.Cut(rest, ":")
+	if !ok {
+		return nil, fmt.Errorf("seal: malformed envelope")
+	}
+	if s == nil {
+		return nil, fmt.Errorf("seal: payload sealed with key %q but sealing is not configured", id)
+	}
+	aead, ok := s.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("seal: payload sealed with unknown key %q", id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(body)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("seal: malformed envelope")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("seal: opening payload under key %q: %w", id, err)
+	}
+	return plain, nil
+}
+
+// Reseal re-encrypts an envelope sealed under a stale key with the
+// active one. It reports whether data changed: plaintext and envelopes
+// already under the active key come back as-is.
+func (s *Sealer) Reseal(data []byte) ([]byte, bool, error) {
+	id, ok := KeyID(data)
+	if !ok || s == nil || id == s.active {
+		return data, false, nil
+	}
+	plain, err := s.Open(data)
+	if err != nil {
+		return nil, false, err
+	}
+	sealed, err := s.Seal(plain)
+	if err != nil {
+		return nil, false, err
+	}
+	return sealed, true, nil
+}
@@ -0,0 +1,93 @@
+// Package selftest runs embedded reference images through every loaded
+// model and checks that scores land in expected ranges. It exists to catch
+// broken preprocessing after model or runtime upgrades, not to measure
+// model quality.
+package selftest
+
+import (
+	"context"
+	"embed"
+	"fmt"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/scan"
+)
+
+//go:embed testdata/*.png
+var testdata embed.FS
+
+// Case is one reference image with the score range every model is
+// expected to produce for it.
+type Case struct {
+	// Name identifies the case in reports.
+	Name string
+	// File is the embedded image path.
+	File string
+	// MinNSFW and MaxNSFW bound the acceptable nsfw score. The ranges
+	// are deliberately wide: the point is catching a broken pipeline
+	// (scores pinned to 0, 1, or NaN), not grading the models.
+	MinNSFW float64
+	MaxNSFW float64
+}
+
+// cases lists the embedded reference images. The synthetic skin pattern
+// is NSFW-like in color statistics only; no model is expected to be
+// confident about it, so its upper bound stays open.
+var cases = []Case{
+	{Name: "safe_gradient", File: "testdata/safe_gradient.png", MinNSFW: 0, MaxNSFW: 0.6},
+	{Name: "safe_blocks", File: "testdata/safe_blocks.png", MinNSFW: 0, MaxNSFW: 0.6},
+	{Name: "skin_pattern", File: "testdata/skin_pattern.png", MinNSFW: 0, MaxNSFW: 1},
+}
+
+// CheckResult is the outcome for one model/image pair.
+type CheckResult struct {
+	Model      string  `json:"model"`
+	Case       string  `json:"case"`
+	NSFWScore  float64 `json:"nsfw_score"`
+	MinNSFW    float64 `json:"min_nsfw"`
+	MaxNSFW    float64 `json:"max_nsfw"`
+	Passed     bool    `json:"passed"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// Report is the full self-test outcome.
+type Report struct {
+	Passed bool          `json:"passed"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Run executes every case against every loaded model.
+func Run(ctx context.Context, s *scan.Scanner) (*Report, error) {
+	report := &Report{Passed: true}
+	models := s.Registry().All()
+	if len(models) == 0 {
+		return nil, fmt.Errorf("selftest: no models loaded")
+	}
+	for _, c := range cases {
+		data, err := testdata.ReadFile(c.File)
+		if err != nil {
+			return nil, fmt.Errorf("selftest: reading embedded %s: %w", c.File, err)
+		}
+		for _, m := range models {
+			check := CheckResult{
+				Model:   m.Spec.Name,
+				Case:    c.Name,
+				MinNSFW: c.MinNSFW,
+				MaxNSFW: c.MaxNSFW,
+			}
+			result, err := s.RunModel(ctx, m, data)
+			if err != nil {
+				check.Error = err.Error()
+			} else {
+				check.NSFWScore = result.NSFWScore
+				check.DurationMS = result.DurationMS
+				check.Passed = result.NSFWScore >= c.MinNSFW && result.NSFWScore <= c.MaxNSFW
+			}
+			if !check.Passed {
+				report.Passed = false
+			}
+			report.Checks = append(report.Checks, check)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// adaptiveWindow is how many completed requests one latency sample
+// window holds before the limit is reconsidered.
+const adaptiveWindow = 32
+
+// adaptiveLimiter is an AIMD controller for scan concurrency: while the
+// observed p95 latency stays at or under the target the in-flight limit
+// creeps up by one per window, and the first window over target halves
+// it. That converges on the highest concurrency the hardware sustains
+// within the latency budget without per-profile tuning.
+type adaptiveLimiter struct {
+	target time.Duration
+	max    int
+
+	mu       sync.Mutex
+	inflight int
+	limit    int
+	lats     []time.Duration
+	lastP95  time.Duration
+}
+
+func newAdaptiveLimiter(target time.Duration, max int) *adaptiveLimiter {
+	a := &adaptiveLimiter{
+		target: target,
+		max:    max,
+		// Start low and let additive increase find the ceiling; a cold
+		// start over target would halve an untested guess anyway.
+		limit: 4,
+		lats:  make([]time.Duration, 0, adaptiveWindow),
+	}
+	if a.limit > max {
+		a.limit = max
+	}
+	return a
+}
+
+// acquire reserves a slot, or reports that the scan should be shed,
+// along with the limit in force either way.
+func (a *adaptiveLimiter) acquire() (ok bool, limit int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.inflight >= a.limit {
+		return false, a.limit
+	}
+	a.inflight++
+	return true, a.limit
+}
+
+// release returns a slot and folds the request's latency into the
+// current window, adjusting the limit when the window is full. It
+// reports the new limit and window p95 when an adjustment happened,
+// else -1.
+func (a *adaptiveLimiter) release(elapsed time.Duration) (limit int, p95 time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.inflight--
+	a.lats = append(a.lats, elapsed)
+	if len(a.lats) < adaptiveWindow {
+		return -1, 0
+	}
+	sorted := append([]time.Duration(nil), a.lats...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	a.lastP95 = sorted[len(sorted)*95/100]
+	a.lats = a.lats[:0]
+	switch {
+	case a.lastP95 > a.target:
+		a.limit /= 2
+		if a.limit < 1 {
+			a.limit = 1
+		}
+	case a.limit < a.max:
+		a.limit++
+	default:
+		return -1, 0
+	}
+	return a.limit, a.lastP95
+}
+
+// status reports the current limit and the last window's p95 for /stats.
+func (a *adaptiveLimiter) status() (limit int, p95 time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.limit, a.lastP95
+}
+
+// adaptiveStatus is the /stats block for the adaptive controller.
+type adaptiveStatus struct {
+	Limit       int     `json:"limit"`
+	P95MS       float64 `json:"p95_ms"`
+	TargetP95MS float64 `json:"target_p95_ms"`
+}
+
+// limitAdaptive is the middleware form: like limitInFlight but with the
+// ceiling steered by observed latency instead of configuration.
+func (s *Server) limitAdaptive(a *adaptiveLimiter) func(http.Handler) http.Handler {
+	sat := &saturation{}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, limit := a.acquire()
+			if !ok && s.cfg.QueueWait > 0 {
+				if err := s.bufferQueuedBody(r); err != nil {
+					s.dropAbandoned(r, "scan")
+					return
+				}
+				// The controller has no hand-off channel to park on, so a
+				// queued request polls for a slot until the wait budget or
+				// its own client gives up.
+				deadline := time.NewTimer(s.cfg.QueueWait)
+				tick := time.NewTicker(5 * time.Millisecond)
+				defer deadline.Stop()
+				defer tick.Stop()
+			wait:
+				for {
+					select {
+					case <-r.Context().Done():
+						s.dropAbandoned(r, "scan")
+						return
+					case <-deadline.C:
+						break wait
+					case <-tick.C:
+						if ok, limit = a.acquire(); ok {
+							break wait
+						}
+					}
+				}
+			}
+			if !ok {
+				s.shedResponse(w, r, "scan", limit, sat)
+				return
+			}
+			start := time.Now()
+			defer func() {
+				sat.complete()
+				if limit, p95 := a.release(time.Since(start)); limit >= 0 {
+					s.log.Info("adaptive concurrency adjusted", "limit", limit, "p95", p95)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
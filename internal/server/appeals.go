@@ -0,0 +1,577 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/bus"
+	"github.com/illussioon/NFWS-Moderations-API/internal/store"
+)
+
+// appealsBucket is the human review queue for contested verdicts, keyed
+// by a time-ordered ID so listing returns appeals oldest-first. Every
+// appealed item enters at elevated priority: the queue holds nothing
+// but end-user disputes, which outrank routine sampling by definition.
+const appealsBucket = "appeals"
+
+// Appeal statuses. An appeal is open until a reviewer resolves it one
+// way or the other; resolved appeals stay in the bucket as a record.
+const (
+	appealOpen       = "open"
+	appealUpheld     = "upheld"
+	appealOverturned = "overturned"
+	// appealEscalated marks an appeal whose required decisions are in
+	// but disagree; the next decision breaks the tie and is final.
+	appealEscalated = "escalated"
+)
+
+// appealDecision is one reviewer's vote on an appeal under consensus
+// review.
+type appealDecision struct {
+	Reviewer  string    `json:"reviewer"`
+	Outcome   string    `json:"outcome"`
+	Note      string    `json:"note,omitempty"`
+	DecidedAt time.Time `json:"decided_at"`
+}
+
+// appealRecord is one end-user dispute over a stored verdict, with the
+// verdict snapshotted at filing time so the appeal survives verdict
+// expiry.
+type appealRecord struct {
+	ID      string `json:"id"`
+	SHA256  string `json:"sha256"`
+	Tenant  string `json:"tenant,omitempty"`
+	Reason  string `json:"reason"`
+	Contact string `json:"contact,omitempty"`
+	// Priority is always "elevated": appeals jump routine review work.
+	Priority string    `json:"priority"`
+	Status   string    `json:"status"`
+	NSFW     bool      `json:"nsfw"`
+	Score    float64   `json:"score"`
+	Severity string    `json:"severity,omitempty"`
+	OpenedAt time.Time `json:"opened_at"`
+	// Required is how many independent reviewer decisions the item's
+	// severity tier demands (NFWS_REVIEW_CONSENSUS); zero means one.
+	Required  int              `json:"required_decisions,omitempty"`
+	Decisions []appealDecision `json:"decisions,omitempty"`
+	// DueAt is the SLA deadline set at filing time; an open appeal
+	// past it is flagged overdue in listings and queue stats.
+	DueAt *time.Time `json:"due_at,omitempty"`
+	// Overdue and Blinded are computed at listing time, never stored.
+	Overdue bool `json:"overdue,omitempty"`
+	// Blinded marks a listing whose model verdict and sibling decisions
+	// were withheld under NFWS_REVIEW_BLIND; they reveal once the
+	// appeal is resolved.
+	Blinded bool `json:"blinded,omitempty"`
+
+	// Reviewer is who currently holds the claim on the appeal, or who
+	// resolved it. ClaimedAt is when the claim was taken.
+	Reviewer  string     `json:"reviewer,omitempty"`
+	ClaimedAt *time.Time `json:"claimed_at,omitempty"`
+
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	// Note is the reviewer's explanation, recorded on resolution.
+	Note string `json:"note,omitempty"`
+}
+
+// loadAppeal reads one appeal by ID, translating a missing record into
+// a 404 and reporting false when a response has already been written.
+func (s *Server) loadAppeal(w http.ResponseWriter, r *http.Request, id string) (appealRecord, bool) {
+	var a appealRecord
+	data, err := s.store.Get(r.Context(), appealsBucket, id)
+	if errors.Is(err, store.ErrNotFound) {
+		s.respondError(w, http.StatusNotFound, "no such appeal")
+		return a, false
+	}
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "loading appeal: "+err.Error())
+		return a, false
+	}
+	if err := json.Unmarshal(data, &a); err != nil {
+		s.respondError(w, http.StatusInternalServerError, "decoding appeal: "+err.Error())
+		return a, false
+	}
+	return a, true
+}
+
+// putAppeal writes an appeal back, reporting false when a response has
+// already been written.
+func (s *Server) putAppeal(w http.ResponseWriter, r *http.Request, a appealRecord) bool {
+	out, err := json.Marshal(a)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "encoding appeal: "+err.Error())
+		return false
+	}
+	if err := s.store.Put(r.Context(), appealsBucket, a.ID, out); err != nil {
+		s.respondError(w, http.StatusInternalServerError, "storing appeal: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// appealLockTTL bounds how long one handler can hold an appeal's write
+// lock; a crashed holder frees the appeal after this.
+const appealLockTTL = 5 * time.Second
+
+// lockAppeal serializes read-modify-write updates to one appeal across
+// concurrent handlers — and across replicas when the store is shared —
+// using the store's lease as a short-lived mutex. It reports false with
+// a response already written when the lock cannot be taken in time; on
+// success the returned unlock must be called once the write is done.
+func (s *Server) lockAppeal(w http.ResponseWriter, r *http.Request, id string) (unlock func(), ok bool) {
+	var h [8]byte
+	rand.Read(h[:])
+	name, holder := "appeal:"+id, hex.EncodeToString(h[:])
+	deadline := time.Now().Add(appealLockTTL)
+	for {
+		got, err := s.store.Lease(r.Context(), name, holder, appealLockTTL)
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, "locking appeal: "+err.Error())
+			return nil, false
+		}
+		if got {
+			// Releasing is renewing with a zero TTL: the lease expires
+			// immediately and the next waiter takes it.
+			return func() { s.store.Lease(r.Context(), name, holder, 0) }, true
+		}
+		if time.Now().After(deadline) {
+			s.respondError(w, http.StatusServiceUnavailable, "appeal is being updated; retry")
+			return nil, false
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+}
+
+// appealID builds a time-ordered queue key, same scheme as audit keys:
+// nanosecond timestamp plus a random suffix against collisions.
+func appealID() string {
+	var suffix [4]byte
+	rand.Read(suffix[:])
+	return fmt.Sprintf("%020d-%s", time.Now().UTC().UnixNano(), hex.EncodeToString(suffix[:]))
+}
+
+// handleAppealCreate files an end-user appeal against a stored verdict
+// and routes it into the review queue. Like feedback, an appeal is only
+// meaningful while the verdict it disputes exists.
+func (s *Server) handleAppealCreate(w http.ResponseWriter, r *http.Request) {
+	var req api.AppealRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if len(req.SHA256) != 2*sha256.Size {
+		s.respondError(w, http.StatusBadRequest, "sha256 must be a 64-character hex digest")
+		return
+	}
+	if req.Reason == "" {
+		s.respondError(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+	data, err := s.store.Get(r.Context(), verdictBucket, req.SHA256)
+	if errors.Is(err, store.ErrNotFound) {
+		s.respondError(w, http.StatusNotFound, "no stored verdict for this hash; scan the image first")
+		return
+	}
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "loading verdict: "+err.Error())
+		return
+	}
+	var v storedVerdict
+	if err := json.Unmarshal(data, &v); err != nil {
+		s.respondError(w, http.StatusInternalServerError, "decoding verdict: "+err.Error())
+		return
+	}
+	rec := appealRecord{
+		ID:       appealID(),
+		SHA256:   req.SHA256,
+		Reason:   req.Reason,
+		Contact:  req.Contact,
+		Priority: "elevated",
+		Status:   appealOpen,
+		NSFW:     v.NSFW,
+		Score:    v.Score,
+		Severity: v.Severity,
+		Required: s.cfg.ReviewConsensus[v.Severity],
+		OpenedAt: time.Now().UTC(),
+	}
+	if k := keyFromContext(r.Context()); k != nil {
+		rec.Tenant = k.Name
+	}
+	if s.cfg.ReviewSLA > 0 {
+		due := rec.OpenedAt.Add(s.cfg.ReviewSLA)
+		rec.DueAt = &due
+	}
+	out, err := json.Marshal(rec)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "encoding appeal: "+err.Error())
+		return
+	}
+	if err := s.store.Put(r.Context(), appealsBucket, rec.ID, out); err != nil {
+		s.respondError(w, http.StatusInternalServerError, "storing appeal: "+err.Error())
+		return
+	}
+	s.events.Publish(bus.AppealOpened, map[string]any{
+		"appeal_id": rec.ID,
+		"sha256":    rec.SHA256,
+		"tenant":    rec.Tenant,
+		"priority":  rec.Priority,
+	})
+	s.respond(w, http.StatusCreated, map[string]any{
+		"appeal_id": rec.ID,
+		"status":    rec.Status,
+		"priority":  rec.Priority,
+	})
+}
+
+// handleAppealsList returns the review queue, oldest appeal first. The
+// optional status and reviewer queries each filter to one value;
+// reviewers working the queue ask for ?status=open.
+func (s *Server) handleAppealsList(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	reviewer := r.URL.Query().Get("reviewer")
+	records, err := s.store.List(r.Context(), appealsBucket, "", 0)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "listing appeals: "+err.Error())
+		return
+	}
+	now := time.Now().UTC()
+	appeals := make([]appealRecord, 0, len(records))
+	for _, rec := range records {
+		var a appealRecord
+		if err := json.Unmarshal(rec.Value, &a); err != nil {
+			continue
+		}
+		if status != "" && a.Status != status {
+			continue
+		}
+		if reviewer != "" && a.Reviewer != reviewer {
+			continue
+		}
+		a.Overdue = a.Status == appealOpen && a.DueAt != nil && now.After(*a.DueAt)
+		if s.cfg.ReviewBlind && (a.Status == appealOpen || a.Status == appealEscalated) {
+			blindAppeal(&a)
+		}
+		appeals = append(appeals, a)
+	}
+	s.respond(w, http.StatusOK, map[string]any{"appeals": appeals})
+}
+
+// blindAppeal strips what could anchor a reviewer: the model's verdict
+// and the votes other reviewers have already cast. Who has voted stays
+// visible so consensus progress is still readable from the queue.
+func blindAppeal(a *appealRecord) {
+	a.Blinded = true
+	a.NSFW = false
+	a.Score = 0
+	a.Severity = ""
+	for i := range a.Decisions {
+		a.Decisions[i].Outcome = ""
+		a.Decisions[i].Note = ""
+	}
+}
+
+// handleAppealClaim assigns an open appeal to a reviewer. A claimed
+// appeal stays in the queue but other reviewers see who holds it; a
+// second claim is a conflict until the holder releases.
+func (s *Server) handleAppealClaim(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var req struct {
+		Reviewer string `json:"reviewer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if req.Reviewer == "" {
+		s.respondError(w, http.StatusBadRequest, "reviewer is required")
+		return
+	}
+	// The claim check and write run under the appeal's lock so two
+	// simultaneous claims cannot both succeed with the last write winning.
+	unlock, ok := s.lockAppeal(w, r, id)
+	if !ok {
+		return
+	}
+	defer unlock()
+	a, ok := s.loadAppeal(w, r, id)
+	if !ok {
+		return
+	}
+	if a.Status != appealOpen {
+		s.respondError(w, http.StatusConflict, "appeal is already "+a.Status)
+		return
+	}
+	if a.Reviewer != "" && a.Reviewer != req.Reviewer {
+		s.respondError(w, http.StatusConflict, "appeal is claimed by "+a.Reviewer)
+		return
+	}
+	now := time.Now().UTC()
+	a.Reviewer = req.Reviewer
+	a.ClaimedAt = &now
+	if !s.putAppeal(w, r, a) {
+		return
+	}
+	s.audit(r, "appeal_claim", map[string]any{"appeal_id": id, "reviewer": req.Reviewer})
+	s.respond(w, http.StatusOK, map[string]any{
+		"appeal_id": id,
+		"reviewer":  a.Reviewer,
+	})
+}
+
+// handleAppealRelease returns a claimed appeal to the unassigned pool,
+// for reviewers going off shift without resolving.
+func (s *Server) handleAppealRelease(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	unlock, ok := s.lockAppeal(w, r, id)
+	if !ok {
+		return
+	}
+	defer unlock()
+	a, ok := s.loadAppeal(w, r, id)
+	if !ok {
+		return
+	}
+	if a.Status != appealOpen {
+		s.respondError(w, http.StatusConflict, "appeal is already "+a.Status)
+		return
+	}
+	if a.Reviewer == "" {
+		s.respondError(w, http.StatusConflict, "appeal is not claimed")
+		return
+	}
+	released := a.Reviewer
+	a.Reviewer = ""
+	a.ClaimedAt = nil
+	if !s.putAppeal(w, r, a) {
+		return
+	}
+	s.audit(r, "appeal_release", map[string]any{"appeal_id": id, "reviewer": released})
+	s.respond(w, http.StatusOK, map[string]any{
+		"appeal_id": id,
+		"released":  true,
+	})
+}
+
+// handleAppealsStats summarizes the review queue for a moderation
+// lead: backlog by status, SLA breaches, how long resolutions take,
+// and how often the models are overturned.
+func (s *Server) handleAppealsStats(w http.ResponseWriter, r *http.Request) {
+	records, err := s.store.List(r.Context(), appealsBucket, "", 0)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "listing appeals: "+err.Error())
+		return
+	}
+	now := time.Now().UTC()
+	var open, claimed, overdue, escalated, upheld, overturned int
+	var votes, agreeing int
+	var latencySum, latencyMax time.Duration
+	for _, rec := range records {
+		var a appealRecord
+		if err := json.Unmarshal(rec.Value, &a); err != nil {
+			continue
+		}
+		switch a.Status {
+		case appealOpen:
+			open++
+			if a.Reviewer != "" {
+				claimed++
+			}
+			if a.DueAt != nil && now.After(*a.DueAt) {
+				overdue++
+			}
+		case appealEscalated:
+			escalated++
+		case appealUpheld:
+			upheld++
+		case appealOverturned:
+			overturned++
+		}
+		if a.ResolvedAt != nil {
+			lat := a.ResolvedAt.Sub(a.OpenedAt)
+			latencySum += lat
+			if lat > latencyMax {
+				latencyMax = lat
+			}
+		}
+		// Human-vs-model agreement: upholding the verdict is agreement.
+		// Consensus appeals contribute every vote cast; single-decision
+		// appeals contribute their final outcome.
+		if len(a.Decisions) > 0 {
+			for _, d := range a.Decisions {
+				votes++
+				if d.Outcome == appealUpheld {
+					agreeing++
+				}
+			}
+		} else if a.ResolvedAt != nil {
+			votes++
+			if a.Status == appealUpheld {
+				agreeing++
+			}
+		}
+	}
+	resolved := upheld + overturned
+	stats := map[string]any{
+		"open":       open,
+		"claimed":    claimed,
+		"overdue":    overdue,
+		"escalated":  escalated,
+		"resolved":   resolved,
+		"upheld":     upheld,
+		"overturned": overturned,
+	}
+	if votes > 0 {
+		stats["decisions"] = votes
+		stats["model_agreement_rate"] = float64(agreeing) / float64(votes)
+	}
+	if resolved > 0 {
+		stats["overturn_rate"] = float64(overturned) / float64(resolved)
+		stats["mean_latency_ms"] = float64(latencySum.Milliseconds()) / float64(resolved)
+		stats["max_latency_ms"] = latencyMax.Milliseconds()
+	}
+	s.respond(w, http.StatusOK, stats)
+}
+
+// handleAppealResolve closes an open appeal. An overturned appeal also
+// feeds the dataset correction path, exactly as a disagreeing feedback
+// label would. Resolution fires bus.AppealResolved, so platforms with a
+// webhook subscribed to it learn the outcome without polling.
+func (s *Server) handleAppealResolve(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var req struct {
+		Outcome  string `json:"outcome"`
+		Note     string `json:"note"`
+		Reviewer string `json:"reviewer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if req.Outcome != appealUpheld && req.Outcome != appealOverturned {
+		s.respondError(w, http.StatusBadRequest, "outcome must be upheld or overturned")
+		return
+	}
+	// Two reviewers resolving concurrently must not lose a vote: the
+	// whole load–append–put runs under the appeal's write lock.
+	unlock, ok := s.lockAppeal(w, r, id)
+	if !ok {
+		return
+	}
+	defer unlock()
+	a, ok := s.loadAppeal(w, r, id)
+	if !ok {
+		return
+	}
+	if a.Status != appealOpen && a.Status != appealEscalated {
+		s.respondError(w, http.StatusConflict, "appeal is already "+a.Status)
+		return
+	}
+	now := time.Now().UTC()
+	// Consensus review: decisions accumulate until the severity tier's
+	// quota is met. Unanimity finalizes; a split escalates, and the
+	// next decision on an escalated appeal breaks the tie.
+	if a.Required > 1 {
+		if req.Reviewer == "" {
+			s.respondError(w, http.StatusBadRequest, "reviewer is required for consensus review")
+			return
+		}
+		for _, d := range a.Decisions {
+			if d.Reviewer == req.Reviewer {
+				s.respondError(w, http.StatusConflict, "reviewer "+req.Reviewer+" already decided this appeal")
+				return
+			}
+		}
+		a.Decisions = append(a.Decisions, appealDecision{
+			Reviewer:  req.Reviewer,
+			Outcome:   req.Outcome,
+			Note:      req.Note,
+			DecidedAt: now,
+		})
+		if a.Status == appealOpen {
+			if len(a.Decisions) < a.Required {
+				if !s.putAppeal(w, r, a) {
+					return
+				}
+				s.respond(w, http.StatusOK, map[string]any{
+					"appeal_id": id,
+					"status":    a.Status,
+					"decisions": len(a.Decisions),
+					"required":  a.Required,
+				})
+				return
+			}
+			unanimous := true
+			for _, d := range a.Decisions {
+				if d.Outcome != a.Decisions[0].Outcome {
+					unanimous = false
+				}
+			}
+			if !unanimous {
+				a.Status = appealEscalated
+				if !s.putAppeal(w, r, a) {
+					return
+				}
+				s.audit(r, "appeal_escalate", map[string]any{
+					"appeal_id": id,
+					"sha256":    a.SHA256,
+				})
+				s.events.Publish(bus.AppealEscalated, map[string]any{
+					"appeal_id": id,
+					"sha256":    a.SHA256,
+					"tenant":    a.Tenant,
+					"severity":  a.Severity,
+				})
+				s.respond(w, http.StatusOK, map[string]any{
+					"appeal_id": id,
+					"status":    a.Status,
+					"decisions": len(a.Decisions),
+					"required":  a.Required,
+				})
+				return
+			}
+		}
+	}
+	a.Status = req.Outcome
+	a.ResolvedAt = &now
+	a.Note = req.Note
+	if req.Reviewer != "" {
+		a.Reviewer = req.Reviewer
+	}
+	if !s.putAppeal(w, r, a) {
+		return
+	}
+	if req.Outcome == appealOverturned {
+		if data, err := s.store.Get(r.Context(), verdictBucket, a.SHA256); err == nil {
+			var v storedVerdict
+			if json.Unmarshal(data, &v) == nil {
+				s.recordDatasetCorrection(r.Context(), a.SHA256, !v.NSFW, v)
+			}
+		}
+	}
+	s.audit(r, "appeal_resolve", map[string]any{
+		"appeal_id": id,
+		"sha256":    a.SHA256,
+		"outcome":   req.Outcome,
+		"reviewer":  a.Reviewer,
+	})
+	s.events.Publish(bus.AppealResolved, map[string]any{
+		"appeal_id": id,
+		"sha256":    a.SHA256,
+		"tenant":    a.Tenant,
+		"outcome":   req.Outcome,
+		"reviewer":  a.Reviewer,
+		"note":      req.Note,
+	})
+	s.respond(w, http.StatusOK, map[string]any{
+		"appeal_id": id,
+		"status":    a.Status,
+	})
+}
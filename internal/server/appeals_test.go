@@ -0,0 +1,234 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/illussioon/NFWS-Moderations-API/internal/bus"
+	"github.com/illussioon/NFWS-Moderations-API/internal/config"
+	"github.com/illussioon/NFWS-Moderations-API/internal/store"
+)
+
+// newAppealsServer builds a Server with just what the appeal handlers
+// touch: an in-memory store, a bus, and review configuration.
+func newAppealsServer(cfg *config.Config) *Server {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(cfg, Deps{
+		Store:  store.NewMemory(),
+		Events: bus.New(log),
+		Log:    log,
+	})
+}
+
+// seedVerdict stores a verdict for a fabricated image hash and returns
+// the hash, so appeals have something to dispute.
+func seedVerdict(t *testing.T, s *Server, severity string) string {
+	t.Helper()
+	sum := sha256.Sum256([]byte("image-" + severity))
+	sha := hex.EncodeToString(sum[:])
+	data, err := json.Marshal(storedVerdict{NSFW: true, Score: 0.9, Severity: severity})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.store.Put(context.Background(), verdictBucket, sha, data); err != nil {
+		t.Fatal(err)
+	}
+	return sha
+}
+
+// call invokes one handler directly, with id bound as the {id} route
+// parameter when non-empty, and decodes the JSON response into out.
+func call(t *testing.T, h http.HandlerFunc, method, id string, payload, out any) int {
+	t.Helper()
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, "/", &body)
+	if id != "" {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	}
+	w := httptest.NewRecorder()
+	h(w, req)
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("decoding response %q: %v", w.Body.String(), err)
+		}
+	}
+	return w.Code
+}
+
+// openAppeal files an appeal against a fresh verdict and returns its ID.
+func openAppeal(t *testing.T, s *Server, severity string) string {
+	t.Helper()
+	sha := seedVerdict(t, s, severity)
+	var resp map[string]any
+	code := call(t, s.handleAppealCreate, http.MethodPost, "",
+		map[string]string{"sha256": sha, "reason": "disputed"}, &resp)
+	if code != http.StatusCreated {
+		t.Fatalf("create appeal: status %d", code)
+	}
+	return resp["appeal_id"].(string)
+}
+
+func vote(reviewer, outcome string) map[string]string {
+	return map[string]string{"reviewer": reviewer, "outcome": outcome}
+}
+
+func TestAppealCreateValidation(t *testing.T) {
+	s := newAppealsServer(&config.Config{})
+	cases := []struct {
+		payload map[string]string
+		code    int
+	}{
+		{map[string]string{"sha256": "short", "reason": "r"}, http.StatusBadRequest},
+		{map[string]string{"sha256": strings.Repeat("ab", 32)}, http.StatusBadRequest},
+		// Valid shape but no stored verdict.
+		{map[string]string{"sha256": strings.Repeat("ab", 32), "reason": "r"}, http.StatusNotFound},
+	}
+	for _, c := range cases {
+		if code := call(t, s.handleAppealCreate, http.MethodPost, "", c.payload, nil); code != c.code {
+			t.Errorf("create %v: status %d, want %d", c.payload, code, c.code)
+		}
+	}
+}
+
+// TestAppealSingleDecision covers severities without a consensus tier:
+// one resolution finalizes immediately.
+func TestAppealSingleDecision(t *testing.T) {
+	s := newAppealsServer(&config.Config{})
+	id := openAppeal(t, s, "suggestive")
+
+	var resp map[string]any
+	if code := call(t, s.handleAppealResolve, http.MethodPost, id, vote("r1", "overturned"), &resp); code != http.StatusOK {
+		t.Fatalf("resolve: status %d", code)
+	}
+	if resp["status"] != appealOverturned {
+		t.Errorf("status = %v, want %s", resp["status"], appealOverturned)
+	}
+	// A second resolution is a conflict.
+	if code := call(t, s.handleAppealResolve, http.MethodPost, id, vote("r2", "upheld"), nil); code != http.StatusConflict {
+		t.Errorf("re-resolve: status %d, want 409", code)
+	}
+	if code := call(t, s.handleAppealResolve, http.MethodPost, id, vote("r2", "discard"), nil); code != http.StatusBadRequest {
+		t.Errorf("bad outcome: status %d, want 400", code)
+	}
+}
+
+// TestAppealConsensusUnanimous walks the consensus machine to a clean
+// finish: votes accumulate as progress replies, a duplicate reviewer is
+// rejected, and the quota-filling unanimous vote finalizes.
+func TestAppealConsensusUnanimous(t *testing.T) {
+	s := newAppealsServer(&config.Config{ReviewConsensus: map[string]int{"explicit": 3}})
+	id := openAppeal(t, s, "explicit")
+
+	var resp map[string]any
+	call(t, s.handleAppealResolve, http.MethodPost, id, vote("r1", "upheld"), &resp)
+	if resp["status"] != appealOpen || resp["decisions"] != float64(1) {
+		t.Fatalf("after vote 1: %v", resp)
+	}
+	if code := call(t, s.handleAppealResolve, http.MethodPost, id, vote("r1", "upheld"), nil); code != http.StatusConflict {
+		t.Errorf("duplicate reviewer: status %d, want 409", code)
+	}
+	call(t, s.handleAppealResolve, http.MethodPost, id, vote("r2", "upheld"), nil)
+	call(t, s.handleAppealResolve, http.MethodPost, id, vote("r3", "upheld"), &resp)
+	if resp["status"] != appealUpheld {
+		t.Errorf("after quota: status = %v, want %s", resp["status"], appealUpheld)
+	}
+}
+
+// TestAppealConsensusSplit checks a disagreeing quota escalates instead
+// of finalizing, and the next decision breaks the tie.
+func TestAppealConsensusSplit(t *testing.T) {
+	s := newAppealsServer(&config.Config{ReviewConsensus: map[string]int{"explicit": 2}})
+	id := openAppeal(t, s, "explicit")
+
+	var resp map[string]any
+	call(t, s.handleAppealResolve, http.MethodPost, id, vote("r1", "upheld"), nil)
+	call(t, s.handleAppealResolve, http.MethodPost, id, vote("r2", "overturned"), &resp)
+	if resp["status"] != appealEscalated {
+		t.Fatalf("after split: status = %v, want %s", resp["status"], appealEscalated)
+	}
+	call(t, s.handleAppealResolve, http.MethodPost, id, vote("r3", "overturned"), &resp)
+	if resp["status"] != appealOverturned {
+		t.Errorf("after tiebreak: status = %v, want %s", resp["status"], appealOverturned)
+	}
+}
+
+func TestAppealClaim(t *testing.T) {
+	s := newAppealsServer(&config.Config{})
+	id := openAppeal(t, s, "explicit")
+
+	rev := func(name string) map[string]string { return map[string]string{"reviewer": name} }
+	if code := call(t, s.handleAppealClaim, http.MethodPost, id, rev("r1"), nil); code != http.StatusOK {
+		t.Fatalf("claim: status %d", code)
+	}
+	if code := call(t, s.handleAppealClaim, http.MethodPost, id, rev("r2"), nil); code != http.StatusConflict {
+		t.Errorf("second claim: status %d, want 409", code)
+	}
+	if code := call(t, s.handleAppealRelease, http.MethodPost, id, nil, nil); code != http.StatusOK {
+		t.Fatalf("release: status %d", code)
+	}
+	if code := call(t, s.handleAppealClaim, http.MethodPost, id, rev("r2"), nil); code != http.StatusOK {
+		t.Errorf("claim after release: status %d", code)
+	}
+	if code := call(t, s.handleAppealClaim, http.MethodPost, "missing", rev("r1"), nil); code != http.StatusNotFound {
+		t.Errorf("claim missing appeal: status %d, want 404", code)
+	}
+}
+
+// TestAppealOverdue checks SLA bookkeeping: an open appeal past its due
+// time is flagged in listings and counted in queue stats.
+func TestAppealOverdue(t *testing.T) {
+	s := newAppealsServer(&config.Config{ReviewSLA: time.Nanosecond})
+	openAppeal(t, s, "explicit")
+	time.Sleep(time.Millisecond)
+
+	var listing struct{ Appeals []appealRecord }
+	call(t, s.handleAppealsList, http.MethodGet, "", nil, &listing)
+	if len(listing.Appeals) != 1 || !listing.Appeals[0].Overdue {
+		t.Errorf("listing = %+v, want one overdue appeal", listing.Appeals)
+	}
+	var stats map[string]any
+	call(t, s.handleAppealsStats, http.MethodGet, "", nil, &stats)
+	if stats["overdue"] != float64(1) || stats["open"] != float64(1) {
+		t.Errorf("stats = %v, want open=1 overdue=1", stats)
+	}
+}
+
+// TestAppealBlindListing checks blind review withholds the model
+// verdict on open appeals and reveals it once resolved.
+func TestAppealBlindListing(t *testing.T) {
+	s := newAppealsServer(&config.Config{ReviewBlind: true})
+	id := openAppeal(t, s, "explicit")
+
+	var listing struct{ Appeals []appealRecord }
+	call(t, s.handleAppealsList, http.MethodGet, "", nil, &listing)
+	a := listing.Appeals[0]
+	if !a.Blinded || a.NSFW || a.Score != 0 || a.Severity != "" {
+		t.Errorf("open appeal not blinded: %+v", a)
+	}
+
+	call(t, s.handleAppealResolve, http.MethodPost, id, vote("r1", "upheld"), nil)
+	var after struct{ Appeals []appealRecord }
+	call(t, s.handleAppealsList, http.MethodGet, "", nil, &after)
+	a = after.Appeals[0]
+	if a.Blinded || !a.NSFW {
+		t.Errorf("resolved appeal still blinded: %+v", a)
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/jobs"
+)
+
+// handleScanAsync enqueues a scan and returns a job ID immediately, for
+// callers whose content can tolerate a polling round-trip. The image is
+// downloaded and validated up front — a bad request fails now, not in a
+// job the caller has to poll to see — and the scan itself runs on the
+// job queue's workers.
+func (s *Server) handleScanAsync(w http.ResponseWriter, r *http.Request) {
+	if s.jobs == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "asynchronous scans are disabled (NFWS_ASYNC_WORKERS)")
+		return
+	}
+	var req api.ScanRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxImageBytesFor(r)*2)).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	lang := preferredLang(r)
+	if fields := s.validateImageSource(&req, s.maxImageBytesFor(r), lang); fields != nil {
+		s.respondFieldErrors(w, lang, fields)
+		return
+	}
+	if req.Receipt && s.receipts == nil {
+		s.respondError(w, http.StatusBadRequest, "receipts require NFWS_RECEIPT_SECRET on the server")
+		return
+	}
+	data, err := s.imageBytes(r, &req)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	data = s.preprocessPlugin(r.Context(), data)
+	opts, msg := s.scanOptions(r, &req)
+	if msg != "" {
+		s.respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+	cacheKey := s.scanCacheKey(r, &req, data)
+	// The worker runs after this handler returns, so it gets a request
+	// detached from the response lifecycle: same headers and caller
+	// identity, but a context that outlives this one.
+	detached := r.Clone(context.WithValue(context.Background(), authKeyContextKey{}, keyFromContext(r.Context())))
+	timeout := s.cfg.RequestTimeout
+	job, err := s.jobs.Enqueue(func(ctx context.Context) (any, error) {
+		ctx, cancel := context.WithTimeout(detached.Context(), timeout)
+		defer cancel()
+		if resp := s.matchHashLists(detached, &req, data); resp != nil {
+			s.deliverCallback(req.CallbackURL, resp)
+			return redactResponse(detached, resp), nil
+		}
+		if resp := s.cachedScanResponse(ctx, cacheKey); resp != nil {
+			s.stats.RecordScan(resp.NSFW, nil)
+			s.auditScan(detached, &req, resp, sha256.Sum256(data))
+			s.deliverCallback(req.CallbackURL, resp)
+			return redactResponse(detached, resp), nil
+		}
+		resp, err := s.executeScan(ctx, detached, &req, data, opts, cacheKey, 0)
+		if err != nil {
+			return nil, err
+		}
+		return redactResponse(detached, resp), nil
+	})
+	if errors.Is(err, jobs.ErrFull) {
+		s.respondError(w, http.StatusServiceUnavailable, "job queue is full; retry later")
+		return
+	}
+	s.respond(w, http.StatusAccepted, map[string]any{
+		"job_id": job.ID,
+		"status": job.Status,
+	})
+}
+
+// handleJobGet reports one job's status, with the scan result once the
+// job is done.
+func (s *Server) handleJobGet(w http.ResponseWriter, r *http.Request) {
+	if s.jobs == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "asynchronous scans are disabled (NFWS_ASYNC_WORKERS)")
+		return
+	}
+	job, ok := s.jobs.Get(chi.URLParam(r, "id"))
+	if !ok {
+		s.respondError(w, http.StatusNotFound, "no such job; results expire after the job TTL")
+		return
+	}
+	s.respond(w, http.StatusOK, job)
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+)
+
+// auditBucket holds the admin action trail, keyed by a time-ordered
+// nanosecond timestamp so listing returns chronological order.
+const auditBucket = "audit"
+
+// auditRecord is one logged admin action.
+type auditRecord struct {
+	Time time.Time `json:"time"`
+	// Actor identifies the admin key used, as a short digest — enough
+	// for accountability without persisting the key itself.
+	Actor  string         `json:"actor"`
+	Action string         `json:"action"`
+	Params map[string]any `json:"params,omitempty"`
+}
+
+// audit records an admin action. Failures are logged but don't fail the
+// action itself: the operation already happened.
+func (s *Server) audit(r *http.Request, action string, params map[string]any) {
+	actor := actorID(r.Header.Get("X-Admin-Key"))
+	if k := keyFromContext(r.Context()); k != nil {
+		actor = k.Name
+	}
+	rec := auditRecord{
+		Time:   time.Now().UTC(),
+		Actor:  actor,
+		Action: action,
+		Params: params,
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	var suffix [4]byte
+	rand.Read(suffix[:])
+	key := fmt.Sprintf("%020d-%s", rec.Time.UnixNano(), hex.EncodeToString(suffix[:]))
+	if err := s.store.Put(r.Context(), auditBucket, key, data); err != nil {
+		s.log.Error("writing audit record failed", "action", action, "err", err)
+	}
+}
+
+// auditScan leaves a correlation record for scans tagged with upstream
+// IDs: the ingestion pipeline looks its external_id or batch_id up via
+// the audit API to reconcile its records with moderation outcomes.
+// Untagged scans leave no record.
+func (s *Server) auditScan(r *http.Request, req *api.ScanRequest, resp *api.ScanResponse, digest [sha256.Size]byte) {
+	if req.ExternalID == "" && req.BatchID == "" {
+		return
+	}
+	params := map[string]any{
+		"sha256": hex.EncodeToString(digest[:]),
+		"nsfw":   resp.NSFW,
+		"score":  resp.Score,
+	}
+	if req.ExternalID != "" {
+		params["external_id"] = req.ExternalID
+	}
+	if req.BatchID != "" {
+		params["batch_id"] = req.BatchID
+	}
+	s.audit(r, "scan", params)
+}
+
+// actorID derives a stable short identifier from an admin key.
+func actorID(key string) string {
+	if key == "" {
+		return "anonymous"
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:4])
+}
+
+// handleAudit returns the most recent admin actions, newest first.
+// ?limit=N caps the result (default 100); ?external_id= and ?batch_id=
+// narrow it to records carrying that correlation ID.
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			s.respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+	externalID := r.URL.Query().Get("external_id")
+	batchID := r.URL.Query().Get("batch_id")
+	records, err := s.store.List(r.Context(), auditBucket, "", 0)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "listing audit records: "+err.Error())
+		return
+	}
+	out := make([]auditRecord, 0, limit)
+	for i := len(records) - 1; i >= 0 && len(out) < limit; i-- {
+		var rec auditRecord
+		if err := json.Unmarshal(records[i].Value, &rec); err != nil {
+			continue
+		}
+		if externalID != "" && rec.Params["external_id"] != externalID {
+			continue
+		}
+		if batchID != "" && rec.Params["batch_id"] != batchID {
+			continue
+		}
+		out = append(out, rec)
+	}
+	s.respond(w, http.StatusOK, map[string]any{"actions": out})
+}
+
+// exportRecord is one NDJSON line of the bulk audit export. Key is the
+// store key, which doubles as the pagination cursor.
+type exportRecord struct {
+	Key string `json:"key"`
+	auditRecord
+}
+
+// handleAuditExport streams audit records as gzip NDJSON, oldest first,
+// for warehouse syncs that would otherwise page millions of rows through
+// small JSON responses. ?since= and ?until= (RFC 3339) bound the time
+// range, ?actor= and ?nsfw= narrow to one tenant or verdict, ?limit=
+// caps the page, and ?cursor= resumes after a previous page's last key
+// (echoed in the X-Next-Cursor header while more records remain).
+func (s *Server) handleAuditExport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var since, until time.Time
+	var err error
+	if v := q.Get("since"); v != "" {
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			s.respondError(w, http.StatusBadRequest, "since must be RFC 3339")
+			return
+		}
+	}
+	if v := q.Get("until"); v != "" {
+		if until, err = time.Parse(time.RFC3339, v); err != nil {
+			s.respondError(w, http.StatusBadRequest, "until must be RFC 3339")
+			return
+		}
+	}
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			s.respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+	}
+	var nsfw *bool
+	if v := q.Get("nsfw"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, "nsfw must be true or false")
+			return
+		}
+		nsfw = &b
+	}
+	actor := q.Get("actor")
+	cursor := q.Get("cursor")
+
+	records, err := s.store.List(r.Context(), auditBucket, "", 0)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "listing audit records: "+err.Error())
+		return
+	}
+	// Select before streaming so the next-page cursor can go in a
+	// header; the selection holds indexes only, not decoded records.
+	type selected struct {
+		idx int
+		rec auditRecord
+	}
+	page := make([]selected, 0, len(records))
+	more := false
+	for i, record := range records {
+		if cursor != "" && record.Key <= cursor {
+			continue
+		}
+		var rec auditRecord
+		if err := json.Unmarshal(record.Value, &rec); err != nil {
+			continue
+		}
+		if !since.IsZero() && rec.Time.Before(since) {
+			continue
+		}
+		if !until.IsZero() && !rec.Time.Before(until) {
+			continue
+		}
+		if actor != "" && rec.Actor != actor {
+			continue
+		}
+		if nsfw != nil && rec.Params["nsfw"] != *nsfw {
+			continue
+		}
+		if limit > 0 && len(page) == limit {
+			more = true
+			break
+		}
+		page = append(page, selected{idx: i, rec: rec})
+	}
+	if more && len(page) > 0 {
+		w.Header().Set("X-Next-Cursor", records[page[len(page)-1].idx].Key)
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Encoding", "gzip")
+	gz := gzip.NewWriter(w)
+	defer gz.Close()
+	enc := json.NewEncoder(gz)
+	for _, sel := range page {
+		if err := enc.Encode(exportRecord{Key: records[sel.idx].Key, auditRecord: sel.rec}); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+)
+
+// Read-through caching for /scan. Identical bytes scanned with
+// identical options by the same tenant return the cached verdict
+// without touching the models; the cache key covers all of that, so a
+// different threshold or model set never sees a stale entry. Hash-list
+// matching still runs on every request — a freshly ingested blocklist
+// hash must block cached content too. Cache hits skip the persistence
+// pipeline (verdict, dataset, events): the original scan already fed
+// it, and feeding it twice would double-count.
+
+// scanCacheKey derives the cache key for a scan, or "" when caching is
+// off.
+func (s *Server) scanCacheKey(r *http.Request, req *api.ScanRequest, data []byte) string {
+	if s.cache == nil {
+		return ""
+	}
+	// Source and correlation fields don't shape the verdict; everything
+	// else does.
+	fp := *req
+	fp.URL, fp.ImageBase64, fp.ExternalID, fp.BatchID = "", "", "", ""
+	opts, err := json.Marshal(fp)
+	if err != nil {
+		return ""
+	}
+	h := sha256.New()
+	h.Write(data)
+	h.Write(opts)
+	if k := keyFromContext(r.Context()); k != nil {
+		io.WriteString(h, k.Name)
+	}
+	return "scan:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedScanResponse returns the cached verdict for key, or nil.
+func (s *Server) cachedScanResponse(ctx context.Context, key string) *api.ScanResponse {
+	if key == "" {
+		return nil
+	}
+	value, err := s.cache.Get(ctx, key)
+	if err != nil {
+		return nil
+	}
+	var resp api.ScanResponse
+	if err := json.Unmarshal(value, &resp); err != nil {
+		return nil
+	}
+	return &resp
+}
+
+// storeScanResponse caches a completed scan's verdict. Best-effort: a
+// failed write is logged and the scan result returns regardless.
+func (s *Server) storeScanResponse(ctx context.Context, key string, resp *api.ScanResponse) {
+	if key == "" {
+		return
+	}
+	value, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	if err := s.cache.Set(ctx, key, value, s.cfg.CacheTTL); err != nil {
+		s.log.Error("caching scan result failed", "err", err)
+	}
+}
+
+// cachedDownload returns cached URL bytes when present and within the
+// caller's size limit.
+func (s *Server) cachedDownload(ctx context.Context, url string, limit int64) ([]byte, bool) {
+	if s.cache == nil {
+		return nil, false
+	}
+	data, err := s.cache.Get(ctx, downloadKey(url))
+	if err != nil || int64(len(data)) > limit {
+		return nil, false
+	}
+	return data, true
+}
+
+// storeDownload caches fetched URL bytes.
+func (s *Server) storeDownload(ctx context.Context, url string, data []byte) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Set(ctx, downloadKey(url), data, s.cfg.CacheTTL); err != nil {
+		s.log.Error("caching download failed", "err", err)
+	}
+}
+
+func downloadKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return "dl:" + hex.EncodeToString(sum[:])
+}
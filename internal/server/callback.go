@@ -0,0 +1,78 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+)
+
+// Scan-completion callbacks: a request carrying callback_url gets the
+// ScanResponse POSTed there once the verdict exists, so downstream
+// systems — async callers especially — don't have to poll. Delivery is
+// detached and best-effort like write-back, but retried, and the body
+// is HMAC-signed when NFWS_CALLBACK_SECRET is set.
+
+// callbackAttempts is how many deliveries are tried before giving up,
+// with backoff doubling from callbackBackoff between them.
+const callbackAttempts = 3
+
+const callbackBackoff = time.Second
+
+var callbackClient = &http.Client{Timeout: 10 * time.Second}
+
+// deliverCallback posts the scan result to the request's callback URL,
+// detached from the scan so a slow receiver never delays anything.
+func (s *Server) deliverCallback(url string, resp *api.ScanResponse) {
+	if url == "" {
+		return
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	go func() {
+		backoff := callbackBackoff
+		for attempt := 1; ; attempt++ {
+			err := s.postCallback(url, body)
+			if err == nil {
+				return
+			}
+			if attempt == callbackAttempts {
+				s.log.Error("callback delivery failed", "url", url, "attempts", attempt, "err", err)
+				return
+			}
+			s.log.Warn("callback delivery failed, retrying", "url", url, "attempt", attempt, "err", err)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}()
+}
+
+func (s *Server) postCallback(url string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.CallbackSecret != "" {
+		mac := hmac.New(sha256.New, []byte(s.cfg.CallbackSecret))
+		mac.Write(body)
+		req.Header.Set("X-NFWS-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := callbackClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback receiver returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+)
+
+// datasetBucket collects retraining candidates in the store: every
+// flagged scan and every feedback correction, keyed by image SHA-256 so
+// the latest label wins. Records carry hashes, source URLs, labels, and
+// detector boxes — never image bytes unless the deployment explicitly
+// opts in via NFWS_DATASET_INLINE_IMAGES.
+const datasetBucket = "dataset"
+
+// datasetRecord is one retraining candidate.
+type datasetRecord struct {
+	SHA256 string `json:"sha256"`
+	// URL is the source location when the image arrived by URL; empty
+	// for uploads.
+	URL string `json:"url,omitempty"`
+	// NSFW is the label: the verdict for flagged items, the reviewer's
+	// label for corrected ones.
+	NSFW  bool    `json:"nsfw"`
+	Score float64 `json:"score"`
+	// Corrected marks items where a reviewer overruled the verdict —
+	// the highest-value examples for retraining.
+	Corrected bool         `json:"corrected,omitempty"`
+	Boxes     []datasetBox `json:"boxes,omitempty"`
+	Width     int          `json:"width,omitempty"`
+	Height    int          `json:"height,omitempty"`
+	// ImageBase64 is present only when inline images are enabled: plain
+	// base64, or a sealed envelope when payload sealing is configured.
+	ImageBase64  string    `json:"image_base64,omitempty"`
+	ModelVersion string    `json:"model_version"`
+	Time         time.Time `json:"time"`
+}
+
+// datasetBox is one detector bounding box in a retraining record.
+type datasetBox struct {
+	Label string  `json:"label"`
+	Score float64 `json:"score"`
+	// Box is [x, y, width, height] in pixels of the original image.
+	Box [4]int `json:"box"`
+}
+
+// recordDataset queues a flagged scan as a retraining candidate,
+// detached and best-effort like the verdict store. No-op unless a
+// dataset bucket is configured.
+func (s *Server) recordDataset(req *api.ScanRequest, resp *api.ScanResponse, imageSHA [32]byte, data []byte) {
+	if s.cfg.DatasetBucket == "" || !resp.NSFW {
+		return
+	}
+	rec := datasetRecord{
+		SHA256:       hex.EncodeToString(imageSHA[:]),
+		URL:          req.URL,
+		NSFW:         true,
+		Score:        resp.Score,
+		Boxes:        datasetBoxes(resp),
+		Width:        resp.Width,
+		Height:       resp.Height,
+		ModelVersion: s.scanner.Registry().Fingerprint(),
+		Time:         time.Now().UTC(),
+	}
+	if s.cfg.DatasetInlineImages {
+		if s.sealer != nil {
+			sealed, err := s.sealer.Seal(data)
+			if err != nil {
+				s.log.Error("sealing dataset image failed", "err", err)
+				return
+			}
+			rec.ImageBase64 = string(sealed)
+		} else {
+			rec.ImageBase64 = base64.StdEncoding.EncodeToString(data)
+		}
+	}
+	out, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := s.store.Put(ctx, datasetBucket, rec.SHA256, out); err != nil {
+			s.log.Error("storing dataset record failed", "err", err)
+		}
+	}()
+}
+
+// recordDatasetCorrection upserts a retraining candidate from a
+// feedback label that disagreed with the stored verdict. A record
+// written at scan time keeps its boxes; only the label flips.
+func (s *Server) recordDatasetCorrection(ctx context.Context, sha string, label bool, v storedVerdict) {
+	if s.cfg.DatasetBucket == "" {
+		return
+	}
+	rec := datasetRecord{
+		SHA256:       sha,
+		NSFW:         label,
+		Score:        v.Score,
+		Corrected:    true,
+		ModelVersion: v.ModelVersion,
+		Time:         time.Now().UTC(),
+	}
+	if data, err := s.store.Get(ctx, datasetBucket, sha); err == nil {
+		var prev datasetRecord
+		if json.Unmarshal(data, &prev) == nil {
+			rec.URL, rec.Boxes = prev.URL, prev.Boxes
+			rec.Width, rec.Height = prev.Width, prev.Height
+			rec.ImageBase64 = prev.ImageBase64
+		}
+	}
+	out, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	if err := s.store.Put(ctx, datasetBucket, sha, out); err != nil {
+		s.log.Error("storing dataset correction failed", "err", err)
+	}
+}
+
+// datasetBoxes flattens a scan's detector output for the record.
+func datasetBoxes(resp *api.ScanResponse) []datasetBox {
+	var boxes []datasetBox
+	for _, m := range resp.Models {
+		for _, d := range m.Detections {
+			boxes = append(boxes, datasetBox{Label: d.Label, Score: d.Score, Box: d.Box})
+		}
+	}
+	return boxes
+}
+
+// handleDatasetExport writes the accumulated retraining candidates to
+// the dataset bucket as JSONL (default) or COCO (?format=coco) and
+// returns the object location. The job reads whatever has accumulated;
+// records stay in the store so successive exports are cumulative.
+func (s *Server) handleDatasetExport(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.DatasetBucket == "" {
+		s.respondError(w, http.StatusConflict, "dataset export requires NFWS_DATASET_BUCKET")
+		return
+	}
+	if !s.s3.Configured() {
+		s.respondError(w, http.StatusConflict, "dataset export requires S3 configuration")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "jsonl"
+	}
+	if format != "jsonl" && format != "coco" {
+		s.respondError(w, http.StatusBadRequest, "format must be \"jsonl\" or \"coco\"")
+		return
+	}
+	items, err := s.datasetRecords(r.Context())
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "listing dataset records: "+err.Error())
+		return
+	}
+	if len(items) == 0 {
+		s.respondError(w, http.StatusUnprocessableEntity, "no dataset records accumulated yet")
+		return
+	}
+	var body []byte
+	contentType := "application/x-ndjson"
+	if format == "coco" {
+		body, err = cocoDataset(items)
+		contentType = "application/json"
+	} else {
+		body, err = jsonlDataset(items)
+	}
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "encoding dataset: "+err.Error())
+		return
+	}
+	key := fmt.Sprintf("datasets/%s/items.%s", time.Now().UTC().Format("2006-01-02T150405Z"), datasetExt(format))
+	if err := s.s3.PutObject(r.Context(), s.cfg.DatasetBucket, key, contentType, body); err != nil {
+		s.respondError(w, http.StatusBadGateway, "uploading dataset: "+err.Error())
+		return
+	}
+	corrected := 0
+	for _, it := range items {
+		if it.Corrected {
+			corrected++
+		}
+	}
+	s.audit(r, "dataset_export", map[string]any{"format": format, "items": len(items), "key": key})
+	s.respond(w, http.StatusOK, map[string]any{
+		"items":     len(items),
+		"corrected": corrected,
+		"format":    format,
+		"url":       "s3://" + s.cfg.DatasetBucket + "/" + key,
+	})
+}
+
+// datasetRecords loads and decodes every accumulated candidate, in
+// stable hash order.
+func (s *Server) datasetRecords(ctx context.Context) ([]datasetRecord, error) {
+	recs, err := s.store.List(ctx, datasetBucket, "", 0)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]datasetRecord, 0, len(recs))
+	for _, rec := range recs {
+		var it datasetRecord
+		if err := json.Unmarshal(rec.Value, &it); err != nil {
+			continue
+		}
+		items = append(items, it)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].SHA256 < items[j].SHA256 })
+	return items, nil
+}
+
+func datasetExt(format string) string {
+	if format == "coco" {
+		return "json"
+	}
+	return "jsonl"
+}
+
+// jsonlDataset encodes one record per line.
+func jsonlDataset(items []datasetRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, it := range items {
+		if err := enc.Encode(it); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// cocoDataset encodes the records as a COCO detection dataset: one
+// image entry per item (file_name is the source URL when known, else
+// "<sha256>.img"), one annotation per box, categories collected from
+// the box labels. The image-level label rides along as a custom "nsfw"
+// field on each image entry.
+func cocoDataset(items []datasetRecord) ([]byte, error) {
+	type cocoImage struct {
+		ID       int    `json:"id"`
+		FileName string `json:"file_name"`
+		Width    int    `json:"width,omitempty"`
+		Height   int    `json:"height,omitempty"`
+		SHA256   string `json:"sha256"`
+		NSFW     bool   `json:"nsfw"`
+	}
+	type cocoAnnotation struct {
+		ID         int     `json:"id"`
+		ImageID    int     `json:"image_id"`
+		CategoryID int     `json:"category_id"`
+		BBox       [4]int  `json:"bbox"`
+		Score      float64 `json:"score"`
+	}
+	type cocoCategory struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	catIDs := make(map[string]int)
+	var cats []cocoCategory
+	var images []cocoImage
+	var anns []cocoAnnotation
+	for i, it := range items {
+		name := it.URL
+		if name == "" {
+			name = it.SHA256 + ".img"
+		}
+		images = append(images, cocoImage{
+			ID: i + 1, FileName: name, Width: it.Width, Height: it.Height,
+			SHA256: it.SHA256, NSFW: it.NSFW,
+		})
+		for _, b := range it.Boxes {
+			id, ok := catIDs[b.Label]
+			if !ok {
+				id = len(cats) + 1
+				catIDs[b.Label] = id
+				cats = append(cats, cocoCategory{ID: id, Name: b.Label})
+			}
+			anns = append(anns, cocoAnnotation{
+				ID: len(anns) + 1, ImageID: i + 1, CategoryID: id,
+				BBox: b.Box, Score: b.Score,
+			})
+		}
+	}
+	return json.Marshal(map[string]any{
+		"images":      images,
+		"annotations": anns,
+		"categories":  cats,
+	})
+}
@@ -0,0 +1,19 @@
+package server
+
+import "net/http"
+
+// handleDigestSend mails the moderation digest for the current window
+// immediately, outside the schedule — for verifying SMTP settings or
+// pulling a mid-window summary.
+func (s *Server) handleDigestSend(w http.ResponseWriter, r *http.Request) {
+	if s.digest == nil {
+		s.respondError(w, http.StatusConflict, "digest emails require NFWS_DIGEST_SCHEDULE")
+		return
+	}
+	if err := s.digest.SendNow(r.Context()); err != nil {
+		s.respondError(w, http.StatusBadGateway, "sending digest: "+err.Error())
+		return
+	}
+	s.audit(r, "digest_send", nil)
+	s.respond(w, http.StatusOK, map[string]any{"sent": true})
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+)
+
+// escalate consults the configured external provider when the local
+// score lands within the borderline window around the threshold,
+// recording the second opinion on the response and raising the verdict
+// when the provider scores higher. Provider failures are logged and the
+// local verdict stands — the escalation path must never take scanning
+// down with it.
+func (s *Server) escalate(ctx context.Context, data []byte, resp *api.ScanResponse) {
+	if s.provider == nil {
+		return
+	}
+	margin := s.cfg.ProviderMargin
+	if resp.Score < resp.Threshold-margin || resp.Score > resp.Threshold+margin {
+		return
+	}
+	score, err := s.provider.Score(ctx, data)
+	if err != nil {
+		s.log.Error("provider escalation failed", "provider", s.provider.Name(), "err", err)
+		return
+	}
+	ext := &api.ExternalResult{Provider: s.provider.Name(), Score: score}
+	if score > resp.Score {
+		ext.Merged = true
+		resp.Score = score
+		resp.NSFW = score >= resp.Threshold
+	}
+	resp.External = ext
+	s.log.Info("borderline scan escalated",
+		"provider", s.provider.Name(),
+		"external_score", score,
+		"merged", ext.Merged,
+		"nsfw", resp.NSFW,
+	)
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/auth"
+	"github.com/illussioon/NFWS-Moderations-API/internal/bus"
+)
+
+// fairShare arbitrates scan capacity between tenants on a shared
+// deployment: a tenant never holds more than its max_slots at once, and
+// capacity backing another tenant's unmet min_slots reservation is off
+// limits, so one tenant's backfill cannot starve interactive traffic
+// from the rest.
+type fairShare struct {
+	// capacity is the global scan ceiling the reservations are carved
+	// from; 0 (uncapped or adaptive) disables reservation math and
+	// leaves only the per-tenant maximums in force.
+	capacity int
+	min      map[string]int
+	max      map[string]int
+
+	mu       sync.Mutex
+	inflight map[string]int
+	total    int
+}
+
+// newFairShare builds the arbiter from the keys file, or returns nil
+// when no key declares slot shares.
+func newFairShare(keys *auth.Keys, capacity int) *fairShare {
+	if keys == nil {
+		return nil
+	}
+	f := &fairShare{
+		capacity: capacity,
+		min:      make(map[string]int),
+		max:      make(map[string]int),
+		inflight: make(map[string]int),
+	}
+	for _, k := range keys.All() {
+		if k.MinSlots > 0 {
+			f.min[k.Name] += k.MinSlots
+		}
+		if k.MaxSlots > 0 {
+			f.max[k.Name] += k.MaxSlots
+		}
+	}
+	if len(f.min) == 0 && len(f.max) == 0 {
+		return nil
+	}
+	return f
+}
+
+// acquire admits one scan for the tenant, or reports that it must back
+// off. Within its own reservation a tenant is always admitted; beyond
+// it the slot must not be one covering another tenant's unmet minimum.
+func (f *fairShare) acquire(tenant string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if max, ok := f.max[tenant]; ok && f.inflight[tenant] >= max {
+		return false
+	}
+	if f.capacity > 0 && f.inflight[tenant] >= f.min[tenant] {
+		reserved := 0
+		for name, min := range f.min {
+			if name == tenant {
+				continue
+			}
+			if short := min - f.inflight[name]; short > 0 {
+				reserved += short
+			}
+		}
+		if f.total+reserved >= f.capacity {
+			return false
+		}
+	}
+	f.inflight[tenant]++
+	f.total++
+	return true
+}
+
+// nearShare reports a tenant holding at least 80% of its slot cap, for
+// the advisory warning emitted while requests still succeed.
+func (f *fairShare) nearShare(tenant string) (held, max int, near bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	max, capped := f.max[tenant]
+	held = f.inflight[tenant]
+	return held, max, capped && held*5 >= max*4
+}
+
+// release returns the tenant's slot.
+func (f *fairShare) release(tenant string) {
+	f.mu.Lock()
+	f.inflight[tenant]--
+	f.total--
+	f.mu.Unlock()
+}
+
+// limitTenantShares enforces the per-tenant slot shares ahead of the
+// global scan ceiling. Tenants without declared shares compete for the
+// unreserved remainder like before.
+func (s *Server) limitTenantShares(f *fairShare) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant := ""
+			if k := keyFromContext(r.Context()); k != nil {
+				tenant = k.Name
+			}
+			if !f.acquire(tenant) {
+				s.stats.RecordShed()
+				s.events.Publish(bus.QuotaExceeded, map[string]any{
+					"class": "tenant", "tenant": tenant, "path": r.URL.Path,
+				})
+				w.Header().Set("Retry-After", "1")
+				s.respondError(w, http.StatusServiceUnavailable,
+					"tenant "+tenant+" is at its share of scan capacity, retry shortly")
+				return
+			}
+			defer f.release(tenant)
+			if held, max, near := f.nearShare(tenant); near {
+				warn(w, "tenant %s holds %d of %d scan slots", tenant, held, max)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/store"
+)
+
+// feedbackBucket holds ground-truth labels keyed by image SHA-256. Each
+// record snapshots the per-model scores from the stored verdict, so
+// threshold tuning works even after the verdict itself expires.
+const feedbackBucket = "feedback"
+
+// feedbackRecord pairs a reviewer's label with the model scores the
+// image received.
+type feedbackRecord struct {
+	NSFW         bool               `json:"nsfw"`
+	Score        float64            `json:"score"`
+	Scores       map[string]float64 `json:"scores,omitempty"`
+	ModelVersion string             `json:"model_version"`
+	LabeledAt    time.Time          `json:"labeled_at"`
+}
+
+// handleFeedback records a human review's verdict for a scanned image.
+// The image must have a stored verdict: feedback is only meaningful
+// against the scores the models actually produced.
+func (s *Server) handleFeedback(w http.ResponseWriter, r *http.Request) {
+	var req api.FeedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if len(req.SHA256) != 2*sha256.Size {
+		s.respondError(w, http.StatusBadRequest, "sha256 must be a 64-character hex digest")
+		return
+	}
+	if req.NSFW == nil {
+		s.respondError(w, http.StatusBadRequest, "nsfw is required")
+		return
+	}
+	data, err := s.store.Get(r.Context(), verdictBucket, req.SHA256)
+	if errors.Is(err, store.ErrNotFound) {
+		s.respondError(w, http.StatusNotFound, "no stored verdict for this hash; scan the image first")
+		return
+	}
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "loading verdict: "+err.Error())
+		return
+	}
+	var v storedVerdict
+	if err := json.Unmarshal(data, &v); err != nil {
+		s.respondError(w, http.StatusInternalServerError, "decoding verdict: "+err.Error())
+		return
+	}
+	rec := feedbackRecord{
+		NSFW:         *req.NSFW,
+		Score:        v.Score,
+		Scores:       v.Scores,
+		ModelVersion: v.ModelVersion,
+		LabeledAt:    time.Now().UTC(),
+	}
+	out, err := json.Marshal(rec)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "encoding feedback: "+err.Error())
+		return
+	}
+	if err := s.store.Put(r.Context(), feedbackBucket, req.SHA256, out); err != nil {
+		s.respondError(w, http.StatusInternalServerError, "storing feedback: "+err.Error())
+		return
+	}
+	if *req.NSFW != v.NSFW {
+		s.recordDatasetCorrection(r.Context(), req.SHA256, *req.NSFW, v)
+	}
+	s.respond(w, http.StatusOK, map[string]any{
+		"recorded": true,
+		"agreed":   *req.NSFW == v.NSFW,
+	})
+}
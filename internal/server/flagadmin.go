@@ -0,0 +1,57 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/flags"
+)
+
+// handleFlagsList returns every configured feature flag with runtime
+// overrides applied. Features absent from the list are fully on.
+func (s *Server) handleFlagsList(w http.ResponseWriter, r *http.Request) {
+	s.respond(w, http.StatusOK, map[string]any{"flags": s.flags.Snapshot()})
+}
+
+// handleFlagPut sets a runtime flag override, persisting it in the
+// store so it survives restarts and — on a shared store — reaches other
+// replicas when they start.
+func (s *Server) handleFlagPut(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	var f flags.Flag
+	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if f.Percent < 0 || f.Percent > 100 {
+		s.respondError(w, http.StatusBadRequest, "percent must be 0-100")
+		return
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "encoding flag: "+err.Error())
+		return
+	}
+	if err := s.store.Put(r.Context(), flags.Bucket, name, data); err != nil {
+		s.respondError(w, http.StatusInternalServerError, "storing flag: "+err.Error())
+		return
+	}
+	s.flags.Override(name, f)
+	s.audit(r, "flag_set", map[string]any{"flag": name, "percent": f.Percent})
+	s.respond(w, http.StatusOK, map[string]any{"flag": name, "state": f})
+}
+
+// handleFlagDelete drops a runtime override, reverting the flag to its
+// static configuration or full release.
+func (s *Server) handleFlagDelete(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := s.store.Delete(r.Context(), flags.Bucket, name); err != nil {
+		s.respondError(w, http.StatusInternalServerError, "deleting flag: "+err.Error())
+		return
+	}
+	s.flags.ClearOverride(name)
+	s.audit(r, "flag_clear", map[string]any{"flag": name})
+	s.respond(w, http.StatusOK, map[string]any{"flag": name, "cleared": true})
+}
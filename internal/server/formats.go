@@ -0,0 +1,27 @@
+package server
+
+import "net/http"
+
+// handleFormats advertises the input formats this deployment accepts,
+// so clients can decide whether to transcode before uploading. Image
+// formats are always available; animated and video support depend on
+// what external tooling is configured.
+func (s *Server) handleFormats(w http.ResponseWriter, r *http.Request) {
+	image := []string{"jpeg", "png", "gif", "webp"}
+	if s.cfg.ImageConverter != "" {
+		image = append(image, "heic", "avif")
+	}
+	animated := []string{"gif", "apng", "webp"}
+	if s.cfg.LottieRenderer != "" {
+		animated = append(animated, "lottie")
+	}
+	video := []string{}
+	if s.cfg.VideoFFmpeg != "" {
+		video = []string{"mp4", "webm", "avi"}
+	}
+	s.respond(w, http.StatusOK, map[string]any{
+		"image":    image,
+		"animated": animated,
+		"video":    video,
+	})
+}
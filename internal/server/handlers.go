@@ -0,0 +1,634 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/bench"
+	"github.com/illussioon/NFWS-Moderations-API/internal/bus"
+	"github.com/illussioon/NFWS-Moderations-API/internal/chaos"
+	"github.com/illussioon/NFWS-Moderations-API/internal/drift"
+	"github.com/illussioon/NFWS-Moderations-API/internal/export"
+	"github.com/illussioon/NFWS-Moderations-API/internal/inference"
+	"github.com/illussioon/NFWS-Moderations-API/internal/model"
+	"github.com/illussioon/NFWS-Moderations-API/internal/receipt"
+	"github.com/illussioon/NFWS-Moderations-API/internal/s3"
+	"github.com/illussioon/NFWS-Moderations-API/internal/scan"
+	"github.com/illussioon/NFWS-Moderations-API/internal/seal"
+	"github.com/illussioon/NFWS-Moderations-API/internal/selftest"
+	"github.com/illussioon/NFWS-Moderations-API/internal/stats"
+)
+
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	var req api.ScanRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxImageBytesFor(r)*2)).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	lang := preferredLang(r)
+	if fields := s.validateImageSource(&req, s.maxImageBytesFor(r), lang); fields != nil {
+		s.respondFieldErrors(w, lang, fields)
+		return
+	}
+	// Sampled-out submissions are auto-allowed before any download or
+	// model work; the scanned fraction keeps the NSFW rate measurable.
+	if k := keyFromContext(r.Context()); k != nil && k.ScanSampling != nil && rand.Float64() >= *k.ScanSampling {
+		s.stats.RecordSampledOut()
+		s.respond(w, http.StatusOK, &api.ScanResponse{SampledOut: true, Threshold: s.scanner.Threshold()})
+		return
+	}
+	downloadStart := time.Now()
+	data, err := s.imageBytes(r, &req)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	downloadMS := float64(time.Since(downloadStart).Microseconds()) / 1000
+	if limit := s.maxImageBytesFor(r); int64(len(data))*5 >= limit*4 {
+		warn(w, "image is %d bytes, near the %d byte limit", len(data), limit)
+	}
+	data = s.preprocessPlugin(r.Context(), data)
+
+	// Industry hash lists run ahead of inference: a known-content match
+	// is terminal and never reaches the models.
+	if resp := s.matchHashLists(r, &req, data); resp != nil {
+		s.deliverCallback(req.CallbackURL, resp)
+		s.respond(w, http.StatusOK, resp)
+		return
+	}
+	if req.Receipt && s.receipts == nil {
+		s.respondError(w, http.StatusBadRequest, "receipts require NFWS_RECEIPT_SECRET on the server")
+		return
+	}
+	opts, msg := s.scanOptions(r, &req)
+	if msg != "" {
+		s.respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+	cacheKey := s.scanCacheKey(r, &req, data)
+	if resp := s.cachedScanResponse(r.Context(), cacheKey); resp != nil {
+		// A hit still counts toward stats and leaves an audit record —
+		// the caller got a verdict either way — but skips the rest of the
+		// pipeline, which already ran for the original scan.
+		s.stats.RecordScan(resp.NSFW, nil)
+		s.auditScan(r, &req, resp, sha256.Sum256(data))
+		s.deliverCallback(req.CallbackURL, resp)
+		s.respond(w, http.StatusOK, redactResponse(r, resp))
+		return
+	}
+	resp, err := s.executeScan(r.Context(), r, &req, data, opts, cacheKey, downloadMS)
+	if err != nil {
+		s.scanError(w, err)
+		return
+	}
+	s.respond(w, http.StatusOK, redactResponse(r, resp))
+}
+
+// executeScan runs the scan pipeline once the image bytes are in hand:
+// inference, escalation, stats, and all the bookkeeping that follows a
+// verdict. It is shared by the synchronous handler and the async job
+// workers, which pass a detached request.
+func (s *Server) executeScan(ctx context.Context, r *http.Request, req *api.ScanRequest, data []byte, opts scan.Options, cacheKey string, downloadMS float64) (*api.ScanResponse, error) {
+	scanStart := time.Now()
+	resp, err := s.scanner.ScanBytes(ctx, data, opts)
+	if err != nil {
+		s.stats.RecordError()
+		return nil, err
+	}
+	s.escalate(ctx, data, resp)
+	s.adjustVerdict(ctx, resp)
+	ranModels := make([]string, 0, len(resp.Models))
+	for name, m := range resp.Models {
+		ranModels = append(ranModels, name)
+		s.drift.Record(name, m.NSFWScore)
+	}
+	s.stats.RecordScan(resp.NSFW, ranModels)
+	if resp.Timings != nil && req.URL != "" {
+		resp.Timings.DownloadMS = downloadMS
+	}
+	digest := sha256.Sum256(data)
+	s.storeVerdict(digest, resp)
+	s.recordReport(r, resp)
+	s.recordDataset(req, resp, digest, data)
+	s.auditScan(r, req, resp, digest)
+	if req.Receipt {
+		s.signReceipt(digest, resp)
+	}
+	s.uploadThumbnail(ctx, resp)
+	s.quarantineOriginal(ctx, resp, digest, data)
+	s.emitScanEvent(r, req, resp, time.Since(scanStart))
+	s.maybeWriteback(req.URL, resp)
+	s.deliverCallback(req.CallbackURL, resp)
+	s.storeScanResponse(ctx, cacheKey, resp)
+	return resp, nil
+}
+
+// scanOptions validates a scan request's tuning parameters and builds
+// the scanner options, shared by /scan and /scan/video. A non-empty msg
+// is the 400 error to return.
+func (s *Server) scanOptions(r *http.Request, req *api.ScanRequest) (opts scan.Options, msg string) {
+	switch req.Schema {
+	case "", api.SchemaV1, api.SchemaV2:
+	default:
+		return opts, "schema must be v1 or v2"
+	}
+	if !scan.ValidAggregation(req.Aggregation) {
+		return opts, "aggregation must be max, mean, topk, or streak"
+	}
+	if !scan.ValidCompat(req.Compat) {
+		return opts, "compat must be empty, safesearch, or rekognition"
+	}
+	if req.CallbackURL != "" && !strings.HasPrefix(req.CallbackURL, "http://") && !strings.HasPrefix(req.CallbackURL, "https://") {
+		return opts, "callback_url must be an http(s) URL"
+	}
+	opts = scan.Options{
+		Models:         req.Models,
+		Debug:          req.Debug,
+		Schema:         req.Schema,
+		Lang:           req.Lang,
+		Aggregation:    req.Aggregation,
+		AggregationK:   req.AggregationK,
+		Thumbnail:      req.Thumbnail,
+		Hashes:         req.Hashes,
+		AgeGate:        req.AgeGate,
+		Compat:         req.Compat,
+		Surface:        req.Surface,
+		StopOnFirstHit: req.StopOnFirstHit,
+		Cascade:        req.Cascade,
+	}
+	if k := keyFromContext(r.Context()); k != nil {
+		opts.Tenant = k.Name
+	}
+	// Cascade is feature-flagged: while its rollout ramps, tenants
+	// outside the flag silently get the full (non-cascaded) scan.
+	if opts.Cascade && !s.flags.Enabled("cascade", opts.Tenant) {
+		opts.Cascade = false
+	}
+	if req.Threshold != nil {
+		if *req.Threshold <= 0 || *req.Threshold > 1 {
+			return opts, "threshold must be in (0,1]"
+		}
+		opts.Threshold = *req.Threshold
+	}
+	if req.AdultThreshold != nil {
+		if *req.AdultThreshold <= 0 || *req.AdultThreshold > 1 {
+			return opts, "adult_threshold must be in (0,1]"
+		}
+		opts.AdultThreshold = *req.AdultThreshold
+	}
+	return opts, ""
+}
+
+// signReceipt attaches a signed verdict token to the response. Signing
+// is deterministic local crypto; a failure would be a bug, so it is
+// logged and the scan result still returns without a receipt.
+func (s *Server) signReceipt(digest [sha256.Size]byte, resp *api.ScanResponse) {
+	scores := make(map[string]float64, len(resp.Models))
+	for name, m := range resp.Models {
+		scores[name] = m.NSFWScore
+	}
+	token, err := s.receipts.Sign(receipt.Claims{
+		SHA256:       hex.EncodeToString(digest[:]),
+		ModelVersion: s.scanner.Registry().Fingerprint(),
+		NSFW:         resp.NSFW,
+		Score:        resp.Score,
+		Scores:       scores,
+	})
+	if err != nil {
+		s.log.Error("signing receipt failed", "err", err)
+		return
+	}
+	resp.Receipt = token
+}
+
+// uploadThumbnail moves an inline thumbnail to the configured bucket,
+// replacing the base64 payload with its s3:// URL. Without a bucket (or
+// on upload failure) the inline payload stays. With payload sealing
+// configured the object is a sealed envelope rather than a raw JPEG.
+func (s *Server) uploadThumbnail(ctx context.Context, resp *api.ScanResponse) {
+	if resp.ThumbnailBase64 == "" || s.cfg.ThumbnailBucket == "" || !s.s3.Configured() {
+		return
+	}
+	thumb, err := base64.StdEncoding.DecodeString(resp.ThumbnailBase64)
+	if err != nil {
+		return
+	}
+	key := fmt.Sprintf("thumbnails/%x.jpg", sha256.Sum256(thumb))
+	contentType := "image/jpeg"
+	body, err := s.sealer.Seal(thumb)
+	if err != nil {
+		s.log.Error("sealing thumbnail failed", "err", err)
+		return
+	}
+	if seal.Sealed(body) {
+		key += ".sealed"
+		contentType = "application/octet-stream"
+	}
+	if err := s.s3.PutObject(ctx, s.cfg.ThumbnailBucket, key, contentType, body); err != nil {
+		s.log.Error("thumbnail upload failed", "bucket", s.cfg.ThumbnailBucket, "key", key, "err", err)
+		return
+	}
+	resp.ThumbnailBase64 = ""
+	resp.ThumbnailURL = fmt.Sprintf("s3://%s/%s", s.cfg.ThumbnailBucket, key)
+}
+
+// maybeWriteback records the verdict on the scanned S3 object when
+// write-back is configured and the scan source was an s3:// URL. It runs
+// asynchronously and best-effort: a failed write-back is logged, never
+// surfaced to the scan caller.
+func (s *Server) maybeWriteback(rawURL string, resp *api.ScanResponse) {
+	if s.cfg.S3WritebackMode == s3.WritebackOff || !s.s3.Configured() {
+		return
+	}
+	bucket, key, ok := s3.ParseURL(rawURL)
+	if !ok {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := s.s3.Writeback(ctx, s.cfg.S3WritebackMode, bucket, key, resp); err != nil {
+			s.log.Error("s3 writeback failed", "bucket", bucket, "key", key, "err", err)
+		}
+	}()
+}
+
+// emitScanEvent publishes the scan on the event bus: scan.completed
+// always, scan.flagged additionally for NSFW verdicts. Subscribers
+// (exporters, webhooks) take it from there.
+func (s *Server) emitScanEvent(r *http.Request, req *api.ScanRequest, resp *api.ScanResponse, elapsed time.Duration) {
+	source := "upload"
+	if req.URL != "" {
+		source = "url"
+	}
+	scores := make(map[string]float64, len(resp.Models))
+	var detections []export.EventDetection
+	for name, m := range resp.Models {
+		scores[name] = m.NSFWScore
+		for _, d := range m.Detections {
+			detections = append(detections, export.EventDetection{Model: name, Label: d.Label, Score: d.Score})
+		}
+	}
+	ev := export.Event{
+		Time:        time.Now().UTC(),
+		Source:      source,
+		Format:      resp.Format,
+		Width:       resp.Width,
+		Height:      resp.Height,
+		NSFW:        resp.NSFW,
+		Score:       resp.Score,
+		Threshold:   resp.Threshold,
+		ModelScores: scores,
+		Detections:  detections,
+		DurationMS:  float64(elapsed.Microseconds()) / 1000,
+		ExternalID:  req.ExternalID,
+		BatchID:     req.BatchID,
+	}
+	if k := keyFromContext(r.Context()); k != nil {
+		ev.Tenant = k.Name
+	}
+	s.events.Publish(bus.ScanCompleted, ev)
+	if resp.NSFW {
+		s.events.Publish(bus.ScanFlagged, ev)
+	}
+}
+
+// maxImageBytesFor returns the image size cap for a request: the
+// caller's per-key override when its key entry sets one, otherwise the
+// server-wide limit.
+func (s *Server) maxImageBytesFor(r *http.Request) int64 {
+	if k := keyFromContext(r.Context()); k != nil && k.MaxImageBytes > 0 {
+		return k.MaxImageBytes
+	}
+	return s.cfg.MaxImageBytes
+}
+
+// validateImageSource checks the image-source fields before any
+// download or decode work: exactly one of url and image_base64, a
+// fetchable URL scheme, and a base64 payload that cannot exceed the
+// size limit once decoded. It returns one entry per violated rule with
+// messages in lang, or nil when the source is usable.
+func (s *Server) validateImageSource(req *api.ScanRequest, limit int64, lang string) []api.FieldError {
+	var fields []api.FieldError
+	switch {
+	case req.URL != "" && req.ImageBase64 != "":
+		fields = append(fields,
+			api.FieldError{Field: "url", Rule: "exclusive", Message: errorMessage(lang, "exclusive")},
+			api.FieldError{Field: "image_base64", Rule: "exclusive", Message: errorMessage(lang, "exclusive")})
+	case req.URL == "" && req.ImageBase64 == "":
+		fields = append(fields,
+			api.FieldError{Field: "url", Rule: "required", Message: errorMessage(lang, "required")},
+			api.FieldError{Field: "image_base64", Rule: "required", Message: errorMessage(lang, "required")})
+	case req.URL != "":
+		if !strings.HasPrefix(req.URL, "http://") && !strings.HasPrefix(req.URL, "https://") && !strings.HasPrefix(req.URL, "s3://") {
+			fields = append(fields, api.FieldError{Field: "url", Rule: "scheme", Message: errorMessage(lang, "scheme")})
+		}
+	default:
+		// Base64 expands 3 bytes into 4 characters, so the decoded size
+		// is known without decoding.
+		if int64(len(req.ImageBase64))/4*3 > limit {
+			fields = append(fields, api.FieldError{Field: "image_base64", Rule: "max_size", Message: errorMessage(lang, "max_size", limit)})
+		}
+	}
+	return fields
+}
+
+// imageBytes resolves the request's image source to raw bytes.
+func (s *Server) imageBytes(r *http.Request, req *api.ScanRequest) ([]byte, error) {
+	limit := s.maxImageBytesFor(r)
+	switch {
+	case strings.HasPrefix(req.URL, "s3://"):
+		bucket, key, ok := s3.ParseURL(req.URL)
+		if !ok {
+			return nil, errors.New("invalid s3:// URL, expected s3://bucket/key")
+		}
+		if !s.s3.Configured() {
+			return nil, errors.New("s3:// URLs require S3 configuration (NFWS_S3_ENDPOINT)")
+		}
+		return s.s3.GetObject(r.Context(), bucket, key, limit)
+	case req.URL != "":
+		if data, ok := s.cachedDownload(r.Context(), req.URL, limit); ok {
+			return data, nil
+		}
+		data, err := s.scanner.FetchLimit(r.Context(), req.URL, limit)
+		if err == nil {
+			s.storeDownload(r.Context(), req.URL, data)
+		}
+		return data, err
+	case req.ImageBase64 != "":
+		data, err := base64.StdEncoding.DecodeString(req.ImageBase64)
+		if err != nil {
+			return nil, errors.New("image_base64 is not valid base64")
+		}
+		if int64(len(data)) > limit {
+			return nil, errors.New("image exceeds size limit")
+		}
+		return data, nil
+	default:
+		return nil, errors.New("one of url or image_base64 is required")
+	}
+}
+
+// scanError maps scan failures to status codes via the scan package's
+// sentinel errors; anything unclassified is the caller's input failing
+// to process.
+func (s *Server) scanError(w http.ResponseWriter, err error) {
+	var maint *model.MaintenanceError
+	switch {
+	case errors.Is(err, inference.ErrRuntimeUnavailable):
+		s.respondError(w, http.StatusServiceUnavailable, err.Error())
+	case errors.As(err, &maint):
+		s.respondError(w, http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, scan.ErrModelNotFound):
+		s.respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, scan.ErrImageTooLarge):
+		s.respondError(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, scan.ErrInferenceTimeout):
+		s.respondError(w, http.StatusGatewayTimeout, err.Error())
+	case errors.Is(err, scan.ErrDecode):
+		s.respondError(w, http.StatusUnprocessableEntity, err.Error())
+	default:
+		s.respondError(w, http.StatusUnprocessableEntity, err.Error())
+	}
+}
+
+// handleModelDisable puts a model into maintenance: its sessions stay
+// loaded (no multi-GB reload when the investigation ends) but scans
+// that would use it fail with MODEL_IN_MAINTENANCE — or, with
+// "fallback": true, default-set scans silently continue on the
+// remaining models.
+func (s *Server) handleModelDisable(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	var req struct {
+		Fallback bool `json:"fallback"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if err := s.scanner.Registry().SetMaintenance(name, req.Fallback); err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	s.log.Warn("model disabled for maintenance", "model", name, "fallback", req.Fallback)
+	s.audit(r, "model_disable", map[string]any{"model": name, "fallback": req.Fallback})
+	s.respond(w, http.StatusOK, map[string]any{
+		"model":       name,
+		"maintenance": true,
+		"fallback":    req.Fallback,
+	})
+}
+
+// handleModelEnable takes a model out of maintenance.
+func (s *Server) handleModelEnable(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := s.scanner.Registry().ClearMaintenance(name); err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	s.log.Warn("model re-enabled", "model", name)
+	s.audit(r, "model_enable", map[string]any{"model": name})
+	s.respond(w, http.StatusOK, map[string]any{
+		"model":       name,
+		"maintenance": false,
+	})
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	type modelInfo struct {
+		Name        string   `json:"name"`
+		Kind        string   `json:"kind"`
+		InputSize   int      `json:"input_size"`
+		Classes     []string `json:"classes"`
+		SizeBytes   int64    `json:"size_bytes"`
+		Checksum    string   `json:"checksum"`
+		LoadMS      float64  `json:"load_ms"`
+		LoadedAt    string   `json:"loaded_at"`
+		Version     string   `json:"version"`
+		Versions    []string `json:"versions,omitempty"`
+		Maintenance bool     `json:"maintenance,omitempty"`
+		Provider    string   `json:"provider"`
+		GPU         bool     `json:"gpu"`
+		// Quantization is the active variant's precision label (int8 or
+		// fp16); absent for full-precision models.
+		Quantization string `json:"quantization,omitempty"`
+	}
+	registry := s.scanner.Registry()
+	models := registry.All()
+	out := make([]modelInfo, 0, len(models))
+	for _, m := range models {
+		disabled, _ := registry.Maintenance(m.Spec.Name)
+		provider := registry.Engine().Provider(m.Spec.Name)
+		out = append(out, modelInfo{
+			Name:         m.Spec.Name,
+			Kind:         string(m.Spec.Kind),
+			InputSize:    m.Spec.InputSize,
+			Classes:      m.Spec.Classes,
+			SizeBytes:    m.SizeBytes,
+			Checksum:     m.Checksum,
+			LoadMS:       float64(m.LoadDuration.Microseconds()) / 1000,
+			LoadedAt:     m.LoadedAt.UTC().Format(time.RFC3339),
+			Version:      m.Version,
+			Versions:     registry.Versions(m.Spec.Name),
+			Maintenance:  disabled,
+			Provider:     provider,
+			GPU:          provider == "cuda",
+			Quantization: m.Quantization(),
+		})
+	}
+	s.respondCached(w, r, map[string]any{"models": out})
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	out := struct {
+		stats.Snapshot
+		// Drift reports per-model distribution drift against each
+		// model's baseline window.
+		Drift map[string]drift.ModelStatus `json:"drift,omitempty"`
+		// Adaptive reports the latency-steered concurrency controller.
+		Adaptive *adaptiveStatus `json:"adaptive,omitempty"`
+	}{Snapshot: s.stats.Snapshot(r.Context()), Drift: s.drift.Status()}
+	if s.adaptive != nil {
+		limit, p95 := s.adaptive.status()
+		out.Adaptive = &adaptiveStatus{
+			Limit:       limit,
+			P95MS:       float64(p95.Microseconds()) / 1000,
+			TargetP95MS: float64(s.cfg.AdaptiveTargetP95.Microseconds()) / 1000,
+		}
+	}
+	s.respondCached(w, r, out)
+}
+
+func (s *Server) handleChaosGet(w http.ResponseWriter, r *http.Request) {
+	s.respond(w, http.StatusOK, s.chaos.Get())
+}
+
+func (s *Server) handleChaosPut(w http.ResponseWriter, r *http.Request) {
+	var cfg chaos.Config
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if err := s.chaos.Set(cfg); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.log.Warn("chaos configuration updated", "config", cfg)
+	s.audit(r, "chaos_update", map[string]any{"config": cfg})
+	s.respond(w, http.StatusOK, s.chaos.Get())
+}
+
+func (s *Server) handleSelfTest(w http.ResponseWriter, r *http.Request) {
+	report, err := selftest.Run(r.Context(), s.scanner)
+	if err != nil {
+		s.scanError(w, err)
+		return
+	}
+	status := http.StatusOK
+	if !report.Passed {
+		status = http.StatusInternalServerError
+	}
+	s.respond(w, status, report)
+}
+
+// handleModelActivate switches the version serving unpinned requests.
+func (s *Server) handleModelActivate(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	var req struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if req.Version == "" {
+		s.respondError(w, http.StatusBadRequest, "version is required")
+		return
+	}
+	if err := s.scanner.Registry().Activate(name, req.Version); err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	s.log.Warn("model version activated", "model", name, "version", req.Version)
+	s.audit(r, "model_activate", map[string]any{"model": name, "version": req.Version})
+	s.events.Publish(bus.ModelLoaded, map[string]any{"model": name, "version": req.Version})
+	s.respond(w, http.StatusOK, map[string]string{
+		"model":   name,
+		"version": req.Version,
+	})
+}
+
+// handleModelRollback reverts the alias to the previously active
+// version, so a bad model push can be undone in seconds.
+func (s *Server) handleModelRollback(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	from, to, err := s.scanner.Registry().Rollback(name)
+	if err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	s.log.Warn("model version rolled back", "model", name, "from", from, "to", to)
+	s.audit(r, "model_rollback", map[string]any{"model": name, "from": from, "to": to})
+	s.events.Publish(bus.ModelLoaded, map[string]any{"model": name, "version": to})
+	s.respond(w, http.StatusOK, map[string]string{
+		"model": name,
+		"from":  from,
+		"to":    to,
+	})
+}
+
+// benchRequest is the body of POST /admin/models/{name}/bench.
+type benchRequest struct {
+	Iterations  int `json:"iterations"`
+	Concurrency int `json:"concurrency"`
+	// ImagesBase64 optionally supplies real images to benchmark with; a
+	// synthetic test pattern is used otherwise.
+	ImagesBase64 []string `json:"images_base64,omitempty"`
+}
+
+func (s *Server) handleModelBench(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	m, ok := s.scanner.Registry().Get(name)
+	if !ok {
+		s.respondError(w, http.StatusNotFound, "unknown model "+name)
+		return
+	}
+	var req benchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.cfg.MaxImageBytes*4)).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	params := bench.Params{Iterations: req.Iterations, Concurrency: req.Concurrency}
+	for _, b64 := range req.ImagesBase64 {
+		data, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, "images_base64 entry is not valid base64")
+			return
+		}
+		params.Images = append(params.Images, data)
+	}
+	result, err := bench.Run(r.Context(), s.scanner, m, params)
+	if err != nil {
+		s.scanError(w, err)
+		return
+	}
+	s.audit(r, "model_bench", map[string]any{
+		"model":       name,
+		"iterations":  params.Iterations,
+		"concurrency": params.Concurrency,
+	})
+	s.respond(w, http.StatusOK, result)
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/hashlist"
+)
+
+// maxLookupHashes caps one lookup batch.
+const maxLookupHashes = 1000
+
+// hashLookupRequest is the body of POST /hashes/lookup.
+type hashLookupRequest struct {
+	Hashes []struct {
+		Hash string `json:"hash"`
+		// Format disambiguates 64-character digests (pdq vs sha256);
+		// md5 and sha1 are inferred from the length.
+		Format string `json:"format,omitempty"`
+	} `json:"hashes"`
+}
+
+// hashLookupResult is one hash's lookup outcome.
+type hashLookupResult struct {
+	Hash    string `json:"hash"`
+	Matched bool   `json:"matched"`
+	// Match identifies the list entry the hash hit, when it did.
+	Match *api.HashMatch `json:"match,omitempty"`
+}
+
+// handleHashLookup checks precomputed hashes against the industry lists
+// and the victim-report blocklist without any image bytes changing
+// hands — the privacy-preserving pre-check for clients that hash
+// locally. Lookups leave no match-trail records and fire no alerts:
+// nothing was scanned, only asked about.
+func (s *Server) handleHashLookup(w http.ResponseWriter, r *http.Request) {
+	var req hashLookupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if len(req.Hashes) == 0 {
+		s.respondError(w, http.StatusBadRequest, "hashes is required")
+		return
+	}
+	if len(req.Hashes) > maxLookupHashes {
+		s.respondError(w, http.StatusBadRequest, fmt.Sprintf("at most %d hashes per lookup", maxLookupHashes))
+		return
+	}
+	results := make([]hashLookupResult, 0, len(req.Hashes))
+	for i, h := range req.Hashes {
+		hash := strings.ToLower(h.Hash)
+		format, msg := resolveHashFormat(hash, h.Format)
+		if msg != "" {
+			s.respondError(w, http.StatusBadRequest, fmt.Sprintf("hashes[%d]: %s", i, msg))
+			return
+		}
+		var bits []byte
+		if format == hashlist.FormatPDQ {
+			bits, _ = hex.DecodeString(hash)
+		}
+		match := s.hashlists.Lookup(format, hash, bits)
+		if match == nil {
+			match = s.lookupBlocklist(r.Context(), format, hash)
+		}
+		result := hashLookupResult{Hash: h.Hash, Matched: match != nil}
+		if match != nil {
+			result.Match = &api.HashMatch{List: match.List, Format: match.Format, Hash: match.Hash, Distance: match.Distance}
+		}
+		results = append(results, result)
+	}
+	s.respond(w, http.StatusOK, map[string]any{"results": results})
+}
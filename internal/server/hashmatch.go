@@ -0,0 +1,132 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/bus"
+	"github.com/illussioon/NFWS-Moderations-API/internal/imageproc"
+)
+
+// hashMatchBucket is the restricted trail of hash-list matches, kept
+// apart from the general audit bucket: its records identify known
+// illegal content and are readable only through the dedicated admin
+// endpoint, never through /admin/audit, its export, or purges.
+const hashMatchBucket = "hashlist_matches"
+
+// hashMatchRecord is one logged hash-list match.
+type hashMatchRecord struct {
+	Time time.Time `json:"time"`
+	// Tenant is the calling key's name; empty in single-key mode.
+	Tenant string `json:"tenant,omitempty"`
+	// List, Format, Hash, and Distance identify the matched list entry
+	// and how close the image's perceptual hash came to it.
+	List     string `json:"list"`
+	Format   string `json:"format"`
+	Hash     string `json:"hash"`
+	Distance int    `json:"distance"`
+	// SHA256 is the scanned image's digest, for cross-referencing with
+	// quarantine and verdict records.
+	SHA256 string `json:"sha256"`
+	// ExternalID and BatchID carry the caller's correlation IDs.
+	ExternalID string `json:"external_id,omitempty"`
+	BatchID    string `json:"batch_id,omitempty"`
+}
+
+// matchHashLists checks the image against the loaded industry hash
+// lists and the ingested victim-report blocklist. On a hit it records the restricted trail entry, publishes the mandatory
+// alert, and returns the terminal response: hash-list matches are
+// flagged outright and never reach the models. A nil return means no
+// match and the scan proceeds.
+func (s *Server) matchHashLists(r *http.Request, req *api.ScanRequest, data []byte) *api.ScanResponse {
+	var pdq []byte
+	if s.hashlists.NeedsPDQ() {
+		pdq = s.pdqOf(data)
+	}
+	match := s.hashlists.Match(data, pdq)
+	if match == nil {
+		match = s.matchBlocklist(r.Context(), data, pdq)
+	}
+	if match == nil {
+		return nil
+	}
+	digest := sha256.Sum256(data)
+	rec := hashMatchRecord{
+		Time:       time.Now().UTC(),
+		List:       match.List,
+		Format:     match.Format,
+		Hash:       match.Hash,
+		Distance:   match.Distance,
+		SHA256:     hex.EncodeToString(digest[:]),
+		ExternalID: req.ExternalID,
+		BatchID:    req.BatchID,
+	}
+	if k := keyFromContext(r.Context()); k != nil {
+		rec.Tenant = k.Name
+	}
+	if encoded, err := json.Marshal(rec); err == nil {
+		var suffix [4]byte
+		rand.Read(suffix[:])
+		key := fmt.Sprintf("%020d-%s", rec.Time.UnixNano(), hex.EncodeToString(suffix[:]))
+		if err := s.store.Put(r.Context(), hashMatchBucket, key, encoded); err != nil {
+			s.log.Error("writing hash match record failed", "list", match.List, "err", err)
+		}
+	}
+	s.log.Warn("image matched industry hash list", "list", match.List, "format", match.Format)
+	s.stats.RecordScan(true, nil)
+	s.events.Publish(bus.HashMatched, rec)
+	resp := &api.ScanResponse{
+		NSFW:      true,
+		Score:     1,
+		Threshold: s.scanner.Threshold(),
+		HashMatch: &api.HashMatch{List: match.List, Format: match.Format, Hash: match.Hash, Distance: match.Distance},
+	}
+	s.quarantineOriginal(r.Context(), resp, digest, data)
+	return resp
+}
+
+// pdqOf computes the image's PDQ hash for list matching; nil when the
+// bytes don't decode (the scan will fail with a proper error later).
+func (s *Server) pdqOf(data []byte) []byte {
+	img, _, err := imageproc.Decode(data)
+	if err != nil {
+		return nil
+	}
+	pdq := imageproc.PDQ(img)
+	return pdq[:]
+}
+
+// handleHashMatchList returns the most recent hash-list matches, newest
+// first. ?limit=N caps the result (default 100).
+func (s *Server) handleHashMatchList(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			s.respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+	records, err := s.store.List(r.Context(), hashMatchBucket, "", 0)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "listing hash matches: "+err.Error())
+		return
+	}
+	out := make([]hashMatchRecord, 0, limit)
+	for i := len(records) - 1; i >= 0 && len(out) < limit; i-- {
+		var rec hashMatchRecord
+		if err := json.Unmarshal(records[i].Value, &rec); err != nil {
+			continue
+		}
+		out = append(out, rec)
+	}
+	s.respond(w, http.StatusOK, map[string]any{"matches": out})
+}
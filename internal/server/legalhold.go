@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// holdBucket records image hashes under legal hold, keyed by digest.
+// Held hashes survive purge requests until the hold is lifted, which
+// legal requires for active investigations.
+const holdBucket = "legal_holds"
+
+// legalHold is one active hold.
+type legalHold struct {
+	SHA256 string    `json:"sha256"`
+	Reason string    `json:"reason,omitempty"`
+	Actor  string    `json:"actor"`
+	Time   time.Time `json:"time"`
+}
+
+// handleLegalHoldPut places a hold on an image hash. Re-holding an
+// already-held hash updates the reason.
+func (s *Server) handleLegalHoldPut(w http.ResponseWriter, r *http.Request) {
+	hash := chi.URLParam(r, "hash")
+	if len(hash) != 2*sha256.Size {
+		s.respondError(w, http.StatusBadRequest, "hash must be a 64-character hex digest")
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		s.respondError(w, http.StatusBadRequest, "decoding request: "+err.Error())
+		return
+	}
+	hold := legalHold{
+		SHA256: hash,
+		Reason: req.Reason,
+		Actor:  actorID(r.Header.Get("X-Admin-Key")),
+		Time:   time.Now().UTC(),
+	}
+	if k := keyFromContext(r.Context()); k != nil {
+		hold.Actor = k.Name
+	}
+	data, err := json.Marshal(hold)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "encoding hold: "+err.Error())
+		return
+	}
+	if err := s.store.Put(r.Context(), holdBucket, hash, data); err != nil {
+		s.respondError(w, http.StatusInternalServerError, "storing hold: "+err.Error())
+		return
+	}
+	s.audit(r, "legal_hold", map[string]any{"sha256": hash, "reason": req.Reason})
+	s.respond(w, http.StatusOK, hold)
+}
+
+// handleLegalHoldDelete lifts a hold, making the hash purgeable again.
+func (s *Server) handleLegalHoldDelete(w http.ResponseWriter, r *http.Request) {
+	hash := chi.URLParam(r, "hash")
+	if _, err := s.store.Get(r.Context(), holdBucket, hash); err != nil {
+		s.respondError(w, http.StatusNotFound, "no hold on "+hash)
+		return
+	}
+	if err := s.store.Delete(r.Context(), holdBucket, hash); err != nil {
+		s.respondError(w, http.StatusInternalServerError, "lifting hold: "+err.Error())
+		return
+	}
+	s.audit(r, "legal_hold_lift", map[string]any{"sha256": hash})
+	s.respond(w, http.StatusOK, map[string]any{"lifted": hash})
+}
+
+// handleLegalHoldList returns every active hold.
+func (s *Server) handleLegalHoldList(w http.ResponseWriter, r *http.Request) {
+	records, err := s.store.List(r.Context(), holdBucket, "", 0)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "listing holds: "+err.Error())
+		return
+	}
+	holds := make([]legalHold, 0, len(records))
+	for _, rec := range records {
+		var h legalHold
+		if err := json.Unmarshal(rec.Value, &h); err != nil {
+			continue
+		}
+		holds = append(holds, h)
+	}
+	s.respond(w, http.StatusOK, map[string]any{"holds": holds})
+}
+
+// held reports whether a hash is under legal hold.
+func (s *Server) held(ctx context.Context, sha string) bool {
+	_, err := s.store.Get(ctx, holdBucket, sha)
+	return err == nil
+}
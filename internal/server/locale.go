@@ -0,0 +1,57 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Validation failures reach end users through moderator consoles and
+// upload forms, so their messages are served in the caller's language
+// while the rule identifiers stay the stable machine-readable contract.
+// English is the source text; the table covers the languages the user
+// base runs in.
+
+// errorText maps language -> validation rule -> message format string.
+var errorText = map[string]map[string]string{
+	"en": {
+		"validation_failed": "request validation failed",
+		"required":          "one of url or image_base64 is required",
+		"exclusive":         "provide url or image_base64, not both",
+		"scheme":            "url scheme must be http, https, or s3",
+		"max_size":          "image exceeds the %d byte size limit",
+	},
+	"ru": {
+		"validation_failed": "запрос не прошёл проверку",
+		"required":          "требуется url или image_base64",
+		"exclusive":         "укажите url или image_base64, но не оба поля",
+		"scheme":            "схема url должна быть http, https или s3",
+		"max_size":          "изображение превышает лимит размера в %d байт",
+	},
+}
+
+// preferredLang picks the first Accept-Language tag with a translation
+// table, defaulting to English. Quality weights are ignored: the tags
+// already arrive in preference order from every client that matters
+// here.
+func preferredLang(r *http.Request) string {
+	for _, tag := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag = strings.TrimSpace(strings.SplitN(tag, ";", 2)[0])
+		if len(tag) >= 2 {
+			if lang := strings.ToLower(tag[:2]); errorText[lang] != nil {
+				return lang
+			}
+		}
+	}
+	return "en"
+}
+
+// errorMessage renders the message for a validation rule in lang,
+// falling back to English for gaps in a translation table.
+func errorMessage(lang, rule string, args ...any) string {
+	text, ok := errorText[lang][rule]
+	if !ok {
+		text = errorText["en"][rule]
+	}
+	return fmt.Sprintf(text, args...)
+}
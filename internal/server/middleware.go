@@ -0,0 +1,211 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/auth"
+)
+
+// authKeyContextKey carries the authenticated key entry through the
+// request context when a keys file is in use.
+type authKeyContextKey struct{}
+
+// keyFromContext returns the key entry that authenticated the request,
+// or nil in legacy single-key mode.
+func keyFromContext(ctx context.Context) *auth.Key {
+	k, _ := ctx.Value(authKeyContextKey{}).(*auth.Key)
+	return k
+}
+
+// requireRole gates a route group on the role attached to the caller's
+// key. With a keys file configured the X-API-Key header must name a key
+// whose role covers the required one; without it the legacy single-key
+// middleware applies (NFWS_API_KEY for scanning and reads, NFWS_ADMIN_KEY
+// for /admin).
+func (s *Server) requireRole(role auth.Role) func(http.Handler) http.Handler {
+	if s.keys == nil {
+		if role == auth.RoleAdmin {
+			return s.requireAdminKey
+		}
+		return s.requireAPIKey
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secret := r.Header.Get("X-API-Key")
+			if secret == "" {
+				secret = r.Header.Get("X-Admin-Key")
+			}
+			key, ok := s.keys.Lookup(secret)
+			if !ok {
+				s.respondError(w, http.StatusUnauthorized, "invalid or missing API key")
+				return
+			}
+			if !key.Role.Allows(role) {
+				s.respondError(w, http.StatusForbidden, "key role "+string(key.Role)+" may not use this endpoint")
+				return
+			}
+			ctx := context.WithValue(r.Context(), authKeyContextKey{}, key)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requireAPIKey enforces the X-API-Key header when an API key is
+// configured. With no key configured the scanning endpoints are open.
+func (s *Server) requireAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.cfg.APIKey != "" && !constantTimeEqual(r.Header.Get("X-API-Key"), s.cfg.APIKey) {
+			s.respondError(w, http.StatusUnauthorized, "invalid or missing API key")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAdminKey enforces the X-Admin-Key header. Unlike the API key,
+// admin endpoints are refused outright when no admin key is configured.
+func (s *Server) requireAdminKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.cfg.AdminKey == "" {
+			s.respondError(w, http.StatusForbidden, "admin endpoints disabled: no admin key configured")
+			return
+		}
+		if !constantTimeEqual(r.Header.Get("X-Admin-Key"), s.cfg.AdminKey) {
+			s.respondError(w, http.StatusUnauthorized, "invalid or missing admin key")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func constantTimeEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// limitInFlight caps how many requests of one endpoint class are served
+// concurrently, shedding the overflow with 503 before any body decoding
+// or inference happens. A zero limit disables the cap. The semaphore is
+// shared by every group the returned middleware is mounted on, so the
+// ceiling is global for the class. With NFWS_QUEUE_WAIT set the
+// overflow queues for a slot instead, and a queued request whose client
+// hangs up is dropped without ever running — during a retry storm the
+// abandoned originals would otherwise burn the capacity their retries
+// are waiting on.
+func (s *Server) limitInFlight(class string, limit int) func(http.Handler) http.Handler {
+	if limit <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	sem := make(chan struct{}, limit)
+	sat := &saturation{}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case sem <- struct{}{}:
+			default:
+				if s.cfg.QueueWait <= 0 {
+					s.shedResponse(w, r, class, limit, sat)
+					return
+				}
+				if err := s.bufferQueuedBody(r); err != nil {
+					s.dropAbandoned(r, class)
+					return
+				}
+				deadline := time.NewTimer(s.cfg.QueueWait)
+				defer deadline.Stop()
+				select {
+				case sem <- struct{}{}:
+					// The slot and the hang-up can become ready together;
+					// don't spend the slot on a dead request.
+					if r.Context().Err() != nil {
+						<-sem
+						s.dropAbandoned(r, class)
+						return
+					}
+				case <-r.Context().Done():
+					s.dropAbandoned(r, class)
+					return
+				case <-deadline.C:
+					s.shedResponse(w, r, class, limit, sat)
+					return
+				}
+			}
+			defer func() {
+				<-sem
+				sat.complete()
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bufferQueuedBody reads the request body into memory before the
+// request parks in the queue. net/http only watches the connection for
+// a client hang-up once the body has been consumed, so without this a
+// disconnect while queued would go unnoticed until the handler ran.
+// Reads are capped at the largest body any handler accepts; a longer
+// tail stays on the wire for MaxBytesReader to refuse as before.
+func (s *Server) bufferQueuedBody(r *http.Request) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 4*s.cfg.MaxImageBytes))
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	return nil
+}
+
+// dropAbandoned accounts for a queued request whose client disconnected
+// before a slot freed up. Nothing is written: the connection is gone.
+func (s *Server) dropAbandoned(r *http.Request, class string) {
+	s.stats.RecordAbandoned()
+	s.log.Info("queued request abandoned by client", "class", class, "path", r.URL.Path)
+}
+
+// logRequests emits one structured log line per request, honoring the
+// per-key sampling rate for high-volume keys. Failed requests always
+// get a line.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		attrs := []any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", sw.status,
+			"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if s.keys != nil {
+			if key, ok := s.keys.Lookup(r.Header.Get("X-API-Key")); ok {
+				s.stats.RecordBytes(key.Name, max(r.ContentLength, 0), sw.written)
+				if sw.status < http.StatusBadRequest && key.LogSampling != nil && rand.Float64() >= *key.LogSampling {
+					return
+				}
+				attrs = append(attrs, "key", key.Name)
+			}
+		}
+		s.log.Info("request", attrs...)
+	})
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/bus"
+	"github.com/illussioon/NFWS-Moderations-API/internal/model"
+)
+
+// maxModelUploadBytes bounds one uploaded ONNX file.
+const maxModelUploadBytes = 512 << 20
+
+// modelNamePattern keeps uploaded model names path-safe: the name
+// becomes the file name inside the models directory.
+var modelNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// preprocessProfile maps a named preprocessing profile to the
+// per-channel mean and scale it implies. "zero-one" is the default and
+// matches models trained on [0,1] inputs.
+func preprocessProfile(name string) (mean, scale [3]float32, ok bool) {
+	switch name {
+	case "", "zero-one":
+		return [3]float32{0, 0, 0}, [3]float32{1 / 255.0, 1 / 255.0, 1 / 255.0}, true
+	case "center":
+		return [3]float32{127.5, 127.5, 127.5}, [3]float32{1 / 127.5, 1 / 127.5, 1 / 127.5}, true
+	case "imagenet":
+		return [3]float32{123.675, 116.28, 103.53}, [3]float32{1 / 58.395, 1 / 57.12, 1 / 57.375}, true
+	case "raw":
+		return [3]float32{0, 0, 0}, [3]float32{1, 1, 1}, true
+	default:
+		return mean, scale, false
+	}
+}
+
+// handleModelUpload implements POST /admin/models: a multipart .onnx
+// upload plus metadata fields (name, kind, input_size, classes,
+// nsfw_classes, preprocess, bgr). The registry smoke-tests the model
+// with a test inference before it starts serving, so a broken or
+// mislabeled file is rejected here rather than on a tenant's scan.
+func (s *Server) handleModelUpload(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxModelUploadBytes+(1<<20))
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid multipart body: "+err.Error())
+		return
+	}
+	name := r.FormValue("name")
+	if !modelNamePattern.MatchString(name) {
+		s.respondError(w, http.StatusBadRequest, "name must be lowercase letters, digits, '_' or '-'")
+		return
+	}
+	kind := model.Kind(r.FormValue("kind"))
+	if kind == "" {
+		kind = model.KindClassifier
+	}
+	if kind != model.KindClassifier && kind != model.KindDetector {
+		s.respondError(w, http.StatusBadRequest, "kind must be classifier or detector")
+		return
+	}
+	inputSize, err := strconv.Atoi(r.FormValue("input_size"))
+	if err != nil || inputSize <= 0 || inputSize > 4096 {
+		s.respondError(w, http.StatusBadRequest, "input_size must be a side length in (0,4096]")
+		return
+	}
+	classes := splitCommaList(r.FormValue("classes"))
+	if len(classes) == 0 {
+		s.respondError(w, http.StatusBadRequest, "classes is required")
+		return
+	}
+	nsfwClasses := splitCommaList(r.FormValue("nsfw_classes"))
+	for _, c := range nsfwClasses {
+		known := false
+		for _, k := range classes {
+			known = known || k == c
+		}
+		if !known {
+			s.respondError(w, http.StatusBadRequest, "nsfw_classes entry "+c+" is not in classes")
+			return
+		}
+	}
+	mean, scale, ok := preprocessProfile(r.FormValue("preprocess"))
+	if !ok {
+		s.respondError(w, http.StatusBadRequest, "preprocess must be zero-one, center, imagenet, or raw")
+		return
+	}
+	file, header, err := r.FormFile("model")
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "model file is required")
+		return
+	}
+	defer file.Close()
+	if filepath.Ext(header.Filename) != ".onnx" {
+		s.respondError(w, http.StatusBadRequest, "model file must be .onnx")
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxModelUploadBytes+1))
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "reading model file: "+err.Error())
+		return
+	}
+	if len(data) == 0 || len(data) > maxModelUploadBytes {
+		s.respondError(w, http.StatusBadRequest, "model file must be between 1 byte and 512 MiB")
+		return
+	}
+	spec := model.Spec{
+		Name:        name,
+		FileName:    name + ".onnx",
+		Kind:        kind,
+		InputSize:   inputSize,
+		Mean:        mean,
+		Scale:       scale,
+		BGR:         r.FormValue("bgr") == "true",
+		Classes:     classes,
+		NSFWClasses: nsfwClasses,
+	}
+	loaded, err := s.scanner.Registry().AddModel(spec, data)
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "already exists") {
+			status = http.StatusConflict
+		}
+		s.respondError(w, status, err.Error())
+		return
+	}
+	s.audit(r, "model_upload", map[string]any{"model": name, "size_bytes": loaded.SizeBytes})
+	s.events.Publish(bus.ModelLoaded, map[string]any{"model": name, "version": loaded.Version})
+	s.respond(w, http.StatusCreated, map[string]any{
+		"name":       name,
+		"version":    loaded.Version,
+		"size_bytes": loaded.SizeBytes,
+	})
+}
+
+// splitCommaList splits a comma-separated form value, dropping blanks.
+func splitCommaList(v string) []string {
+	var out []string
+	for _, t := range strings.Split(v, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
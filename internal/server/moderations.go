@@ -0,0 +1,194 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/model"
+	"github.com/illussioon/NFWS-Moderations-API/internal/scan"
+)
+
+// The OpenAI moderation compatibility endpoint. Tools built against
+// that API can point at this service unchanged; image inputs run
+// through the local models and fold into the "sexual" category.
+
+// moderationsRequest is the OpenAI request shape. Input may be a single
+// string or an array of strings / {"type": "image_url"} objects; only
+// image inputs (URLs and data: URLs) are supported here.
+type moderationsRequest struct {
+	Input json.RawMessage `json:"input"`
+	Model string          `json:"model"`
+}
+
+type moderationsResponse struct {
+	ID      string             `json:"id"`
+	Model   string             `json:"model"`
+	Results []moderationResult `json:"results"`
+}
+
+type moderationResult struct {
+	Flagged        bool               `json:"flagged"`
+	Categories     map[string]bool    `json:"categories"`
+	CategoryScores map[string]float64 `json:"category_scores"`
+}
+
+// moderationCategories is the full OpenAI category set. Categories the
+// loaded models can't assess stay at zero so downstream code that
+// expects every key keeps working.
+var moderationCategories = []string{
+	"sexual", "sexual/minors",
+	"harassment", "harassment/threatening",
+	"hate", "hate/threatening",
+	"illicit", "illicit/violent",
+	"self-harm", "self-harm/intent", "self-harm/instructions",
+	"violence", "violence/graphic",
+}
+
+// handleModerations serves POST /v1/moderations.
+func (s *Server) handleModerations(w http.ResponseWriter, r *http.Request) {
+	var req moderationsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxImageBytesFor(r)*2)).Decode(&req); err != nil {
+		s.moderationsError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	inputs, err := moderationInputs(req.Input)
+	if err != nil {
+		s.moderationsError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(inputs) == 0 {
+		s.moderationsError(w, http.StatusBadRequest, "input is required")
+		return
+	}
+	modelName := req.Model
+	if modelName == "" {
+		modelName = "nfws-" + s.scanner.Registry().Fingerprint()
+	}
+	out := moderationsResponse{
+		ID:    "modr-" + randomHex(12),
+		Model: modelName,
+	}
+	for _, input := range inputs {
+		data, err := s.moderationImage(r, input)
+		if err != nil {
+			s.moderationsError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		resp, err := s.scanner.ScanBytes(r.Context(), data, scan.Options{Schema: api.SchemaV2})
+		if err != nil {
+			s.stats.RecordError()
+			s.moderationsError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		ranModels := make([]string, 0, len(resp.Models))
+		for name := range resp.Models {
+			ranModels = append(ranModels, name)
+		}
+		s.stats.RecordScan(resp.NSFW, ranModels)
+		out.Results = append(out.Results, moderationResultFrom(resp))
+	}
+	s.respond(w, http.StatusOK, out)
+}
+
+// moderationResultFrom maps a scan onto OpenAI's category set: sexual
+// carries the highest explicit-category score (nudity or hentai).
+func moderationResultFrom(resp *api.ScanResponse) moderationResult {
+	res := moderationResult{
+		Flagged:        resp.NSFW,
+		Categories:     make(map[string]bool, len(moderationCategories)),
+		CategoryScores: make(map[string]float64, len(moderationCategories)),
+	}
+	for _, c := range moderationCategories {
+		res.Categories[c] = false
+		res.CategoryScores[c] = 0
+	}
+	sexual := resp.Categories[model.CategoryNudity]
+	if h := resp.Categories[model.CategoryHentai]; h.Score > sexual.Score {
+		sexual = h
+	}
+	res.Categories["sexual"] = sexual.Flagged
+	res.CategoryScores["sexual"] = sexual.Score
+	return res
+}
+
+// moderationInputs flattens the polymorphic input field into a list of
+// image references.
+func moderationInputs(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return []string{single}, nil
+	}
+	var parts []json.RawMessage
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return nil, errInvalidModerationInput
+	}
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		var str string
+		if err := json.Unmarshal(p, &str); err == nil {
+			out = append(out, str)
+			continue
+		}
+		var obj struct {
+			Type     string `json:"type"`
+			ImageURL struct {
+				URL string `json:"url"`
+			} `json:"image_url"`
+		}
+		if err := json.Unmarshal(p, &obj); err != nil || obj.Type != "image_url" || obj.ImageURL.URL == "" {
+			return nil, errInvalidModerationInput
+		}
+		out = append(out, obj.ImageURL.URL)
+	}
+	return out, nil
+}
+
+var errInvalidModerationInput = &moderationInputError{}
+
+type moderationInputError struct{}
+
+func (*moderationInputError) Error() string {
+	return `input must be a string or an array of strings / {"type": "image_url"} objects`
+}
+
+// moderationImage resolves one input reference — an http(s) URL or a
+// data: URL — to image bytes.
+func (s *Server) moderationImage(r *http.Request, input string) ([]byte, error) {
+	limit := s.maxImageBytesFor(r)
+	if strings.HasPrefix(input, "data:") {
+		_, b64, ok := strings.Cut(input, ";base64,")
+		if !ok {
+			return nil, &moderationInputError{}
+		}
+		data, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil || int64(len(data)) > limit {
+			return nil, &moderationInputError{}
+		}
+		return data, nil
+	}
+	return s.scanner.FetchLimit(r.Context(), input, limit)
+}
+
+// moderationsError writes OpenAI's error envelope, which differs from
+// the service's own.
+func (s *Server) moderationsError(w http.ResponseWriter, status int, msg string) {
+	s.respond(w, status, map[string]any{"error": map[string]any{
+		"message": msg,
+		"type":    "invalid_request_error",
+	}})
+}
+
+// randomHex returns n random bytes hex-encoded.
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/plugin"
+)
+
+// pluginVerdict is what the verdict hook reads on stdin.
+type pluginVerdict struct {
+	NSFW      bool               `json:"nsfw"`
+	Score     float64            `json:"score"`
+	Threshold float64            `json:"threshold"`
+	Severity  string             `json:"severity,omitempty"`
+	Models    map[string]float64 `json:"models"`
+}
+
+// pluginAdjustment is what the verdict hook may write back; absent
+// fields leave the verdict untouched.
+type pluginAdjustment struct {
+	NSFW  *bool    `json:"nsfw,omitempty"`
+	Score *float64 `json:"score,omitempty"`
+}
+
+// preprocessPlugin gives the preprocess hook a chance to rewrite the
+// image bytes before decoding. Fails open: any plugin error keeps the
+// original bytes.
+func (s *Server) preprocessPlugin(ctx context.Context, data []byte) []byte {
+	if s.plugin == nil || s.plugin.Hook() != plugin.HookPreprocess {
+		return data
+	}
+	out, err := s.plugin.Run(ctx, data)
+	if err != nil {
+		s.log.Error("preprocess plugin failed, using original image", "err", err)
+		return data
+	}
+	if len(out) == 0 {
+		return data
+	}
+	return out
+}
+
+// adjustVerdict gives the verdict hook a chance to override the overall
+// score and flag. Fails open: any plugin error keeps the model verdict.
+func (s *Server) adjustVerdict(ctx context.Context, resp *api.ScanResponse) {
+	if s.plugin == nil || s.plugin.Hook() != plugin.HookVerdict {
+		return
+	}
+	in := pluginVerdict{
+		NSFW:      resp.NSFW,
+		Score:     resp.Score,
+		Threshold: resp.Threshold,
+		Severity:  resp.Severity,
+		Models:    make(map[string]float64, len(resp.Models)),
+	}
+	for name, m := range resp.Models {
+		in.Models[name] = m.NSFWScore
+	}
+	input, err := json.Marshal(in)
+	if err != nil {
+		return
+	}
+	out, err := s.plugin.Run(ctx, input)
+	if err != nil {
+		s.log.Error("verdict plugin failed, keeping model verdict", "err", err)
+		return
+	}
+	var adj pluginAdjustment
+	if err := json.Unmarshal(out, &adj); err != nil {
+		s.log.Error("verdict plugin wrote invalid JSON, keeping model verdict", "err", err)
+		return
+	}
+	if adj.Score != nil {
+		resp.Score = *adj.Score
+		resp.NSFW = resp.Score >= resp.Threshold
+	}
+	if adj.NSFW != nil {
+		resp.NSFW = *adj.NSFW
+	}
+}
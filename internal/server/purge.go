@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// purgeResult reports what an erasure request actually removed.
+type purgeResult struct {
+	// Hashes are the image digests the purge resolved to and erased.
+	Hashes []string `json:"hashes"`
+	// Per-bucket deletion counts.
+	Verdicts int `json:"verdicts_deleted"`
+	Feedback int `json:"feedback_deleted"`
+	Dataset  int `json:"dataset_deleted"`
+	// AuditRedacted counts audit records whose params were blanked. The
+	// records themselves stay, so the trail still shows that a scan
+	// happened — just not of what.
+	AuditRedacted int `json:"audit_redacted"`
+	// Held lists hashes the purge resolved to but left untouched because
+	// they are under legal hold.
+	Held []string `json:"held,omitempty"`
+}
+
+// handlePurgeByHash erases every stored trace of one image: its verdict,
+// feedback, and dataset records are deleted and matching audit records
+// redacted in place. For right-to-erasure requests against the stores.
+func (s *Server) handlePurgeByHash(w http.ResponseWriter, r *http.Request) {
+	hash := chi.URLParam(r, "hash")
+	if len(hash) != 2*sha256.Size {
+		s.respondError(w, http.StatusBadRequest, "hash must be a 64-character hex digest")
+		return
+	}
+	res, err := s.purge(r.Context(), map[string]bool{hash: true}, "")
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "purging records: "+err.Error())
+		return
+	}
+	s.audit(r, "purge", map[string]any{"by": "hash", "hashes": res.Hashes})
+	s.respond(w, http.StatusOK, res)
+}
+
+// handlePurgeByExternalID erases by upstream correlation ID: the audit
+// trail resolves the ID to image hashes, which are then purged as in
+// handlePurgeByHash.
+func (s *Server) handlePurgeByExternalID(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	res, err := s.purge(r.Context(), make(map[string]bool), id)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "purging records: "+err.Error())
+		return
+	}
+	s.audit(r, "purge", map[string]any{"by": "external_id", "external_id": id, "hashes": res.Hashes})
+	s.respond(w, http.StatusOK, res)
+}
+
+// purge deletes the stored verdict, feedback, and dataset records for
+// the given hashes and redacts matching audit records. When externalID
+// is set, audit records carrying it contribute their hashes first, so
+// erasure by upstream ID reaches content the caller never hashed.
+func (s *Server) purge(ctx context.Context, hashes map[string]bool, externalID string) (purgeResult, error) {
+	var res purgeResult
+	records, err := s.store.List(ctx, auditBucket, "", 0)
+	if err != nil {
+		return res, err
+	}
+	decoded := make([]auditRecord, len(records))
+	for i, record := range records {
+		if err := json.Unmarshal(record.Value, &decoded[i]); err != nil {
+			continue
+		}
+		if externalID != "" && decoded[i].Params["external_id"] == externalID {
+			if sha, ok := decoded[i].Params["sha256"].(string); ok {
+				hashes[sha] = true
+			}
+		}
+	}
+	// Hashes under legal hold are carved out before anything is touched:
+	// neither their records nor their audit trail may go while an
+	// investigation is active.
+	held := make(map[string]bool)
+	for sha := range hashes {
+		if s.held(ctx, sha) {
+			held[sha] = true
+			delete(hashes, sha)
+			res.Held = append(res.Held, sha)
+		}
+	}
+	for i, rec := range decoded {
+		sha, _ := rec.Params["sha256"].(string)
+		if held[sha] {
+			continue
+		}
+		matched := sha != "" && hashes[sha]
+		if externalID != "" && rec.Params["external_id"] == externalID {
+			matched = true
+		}
+		if !matched {
+			continue
+		}
+		rec.Params = map[string]any{"redacted": true}
+		data, err := json.Marshal(rec)
+		if err != nil {
+			continue
+		}
+		if err := s.store.Put(ctx, auditBucket, records[i].Key, data); err != nil {
+			return res, err
+		}
+		res.AuditRedacted++
+	}
+	for sha := range hashes {
+		res.Hashes = append(res.Hashes, sha)
+		for _, b := range []struct {
+			bucket string
+			count  *int
+		}{
+			{verdictBucket, &res.Verdicts},
+			{feedbackBucket, &res.Feedback},
+			{datasetBucket, &res.Dataset},
+		} {
+			if _, err := s.store.Get(ctx, b.bucket, sha); err != nil {
+				continue
+			}
+			if err := s.store.Delete(ctx, b.bucket, sha); err != nil {
+				return res, err
+			}
+			*b.count++
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/seal"
+)
+
+// quarantineOriginal preserves a flagged scan's original bytes in the
+// quarantine bucket for legal-hold workflows, and records the object
+// location on the verdict. Objects go up with a private canned ACL and,
+// when an expiry is configured, an expire-after-days tag for the
+// bucket's lifecycle rules to act on. With payload sealing configured
+// the object is a sealed envelope rather than the raw image. Upload
+// failures are logged, never surfaced to the scan caller.
+func (s *Server) quarantineOriginal(ctx context.Context, resp *api.ScanResponse, digest [sha256.Size]byte, data []byte) {
+	if s.cfg.QuarantineBucket == "" || !resp.NSFW || !s.s3.Configured() {
+		return
+	}
+	key := fmt.Sprintf("quarantine/%x", digest)
+	contentType := "application/octet-stream"
+	body, err := s.sealer.Seal(data)
+	if err != nil {
+		s.log.Error("sealing quarantined original failed", "err", err)
+		return
+	}
+	if seal.Sealed(body) {
+		key += ".sealed"
+	}
+	tags := map[string]string{"nfws": "quarantine"}
+	if s.cfg.QuarantineExpiryDays > 0 {
+		tags["expire-after-days"] = strconv.Itoa(s.cfg.QuarantineExpiryDays)
+	}
+	if err := s.s3.PutObjectRestricted(ctx, s.cfg.QuarantineBucket, key, contentType, body, tags); err != nil {
+		s.log.Error("quarantine upload failed", "bucket", s.cfg.QuarantineBucket, "key", key, "err", err)
+		return
+	}
+	resp.QuarantineURL = fmt.Sprintf("s3://%s/%s", s.cfg.QuarantineBucket, key)
+}
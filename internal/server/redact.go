@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/auth"
+)
+
+// redactResponse applies the caller's redaction level, returning a
+// copy so the full response stays intact for the exporter, verdict
+// store, and write-back, which run on the original.
+func redactResponse(r *http.Request, resp *api.ScanResponse) *api.ScanResponse {
+	key := keyFromContext(r.Context())
+	if key == nil || key.Redact == "" {
+		return resp
+	}
+	out := *resp
+	switch key.Redact {
+	case auth.RedactVerdict:
+		// Verdict only: no per-model detail, categories, or frame
+		// scores leave the server.
+		out.Models = nil
+		out.Categories = nil
+		out.Frames = nil
+		out.Timings = nil
+	case auth.RedactDetections:
+		// Keep per-model aggregate scores but drop the class-level
+		// labels and boxes.
+		models := make(map[string]api.ModelResult, len(resp.Models))
+		for name, m := range resp.Models {
+			m.Scores = nil
+			m.Detections = nil
+			models[name] = m
+		}
+		out.Models = models
+		if resp.Frames != nil {
+			frames := *resp.Frames
+			out.Frames = &frames
+		}
+	}
+	return &out
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/scan"
+	"github.com/illussioon/NFWS-Moderations-API/internal/seal"
+)
+
+// replayRequest selects audited scans and the model to re-run them
+// against. Filters mirror the audit API: external_id and batch_id match
+// the correlation IDs, since/until (RFC 3339) bound the scan time.
+type replayRequest struct {
+	// Model is the model to replay against, optionally version-pinned as
+	// "name@version"; empty re-runs the default model set.
+	Model      string  `json:"model,omitempty"`
+	ExternalID string  `json:"external_id,omitempty"`
+	BatchID    string  `json:"batch_id,omitempty"`
+	Since      string  `json:"since,omitempty"`
+	Until      string  `json:"until,omitempty"`
+	Threshold  float64 `json:"threshold,omitempty"`
+	// Limit caps how many audited scans are considered, newest first;
+	// default 100.
+	Limit int `json:"limit,omitempty"`
+}
+
+// replayChange is one audited scan whose verdict flipped under the
+// replayed model.
+type replayChange struct {
+	SHA256     string  `json:"sha256"`
+	ExternalID string  `json:"external_id,omitempty"`
+	BatchID    string  `json:"batch_id,omitempty"`
+	WasNSFW    bool    `json:"was_nsfw"`
+	NowNSFW    bool    `json:"now_nsfw"`
+	WasScore   float64 `json:"was_score"`
+	NowScore   float64 `json:"now_score"`
+}
+
+// handleReplay re-runs audited scans against a model version and
+// reports which verdicts would change — the validation pass before a
+// model upgrade that could flip thousands of historical decisions.
+// Image bytes come from the inline dataset records, so coverage is
+// limited to flagged scans on deployments with
+// NFWS_DATASET_INLINE_IMAGES; audited scans without a stored image are
+// counted as skipped, not failed.
+func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request) {
+	var req replayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "decoding request: "+err.Error())
+		return
+	}
+	var since, until time.Time
+	var err error
+	if req.Since != "" {
+		if since, err = time.Parse(time.RFC3339, req.Since); err != nil {
+			s.respondError(w, http.StatusBadRequest, "since must be RFC 3339")
+			return
+		}
+	}
+	if req.Until != "" {
+		if until, err = time.Parse(time.RFC3339, req.Until); err != nil {
+			s.respondError(w, http.StatusBadRequest, "until must be RFC 3339")
+			return
+		}
+	}
+	if req.Limit < 0 {
+		s.respondError(w, http.StatusBadRequest, "limit must not be negative")
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 100
+	}
+
+	records, err := s.store.List(r.Context(), auditBucket, "", 0)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "listing audit records: "+err.Error())
+		return
+	}
+	opts := scan.Options{Threshold: req.Threshold, Schema: api.SchemaV1}
+	if req.Model != "" {
+		opts.Models = []string{req.Model}
+	}
+	// One image may appear in many audit records; scan each hash once
+	// and compare every matching record against that result.
+	type replayed struct {
+		resp *api.ScanResponse
+		err  error
+	}
+	results := make(map[string]*replayed)
+	var changes []replayChange
+	considered, skipped, failures := 0, 0, 0
+	for i := len(records) - 1; i >= 0 && considered < req.Limit; i-- {
+		var rec auditRecord
+		if err := json.Unmarshal(records[i].Value, &rec); err != nil || rec.Action != "scan" {
+			continue
+		}
+		sha, _ := rec.Params["sha256"].(string)
+		if sha == "" {
+			continue
+		}
+		if req.ExternalID != "" && rec.Params["external_id"] != req.ExternalID {
+			continue
+		}
+		if req.BatchID != "" && rec.Params["batch_id"] != req.BatchID {
+			continue
+		}
+		if !since.IsZero() && rec.Time.Before(since) {
+			continue
+		}
+		if !until.IsZero() && !rec.Time.Before(until) {
+			continue
+		}
+		considered++
+		res := results[sha]
+		if res == nil {
+			data, err := s.replayImage(r, sha)
+			if err != nil {
+				skipped++
+				results[sha] = &replayed{}
+				continue
+			}
+			resp, err := s.scanner.ScanBytes(r.Context(), data, opts)
+			res = &replayed{resp: resp, err: err}
+			results[sha] = res
+			if err != nil {
+				s.log.Warn("replay scan failed", "sha256", sha, "err", err)
+			}
+		}
+		switch {
+		case res.err != nil:
+			failures++
+		case res.resp == nil:
+			skipped++
+		default:
+			wasNSFW, _ := rec.Params["nsfw"].(bool)
+			wasScore, _ := rec.Params["score"].(float64)
+			if res.resp.NSFW != wasNSFW {
+				change := replayChange{
+					SHA256:   sha,
+					WasNSFW:  wasNSFW,
+					NowNSFW:  res.resp.NSFW,
+					WasScore: wasScore,
+					NowScore: res.resp.Score,
+				}
+				change.ExternalID, _ = rec.Params["external_id"].(string)
+				change.BatchID, _ = rec.Params["batch_id"].(string)
+				changes = append(changes, change)
+			}
+		}
+	}
+	s.audit(r, "replay", map[string]any{
+		"model": req.Model, "considered": considered, "changed": len(changes),
+	})
+	s.respond(w, http.StatusOK, map[string]any{
+		"model":      req.Model,
+		"considered": considered,
+		"replayed":   considered - skipped - failures,
+		"skipped":    skipped,
+		"failures":   failures,
+		"changed":    len(changes),
+		"changes":    changes,
+	})
+}
+
+// replayImage recovers the original bytes for an audited scan from its
+// inline dataset record, opening the sealed envelope when payload
+// sealing is configured.
+func (s *Server) replayImage(r *http.Request, sha string) ([]byte, error) {
+	data, err := s.store.Get(r.Context(), datasetBucket, sha)
+	if err != nil {
+		return nil, err
+	}
+	var rec datasetRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	if rec.ImageBase64 == "" {
+		return nil, errors.New("dataset record has no inline image")
+	}
+	if seal.Sealed([]byte(rec.ImageBase64)) {
+		return s.sealer.Open([]byte(rec.ImageBase64))
+	}
+	return base64.StdEncoding.DecodeString(rec.ImageBase64)
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+)
+
+// Report counters live apart from the stats counters: they are bucketed
+// by calendar month and never reset, so past filings stay reproducible.
+// reportKeysBucket registers each counter name so the report handler can
+// enumerate a month (counters aren't listable themselves).
+const (
+	reportCounterBucket = "report_counters"
+	reportKeysBucket    = "report_keys"
+)
+
+// recordReport adds one completed scan to the current month's compliance
+// counters. Best-effort and detached, like the stats counters: a slow
+// store must not slow scans down.
+func (s *Server) recordReport(r *http.Request, resp *api.ScanResponse) {
+	keys := []string{"scans", "verdict:clean"}
+	if resp.NSFW {
+		keys[1] = "verdict:nsfw"
+	}
+	if resp.Severity != "" {
+		keys = append(keys, "severity:"+resp.Severity)
+	}
+	for name, c := range resp.Categories {
+		if c.Flagged {
+			keys = append(keys, "category:"+name)
+		}
+	}
+	if k := keyFromContext(r.Context()); k != nil {
+		keys = append(keys, "tenant:"+k.Name)
+	}
+	keys = append(keys, "model_version:"+s.scanner.Registry().Fingerprint())
+
+	month := time.Now().UTC().Format("2006-01")
+	var register []string
+	s.reportMu.Lock()
+	for _, k := range keys {
+		full := month + "|" + k
+		if !s.reportSeen[full] {
+			s.reportSeen[full] = true
+			register = append(register, full)
+		}
+	}
+	s.reportMu.Unlock()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		for _, k := range keys {
+			s.store.Incr(ctx, reportCounterBucket, month+"|"+k, 1)
+		}
+		for _, k := range register {
+			s.store.Put(ctx, reportKeysBucket, k, []byte{})
+		}
+	}()
+}
+
+// monthlyReport is the JSON shape of one month's summary.
+type monthlyReport struct {
+	Month         string           `json:"month"`
+	GeneratedAt   time.Time        `json:"generated_at"`
+	Scans         int64            `json:"scans"`
+	Verdicts      map[string]int64 `json:"verdicts"`
+	Severities    map[string]int64 `json:"severities"`
+	Categories    map[string]int64 `json:"categories"`
+	Tenants       map[string]int64 `json:"tenants"`
+	ModelVersions map[string]int64 `json:"model_versions"`
+}
+
+// handleMonthlyReport builds the compliance summary for one calendar
+// month (?month=YYYY-MM, default the current one) as JSON or, with
+// ?format=csv, as CSV. When receipt signing is configured the JSON body
+// carries the report again as a signed JWT, and the CSV ends with a
+// signature row.
+func (s *Server) handleMonthlyReport(w http.ResponseWriter, r *http.Request) {
+	month := r.URL.Query().Get("month")
+	if month == "" {
+		month = time.Now().UTC().Format("2006-01")
+	}
+	if _, err := time.Parse("2006-01", month); err != nil {
+		s.respondError(w, http.StatusBadRequest, "month must look like 2006-01")
+		return
+	}
+	recs, err := s.store.List(r.Context(), reportKeysBucket, month+"|", 0)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "listing report counters: "+err.Error())
+		return
+	}
+	rep := monthlyReport{
+		Month:         month,
+		GeneratedAt:   time.Now().UTC(),
+		Verdicts:      make(map[string]int64),
+		Severities:    make(map[string]int64),
+		Categories:    make(map[string]int64),
+		Tenants:       make(map[string]int64),
+		ModelVersions: make(map[string]int64),
+	}
+	for _, rec := range recs {
+		n, err := s.store.Incr(r.Context(), reportCounterBucket, rec.Key, 0)
+		if err != nil {
+			continue
+		}
+		name := strings.TrimPrefix(rec.Key, month+"|")
+		group, item, _ := strings.Cut(name, ":")
+		switch group {
+		case "scans":
+			rep.Scans = n
+		case "verdict":
+			rep.Verdicts[item] = n
+		case "severity":
+			rep.Severities[item] = n
+		case "category":
+			rep.Categories[item] = n
+		case "tenant":
+			rep.Tenants[item] = n
+		case "model_version":
+			rep.ModelVersions[item] = n
+		}
+	}
+	if r.URL.Query().Get("format") == "csv" {
+		s.writeReportCSV(w, rep)
+		return
+	}
+	body := map[string]any{"report": rep}
+	if s.receipts != nil {
+		if token, err := s.receipts.SignJSON(rep); err == nil {
+			body["signature"] = token
+		}
+	}
+	s.respond(w, http.StatusOK, body)
+}
+
+// writeReportCSV renders the report as section,name,count rows.
+func (s *Server) writeReportCSV(w http.ResponseWriter, rep monthlyReport) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="nfws-report-`+rep.Month+`.csv"`)
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"section", "name", "count"})
+	cw.Write([]string{"total", "scans", strconv.FormatInt(rep.Scans, 10)})
+	writeSection := func(section string, m map[string]int64) {
+		for _, name := range sortedKeys(m) {
+			cw.Write([]string{section, name, strconv.FormatInt(m[name], 10)})
+		}
+	}
+	writeSection("verdict", rep.Verdicts)
+	writeSection("severity", rep.Severities)
+	writeSection("category", rep.Categories)
+	writeSection("tenant", rep.Tenants)
+	writeSection("model_version", rep.ModelVersions)
+	if s.receipts != nil {
+		if token, err := s.receipts.SignJSON(rep); err == nil {
+			cw.Write([]string{"signature", "jws", token})
+		}
+	}
+	cw.Flush()
+}
+
+// sortedKeys keeps CSV row order stable across runs.
+func sortedKeys(m map[string]int64) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/scan"
+	"github.com/illussioon/NFWS-Moderations-API/internal/store"
+)
+
+// Reproducibility mode (NFWS_REPRODUCIBLE) stamps every stored verdict
+// with enough provenance to re-derive it later: each model's exact
+// preprocessing parameters and file checksum, the runtime the sessions
+// executed on, and the digest of every input tensor. POST
+// /admin/reproduce then replays a disputed image and checks that the
+// tensors come out bit-identical — the evidence a moderation dispute
+// needs that the decision followed from the recorded inputs.
+
+// verdictProvenance pins everything a replay must match.
+type verdictProvenance struct {
+	Engine string `json:"engine"`
+	// Runtime is the backend library version the verdict ran on.
+	Runtime string                     `json:"runtime,omitempty"`
+	Models  map[string]modelProvenance `json:"models"`
+}
+
+// modelProvenance records one model's exact configuration at scan time.
+type modelProvenance struct {
+	Version      string     `json:"version"`
+	Checksum     string     `json:"checksum"`
+	InputSize    int        `json:"input_size"`
+	Mean         [3]float32 `json:"mean"`
+	Scale        [3]float32 `json:"scale"`
+	BGR          bool       `json:"bgr,omitempty"`
+	TensorSHA256 string     `json:"tensor_sha256"`
+}
+
+// scanProvenance builds the provenance block for a finished scan, or
+// nil outside reproducibility mode.
+func (s *Server) scanProvenance(resp *api.ScanResponse) *verdictProvenance {
+	if !s.cfg.Reproducible {
+		return nil
+	}
+	registry := s.scanner.Registry()
+	p := &verdictProvenance{
+		Engine:  registry.Engine().Name(),
+		Runtime: registry.Engine().Version(),
+		Models:  make(map[string]modelProvenance, len(resp.Models)),
+	}
+	for name, m := range resp.Models {
+		loaded, ok := registry.Get(name)
+		if !ok {
+			continue
+		}
+		p.Models[name] = modelProvenance{
+			Version:      loaded.Version,
+			Checksum:     loaded.Checksum,
+			InputSize:    loaded.Spec.InputSize,
+			Mean:         loaded.Spec.Mean,
+			Scale:        loaded.Spec.Scale,
+			BGR:          loaded.Spec.BGR,
+			TensorSHA256: m.TensorSHA256,
+		}
+	}
+	return p
+}
+
+// tensorMismatch is one model whose replayed input tensor differed from
+// the recorded one.
+type tensorMismatch struct {
+	Model    string `json:"model"`
+	Recorded string `json:"recorded"`
+	Replayed string `json:"replayed"`
+}
+
+// handleReproduce replays a disputed verdict's image through the same
+// models and reports whether every input tensor came out bit-identical.
+// Image bytes come from the inline dataset record, like /admin/replay.
+func (s *Server) handleReproduce(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SHA256 string `json:"sha256"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if len(req.SHA256) != 2*sha256.Size {
+		s.respondError(w, http.StatusBadRequest, "sha256 must be a 64-character hex digest")
+		return
+	}
+	if !s.cfg.Reproducible {
+		s.respondError(w, http.StatusConflict, "reproducibility mode is off (NFWS_REPRODUCIBLE)")
+		return
+	}
+	data, err := s.store.Get(r.Context(), verdictBucket, req.SHA256)
+	if errors.Is(err, store.ErrNotFound) {
+		s.respondError(w, http.StatusNotFound, "no stored verdict for this image")
+		return
+	}
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "loading verdict: "+err.Error())
+		return
+	}
+	var v storedVerdict
+	if err := json.Unmarshal(data, &v); err != nil {
+		s.respondError(w, http.StatusInternalServerError, "decoding verdict: "+err.Error())
+		return
+	}
+	if v.Provenance == nil {
+		s.respondError(w, http.StatusConflict, "verdict was stored without provenance; it predates reproducibility mode")
+		return
+	}
+	img, err := s.replayImage(r, req.SHA256)
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, "original image bytes are not stored (requires NFWS_DATASET_INLINE_IMAGES): "+err.Error())
+		return
+	}
+	models := make([]string, 0, len(v.Provenance.Models))
+	for name := range v.Provenance.Models {
+		models = append(models, name)
+	}
+	sort.Strings(models)
+	resp, err := s.scanner.ScanBytes(r.Context(), img, scan.Options{Models: models, Schema: api.SchemaV1})
+	if err != nil {
+		s.scanError(w, err)
+		return
+	}
+	var mismatches []tensorMismatch
+	for _, name := range models {
+		recorded := v.Provenance.Models[name].TensorSHA256
+		replayed := resp.Models[name].TensorSHA256
+		if recorded != replayed {
+			mismatches = append(mismatches, tensorMismatch{Model: name, Recorded: recorded, Replayed: replayed})
+		}
+	}
+	runtime := s.scanner.Registry().Engine().Version()
+	s.audit(r, "reproduce", map[string]any{
+		"sha256": req.SHA256, "reproducible": len(mismatches) == 0,
+	})
+	s.respond(w, http.StatusOK, map[string]any{
+		"sha256":          req.SHA256,
+		"reproducible":    len(mismatches) == 0,
+		"runtime_changed": v.Provenance.Runtime != runtime,
+		"recorded_nsfw":   v.NSFW,
+		"replayed_nsfw":   resp.NSFW,
+		"mismatches":      mismatches,
+	})
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/bus"
+)
+
+// saturationWindow is how many recent completions feed the throughput
+// estimate behind shed-response backoff hints.
+const saturationWindow = 128
+
+// saturation tracks per-class completion throughput and the callers
+// currently being shed, so a 503 can tell a client where it stands and
+// how long a slot should take to free up instead of leaving it to blind
+// retries.
+type saturation struct {
+	mu sync.Mutex
+	// done is a ring of recent completion times.
+	done []time.Time
+	next int
+	// shedding counts callers concurrently receiving a shed response;
+	// a caller's position is its rank among them.
+	shedding int
+}
+
+// complete records one finished request.
+func (t *saturation) complete() {
+	now := time.Now()
+	t.mu.Lock()
+	if len(t.done) < saturationWindow {
+		t.done = append(t.done, now)
+	} else {
+		t.done[t.next] = now
+		t.next = (t.next + 1) % saturationWindow
+	}
+	t.mu.Unlock()
+}
+
+// throughput estimates completions per second over the ring; 0 until
+// enough requests have finished to measure.
+func (t *saturation) throughput() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.done) < 2 {
+		return 0
+	}
+	oldest := t.done[0]
+	if len(t.done) == saturationWindow {
+		oldest = t.done[t.next]
+	}
+	span := time.Since(oldest)
+	if span <= 0 {
+		return 0
+	}
+	return float64(len(t.done)-1) / span.Seconds()
+}
+
+// shedEnter registers one more caller being shed and returns its
+// position; shedExit balances it once the response is written.
+func (t *saturation) shedEnter() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.shedding++
+	return t.shedding
+}
+
+func (t *saturation) shedExit() {
+	t.mu.Lock()
+	t.shedding--
+	t.mu.Unlock()
+}
+
+// shedResponse writes the saturation 503: the standard error message
+// plus the caller's position among current rejections, the estimated
+// wait until that many slots turn over, and the observed throughput —
+// enough for an informed backoff. Retry-After mirrors the estimate for
+// clients that only honor the header.
+func (s *Server) shedResponse(w http.ResponseWriter, r *http.Request, class string, limit int, sat *saturation) {
+	s.stats.RecordShed()
+	s.events.Publish(bus.QuotaExceeded, map[string]any{
+		"class": class, "limit": limit, "path": r.URL.Path,
+	})
+	position := sat.shedEnter()
+	defer sat.shedExit()
+	body := map[string]any{
+		"error":          "server is at capacity for " + class + " requests, retry shortly",
+		"queue_position": position,
+		"limit":          limit,
+	}
+	retryAfter := 1.0
+	if tput := sat.throughput(); tput > 0 {
+		body["throughput_rps"] = math.Round(tput*10) / 10
+		retryAfter = float64(position) / tput
+		body["estimated_wait_ms"] = math.Round(retryAfter * 1000)
+	}
+	w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Max(1, math.Ceil(retryAfter)))))
+	s.respond(w, http.StatusServiceUnavailable, body)
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/seal"
+)
+
+// handlePayloadKeysRotate finishes a payload-key rotation: after the
+// operator adds the new key to NFWS_PAYLOAD_KEYS and points
+// NFWS_PAYLOAD_ACTIVE_KEY at it, this re-seals every stored payload
+// still under an older key. Thumbnails are immutable objects keyed by
+// content hash, so only dataset records are rewritten; old keys can be
+// dropped from the configuration once their thumbnail objects age out.
+func (s *Server) handlePayloadKeysRotate(w http.ResponseWriter, r *http.Request) {
+	if s.sealer == nil {
+		s.respondError(w, http.StatusConflict, "payload sealing requires NFWS_PAYLOAD_KEYS")
+		return
+	}
+	records, err := s.store.List(r.Context(), datasetBucket, "", 0)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "listing dataset records: "+err.Error())
+		return
+	}
+	checked, resealed := 0, 0
+	for _, record := range records {
+		var rec datasetRecord
+		if err := json.Unmarshal(record.Value, &rec); err != nil || rec.ImageBase64 == "" {
+			continue
+		}
+		checked++
+		if !seal.Sealed([]byte(rec.ImageBase64)) {
+			continue
+		}
+		sealed, changed, err := s.sealer.Reseal([]byte(rec.ImageBase64))
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, "resealing record "+record.Key+": "+err.Error())
+			return
+		}
+		if !changed {
+			continue
+		}
+		rec.ImageBase64 = string(sealed)
+		data, err := json.Marshal(rec)
+		if err != nil {
+			continue
+		}
+		if err := s.store.Put(r.Context(), datasetBucket, record.Key, data); err != nil {
+			s.respondError(w, http.StatusInternalServerError, "storing resealed record: "+err.Error())
+			return
+		}
+		resealed++
+	}
+	s.audit(r, "payload_keys_rotate", map[string]any{"active": s.sealer.Active(), "resealed": resealed})
+	s.respond(w, http.StatusOK, map[string]any{
+		"active_key": s.sealer.Active(),
+		"checked":    checked,
+		"resealed":   resealed,
+	})
+}
@@ -0,0 +1,284 @@
+// Package server wires the HTTP API: routing, auth middleware, and the
+// JSON handlers.
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/auth"
+	"github.com/illussioon/NFWS-Moderations-API/internal/bus"
+	"github.com/illussioon/NFWS-Moderations-API/internal/cache"
+	"github.com/illussioon/NFWS-Moderations-API/internal/chaos"
+	"github.com/illussioon/NFWS-Moderations-API/internal/config"
+	"github.com/illussioon/NFWS-Moderations-API/internal/digest"
+	"github.com/illussioon/NFWS-Moderations-API/internal/drift"
+	"github.com/illussioon/NFWS-Moderations-API/internal/flags"
+	"github.com/illussioon/NFWS-Moderations-API/internal/hashlist"
+	"github.com/illussioon/NFWS-Moderations-API/internal/jobs"
+	"github.com/illussioon/NFWS-Moderations-API/internal/plugin"
+	"github.com/illussioon/NFWS-Moderations-API/internal/provider"
+	"github.com/illussioon/NFWS-Moderations-API/internal/receipt"
+	"github.com/illussioon/NFWS-Moderations-API/internal/s3"
+	"github.com/illussioon/NFWS-Moderations-API/internal/scan"
+	"github.com/illussioon/NFWS-Moderations-API/internal/seal"
+	"github.com/illussioon/NFWS-Moderations-API/internal/stats"
+	"github.com/illussioon/NFWS-Moderations-API/internal/store"
+)
+
+// Deps bundles what the handlers need. Optional dependencies (Events,
+// S3, Keys) may be nil or unconfigured.
+type Deps struct {
+	Scanner  *scan.Scanner
+	Chaos    *chaos.Injector
+	Events   *bus.Bus
+	Store    store.Store
+	S3       *s3.Client
+	Stats    *stats.Collector
+	Keys     *auth.Keys
+	Provider provider.Provider
+	Drift    *drift.Detector
+	Plugin   *plugin.Plugin
+	Sealer   *seal.Sealer
+	Flags    *flags.Set
+	Digest   *digest.Digest
+	HashList *hashlist.Matcher
+	Cache    cache.Cache
+	Jobs     *jobs.Queue
+	Log      *slog.Logger
+}
+
+// Server holds the handler dependencies.
+type Server struct {
+	cfg       *config.Config
+	scanner   *scan.Scanner
+	chaos     *chaos.Injector
+	events    *bus.Bus
+	store     store.Store
+	s3        *s3.Client
+	stats     *stats.Collector
+	keys      *auth.Keys
+	provider  provider.Provider
+	drift     *drift.Detector
+	plugin    *plugin.Plugin
+	sealer    *seal.Sealer
+	flags     *flags.Set
+	digest    *digest.Digest
+	hashlists *hashlist.Matcher
+	cache     cache.Cache
+	jobs      *jobs.Queue
+	receipts  *receipt.Signer
+	log       *slog.Logger
+
+	// adaptive, when non-nil, replaces the static scan ceiling with the
+	// latency-steered AIMD controller.
+	adaptive *adaptiveLimiter
+
+	// reportSeen tracks which monthly report counters this process has
+	// already registered, to avoid a store write per scan.
+	reportMu   sync.Mutex
+	reportSeen map[string]bool
+}
+
+// New builds the Server and its router.
+func New(cfg *config.Config, deps Deps) *Server {
+	var signer *receipt.Signer
+	if cfg.ReceiptSecret != "" {
+		signer = receipt.NewSigner(cfg.ReceiptSecret)
+	}
+	var adaptive *adaptiveLimiter
+	if cfg.AdaptiveTargetP95 > 0 {
+		adaptive = newAdaptiveLimiter(cfg.AdaptiveTargetP95, cfg.AdaptiveMaxInflight)
+	}
+	return &Server{
+		cfg:       cfg,
+		scanner:   deps.Scanner,
+		chaos:     deps.Chaos,
+		events:    deps.Events,
+		store:     deps.Store,
+		s3:        deps.S3,
+		stats:     deps.Stats,
+		keys:      deps.Keys,
+		provider:  deps.Provider,
+		drift:     deps.Drift,
+		plugin:    deps.Plugin,
+		sealer:    deps.Sealer,
+		flags:     deps.Flags,
+		digest:    deps.Digest,
+		hashlists: deps.HashList,
+		cache:     deps.Cache,
+		jobs:      deps.Jobs,
+		receipts:  signer,
+		log:       deps.Log,
+
+		adaptive:   adaptive,
+		reportSeen: make(map[string]bool),
+	}
+}
+
+// Router returns the fully-wired chi router.
+func (s *Server) Router() http.Handler {
+	r := chi.NewRouter()
+	r.Use(chimw.RealIP)
+	r.Use(s.logRequests)
+	r.Use(chimw.Recoverer)
+	r.Use(chimw.Timeout(s.cfg.RequestTimeout))
+
+	r.Get("/healthz", s.handleHealthz)
+
+	// One semaphore per endpoint class: scanning is the expensive path,
+	// everything operator- or admin-facing shares the control budget.
+	// The adaptive controller, when configured, steers the scan ceiling
+	// by latency instead of the static limit.
+	scanLimit := s.limitInFlight("scan", s.cfg.MaxInflightScans)
+	if s.adaptive != nil {
+		scanLimit = s.limitAdaptive(s.adaptive)
+	}
+	controlLimit := s.limitInFlight("control", s.cfg.MaxInflightControl)
+
+	r.Group(func(r chi.Router) {
+		r.Use(s.requireRole(auth.RoleScanner))
+		// Per-tenant shares, when the keys file declares any, arbitrate
+		// ahead of the global ceiling so one tenant's burst is turned
+		// away before it occupies shared slots.
+		if fs := newFairShare(s.keys, s.cfg.MaxInflightScans); fs != nil {
+			r.Use(s.limitTenantShares(fs))
+		}
+		r.Use(scanLimit)
+		r.Use(s.chaos.Middleware)
+		r.Post("/scan", s.handleScan)
+		r.Post("/scan/async", s.handleScanAsync)
+		r.Get("/jobs/{id}", s.handleJobGet)
+		r.Post("/scan/video", s.handleScanVideo)
+		r.Post("/verify", s.handleVerify)
+		r.Post("/hashes/lookup", s.handleHashLookup)
+		r.Post("/feedback", s.handleFeedback)
+		r.Post("/appeals", s.handleAppealCreate)
+		r.Post("/v1/moderations", s.handleModerations)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(s.requireRole(auth.RoleOperator))
+		r.Use(controlLimit)
+		r.Get("/models", s.handleModels)
+		r.Get("/formats", s.handleFormats)
+		r.Get("/stats", s.handleStats)
+	})
+
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(s.requireRole(auth.RoleAdmin))
+		r.Use(controlLimit)
+		r.Post("/models", s.handleModelUpload)
+		r.Post("/models/{name}/bench", s.handleModelBench)
+		r.Post("/models/{name}/activate", s.handleModelActivate)
+		r.Post("/models/{name}/rollback", s.handleModelRollback)
+		r.Post("/models/{name}/disable", s.handleModelDisable)
+		r.Post("/models/{name}/enable", s.handleModelEnable)
+		r.Get("/models/{name}/threshold-suggestion", s.handleThresholdSuggestion)
+		r.Get("/selftest", s.handleSelfTest)
+		r.Get("/chaos", s.handleChaosGet)
+		r.Put("/chaos", s.handleChaosPut)
+		r.Get("/flags", s.handleFlagsList)
+		r.Put("/flags/{name}", s.handleFlagPut)
+		r.Delete("/flags/{name}", s.handleFlagDelete)
+		r.Get("/audit", s.handleAudit)
+		r.Get("/audit/export", s.handleAuditExport)
+		r.Delete("/audit/by-hash/{hash}", s.handlePurgeByHash)
+		r.Delete("/audit/by-external-id/{id}", s.handlePurgeByExternalID)
+		r.Get("/hashlist/matches", s.handleHashMatchList)
+		r.Get("/blocklist", s.handleBlocklistList)
+		r.Put("/blocklist/{hash}", s.handleBlocklistPut)
+		r.Delete("/blocklist/{hash}", s.handleBlocklistDelete)
+		r.Get("/legal-hold", s.handleLegalHoldList)
+		r.Put("/legal-hold/{hash}", s.handleLegalHoldPut)
+		r.Delete("/legal-hold/{hash}", s.handleLegalHoldDelete)
+		r.Post("/payload-keys/rotate", s.handlePayloadKeysRotate)
+		r.Get("/appeals", s.handleAppealsList)
+		r.Get("/appeals/stats", s.handleAppealsStats)
+		r.Post("/appeals/{id}/claim", s.handleAppealClaim)
+		r.Post("/appeals/{id}/release", s.handleAppealRelease)
+		r.Post("/appeals/{id}/resolve", s.handleAppealResolve)
+		r.Get("/appeals/{id}/image", s.handleAppealImage)
+		r.Post("/appeals/{id}/exposure", s.handleAppealExposure)
+		r.Get("/wellness", s.handleWellness)
+		r.Post("/replay", s.handleReplay)
+		r.Post("/reproduce", s.handleReproduce)
+		r.Post("/digest/send", s.handleDigestSend)
+		r.Get("/reports/monthly", s.handleMonthlyReport)
+		r.Post("/dataset/export", s.handleDatasetExport)
+	})
+	return r
+}
+
+// respond writes v as JSON with the given status.
+func (s *Server) respond(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.log.Error("encoding response", "err", err)
+	}
+}
+
+// respondCached writes v as JSON with an ETag and honors If-None-Match,
+// so pollers (dashboards hitting /models and /stats every second) get a
+// 304 instead of re-transferring an identical payload.
+func (s *Server) respondCached(w http.ResponseWriter, r *http.Request, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "encoding response")
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, max-age=1")
+	if match := r.Header.Get("If-None-Match"); match != "" && match == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(body, '\n'))
+}
+
+// respondError writes the uniform error envelope.
+func (s *Server) respondError(w http.ResponseWriter, status int, msg string) {
+	s.respond(w, status, api.Error{Error: msg})
+}
+
+// respondFieldErrors writes a 422 pinning each problem to the request
+// field and validation rule that caused it, with messages in lang.
+func (s *Server) respondFieldErrors(w http.ResponseWriter, lang string, fields []api.FieldError) {
+	s.respond(w, http.StatusUnprocessableEntity, api.Error{Error: errorMessage(lang, "validation_failed"), Fields: fields})
+}
+
+// warn attaches an advisory X-Warning header to a response that will
+// still succeed, so integrators hear about an approaching limit before
+// the hard rejections start. A response can carry several.
+func warn(w http.ResponseWriter, format string, args ...any) {
+	w.Header().Add("X-Warning", fmt.Sprintf(format, args...))
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	status := "ok"
+	if err := s.store.Ping(r.Context()); err != nil {
+		status = "degraded"
+	}
+	resp := map[string]any{
+		"status": status,
+		"models": s.scanner.Registry().Names(),
+		"engine": s.scanner.Registry().Engine().Name(),
+	}
+	if rs, ok := s.store.(*store.Resilient); ok {
+		resp["store"] = rs.Health()
+	}
+	s.respond(w, http.StatusOK, resp)
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// handleThresholdSuggestion computes the threshold for one model that
+// meets a target precision (?precision=0.95, the default) or recall
+// (?recall=0.9) against the accumulated feedback labels, with 95%
+// Wilson confidence intervals so small samples are visibly uncertain.
+func (s *Server) handleThresholdSuggestion(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if _, ok := s.scanner.Registry().Get(name); !ok {
+		s.respondError(w, http.StatusNotFound, "unknown model "+strconv.Quote(name))
+		return
+	}
+	targetKind, targetValue := "precision", 0.95
+	if v := r.URL.Query().Get("precision"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 1 {
+			s.respondError(w, http.StatusBadRequest, "precision must be in (0,1]")
+			return
+		}
+		targetValue = f
+	}
+	if v := r.URL.Query().Get("recall"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 1 {
+			s.respondError(w, http.StatusBadRequest, "recall must be in (0,1]")
+			return
+		}
+		targetKind, targetValue = "recall", f
+	}
+
+	recs, err := s.store.List(r.Context(), feedbackBucket, "", 0)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "listing feedback: "+err.Error())
+		return
+	}
+	var scores []float64
+	var labels []bool
+	positives := 0
+	for _, rec := range recs {
+		var fb feedbackRecord
+		if err := json.Unmarshal(rec.Value, &fb); err != nil {
+			continue
+		}
+		score, ok := fb.Scores[name]
+		if !ok {
+			continue
+		}
+		scores = append(scores, score)
+		labels = append(labels, fb.NSFW)
+		if fb.NSFW {
+			positives++
+		}
+	}
+	const minSamples = 10
+	if len(scores) < minSamples || positives == 0 || positives == len(scores) {
+		s.respondError(w, http.StatusUnprocessableEntity,
+			"need at least 10 feedback samples for this model with both labels present")
+		return
+	}
+
+	// Every distinct score is a candidate threshold (flagging means
+	// score >= threshold). Pick the candidate meeting the target while
+	// maximizing the other metric: the lowest threshold for a precision
+	// target, the highest for a recall target.
+	candidates := append([]float64(nil), scores...)
+	sort.Float64s(candidates)
+	best := -1
+	var bestPrec, bestRec float64
+	var bestTP, bestFP, bestFN int
+	for i, t := range candidates {
+		if i > 0 && t == candidates[i-1] {
+			continue
+		}
+		tp, fp, fn := 0, 0, 0
+		for j, score := range scores {
+			flagged := score >= t
+			switch {
+			case flagged && labels[j]:
+				tp++
+			case flagged && !labels[j]:
+				fp++
+			case !flagged && labels[j]:
+				fn++
+			}
+		}
+		if tp == 0 {
+			continue
+		}
+		prec := float64(tp) / float64(tp+fp)
+		rec := float64(tp) / float64(tp+fn)
+		switch targetKind {
+		case "precision":
+			// Candidates ascend, so the first one meeting the target
+			// has the highest recall.
+			if prec >= targetValue && best < 0 {
+				best, bestPrec, bestRec = i, prec, rec
+				bestTP, bestFP, bestFN = tp, fp, fn
+			}
+		case "recall":
+			// Keep the highest threshold still meeting the target for
+			// the best precision.
+			if rec >= targetValue {
+				best, bestPrec, bestRec = i, prec, rec
+				bestTP, bestFP, bestFN = tp, fp, fn
+			}
+		}
+	}
+	if best < 0 {
+		s.respondError(w, http.StatusUnprocessableEntity,
+			"no threshold reaches the target "+targetKind+" on the current feedback")
+		return
+	}
+	precLo, precHi := wilson(bestTP, bestTP+bestFP)
+	recLo, recHi := wilson(bestTP, bestTP+bestFN)
+	s.respond(w, http.StatusOK, map[string]any{
+		"model":     name,
+		"samples":   len(scores),
+		"positives": positives,
+		"target":    map[string]any{targetKind: targetValue},
+		"suggestion": map[string]any{
+			"threshold":    candidates[best],
+			"precision":    bestPrec,
+			"precision_ci": []float64{precLo, precHi},
+			"recall":       bestRec,
+			"recall_ci":    []float64{recLo, recHi},
+			"tp":           bestTP,
+			"fp":           bestFP,
+			"fn":           bestFN,
+		},
+	})
+}
+
+// wilson returns the 95% Wilson score interval for k successes in n
+// trials — better behaved than the normal approximation at the small
+// sample sizes feedback starts with.
+func wilson(k, n int) (lo, hi float64) {
+	if n == 0 {
+		return 0, 1
+	}
+	const z = 1.96
+	p := float64(k) / float64(n)
+	nf := float64(n)
+	denom := 1 + z*z/nf
+	center := (p + z*z/(2*nf)) / denom
+	margin := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf)) / denom
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
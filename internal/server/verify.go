@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+	"github.com/illussioon/NFWS-Moderations-API/internal/store"
+)
+
+// verdictBucket holds stored verdicts keyed by the image's SHA-256.
+const verdictBucket = "verdicts"
+
+// storedVerdict is what POST /verify checks against. It is stamped with
+// the model-set fingerprint so improved models invalidate old verdicts.
+type storedVerdict struct {
+	NSFW     bool    `json:"nsfw"`
+	Score    float64 `json:"score"`
+	Severity string  `json:"severity,omitempty"`
+	// Scores keeps the per-model contributions so feedback on this
+	// image can drive per-model threshold tuning later.
+	Scores       map[string]float64 `json:"scores,omitempty"`
+	ModelVersion string             `json:"model_version"`
+	ScannedAt    time.Time          `json:"scanned_at"`
+	// Provenance pins the runtime and per-model tensor digests for
+	// reproducibility replays; nil outside reproducibility mode.
+	Provenance *verdictProvenance `json:"provenance,omitempty"`
+}
+
+// storeVerdict records a scan outcome, detached and best-effort like the
+// stats path: a slow or unreachable store never delays the scan reply.
+func (s *Server) storeVerdict(imageSHA [32]byte, resp *api.ScanResponse) {
+	scores := make(map[string]float64, len(resp.Models))
+	for name, m := range resp.Models {
+		scores[name] = m.NSFWScore
+	}
+	v := storedVerdict{
+		NSFW:         resp.NSFW,
+		Score:        resp.Score,
+		Severity:     resp.Severity,
+		Scores:       scores,
+		ModelVersion: s.scanner.Registry().Fingerprint(),
+		ScannedAt:    time.Now().UTC(),
+		Provenance:   s.scanProvenance(resp),
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	key := hex.EncodeToString(imageSHA[:])
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := s.store.Put(ctx, verdictBucket, key, data); err != nil {
+			s.log.Error("storing verdict failed", "err", err)
+		}
+	}()
+}
+
+func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	var req api.VerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if len(req.SHA256) != 2*sha256.Size {
+		s.respondError(w, http.StatusBadRequest, "sha256 must be a 64-character hex digest")
+		return
+	}
+	data, err := s.store.Get(r.Context(), verdictBucket, req.SHA256)
+	if errors.Is(err, store.ErrNotFound) {
+		s.respond(w, http.StatusOK, api.VerifyResponse{Reason: "unknown"})
+		return
+	}
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "loading verdict: "+err.Error())
+		return
+	}
+	var v storedVerdict
+	if err := json.Unmarshal(data, &v); err != nil {
+		s.respondError(w, http.StatusInternalServerError, "decoding verdict: "+err.Error())
+		return
+	}
+	resp := api.VerifyResponse{
+		Known:        true,
+		Valid:        true,
+		NSFW:         &v.NSFW,
+		Score:        v.Score,
+		Severity:     v.Severity,
+		ModelVersion: v.ModelVersion,
+		ScannedAt:    v.ScannedAt.Format(time.RFC3339),
+	}
+	switch {
+	case v.ModelVersion != s.scanner.Registry().Fingerprint():
+		resp.Valid, resp.Reason = false, "model_changed"
+	case time.Since(v.ScannedAt) > s.cfg.VerdictTTL:
+		resp.Valid, resp.Reason = false, "expired"
+	}
+	s.respond(w, http.StatusOK, resp)
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/api"
+)
+
+// videoFrame is one per-frame NDJSON record of a streaming video scan.
+// Skipped marks near-duplicate frames that reused an earlier frame's
+// score instead of running inference.
+type videoFrame struct {
+	Frame   int     `json:"frame"`
+	Score   float64 `json:"score"`
+	NSFW    bool    `json:"nsfw"`
+	Skipped bool    `json:"skipped,omitempty"`
+}
+
+// videoFinal is the aggregate record closing the stream; clients tell it
+// apart from frame records by the final marker.
+type videoFinal struct {
+	Final bool `json:"final"`
+	*api.ScanResponse
+}
+
+// handleScanVideo scans a multi-frame input like /scan, but streams
+// NDJSON: one record per frame as it is scored, then a final aggregate
+// record. A client that only needs the first hit can close the
+// connection the moment a frame record reports nsfw, which aborts the
+// remaining inference.
+func (s *Server) handleScanVideo(w http.ResponseWriter, r *http.Request) {
+	var req api.ScanRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxImageBytesFor(r)*2)).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	lang := preferredLang(r)
+	if fields := s.validateImageSource(&req, s.maxImageBytesFor(r), lang); fields != nil {
+		s.respondFieldErrors(w, lang, fields)
+		return
+	}
+	data, err := s.imageBytes(r, &req)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	data = s.preprocessPlugin(r.Context(), data)
+	opts, msg := s.scanOptions(r, &req)
+	if msg != "" {
+		s.respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if !s.flags.Enabled("scan_video", opts.Tenant) {
+		s.respondError(w, http.StatusForbidden, "feature scan_video is not enabled for this tenant")
+		return
+	}
+	threshold := s.scanner.Threshold()
+	if opts.Threshold > 0 {
+		threshold = opts.Threshold
+	}
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	streaming := false
+	opts.OnFrame = func(index int, score float64, skipped bool) error {
+		if !streaming {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			streaming = true
+		}
+		if err := enc.Encode(videoFrame{
+			Frame:   index,
+			Score:   score,
+			NSFW:    score >= threshold,
+			Skipped: skipped,
+		}); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	scanStart := time.Now()
+	resp, err := s.scanner.ScanBytes(r.Context(), data, opts)
+	if err != nil {
+		s.stats.RecordError()
+		if !streaming {
+			s.scanError(w, err)
+			return
+		}
+		// The status line is gone; an error record is all that can
+		// close the stream honestly.
+		enc.Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	ranModels := make([]string, 0, len(resp.Models))
+	for name, m := range resp.Models {
+		ranModels = append(ranModels, name)
+		s.drift.Record(name, m.NSFWScore)
+	}
+	s.stats.RecordScan(resp.NSFW, ranModels)
+	s.auditScan(r, &req, resp, sha256.Sum256(data))
+	s.emitScanEvent(r, &req, resp, time.Since(scanStart))
+	if !streaming {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	if err := enc.Encode(videoFinal{Final: true, ScanResponse: redactResponse(r, resp)}); err != nil {
+		s.log.Error("encoding response", "err", err)
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/illussioon/NFWS-Moderations-API/internal/imageproc"
+	"github.com/illussioon/NFWS-Moderations-API/internal/store"
+)
+
+// Reviewer wellness: appealed images are served blurred and grayscaled
+// by default, with the unobscured original an explicit reveal away, and
+// each reviewer's reveal count and self-reported exposure time tracked
+// — the industry-standard precautions against the cumulative toll of
+// moderating flagged content.
+
+// exposureBucket holds per-reviewer exposure totals, keyed by reviewer.
+const exposureBucket = "exposure"
+
+// exposureRecord is one reviewer's cumulative contact with unobscured
+// flagged content.
+type exposureRecord struct {
+	Reviewer  string    `json:"reviewer"`
+	Reveals   int       `json:"reveals"`
+	ViewedMS  int64     `json:"viewed_ms"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// handleAppealImage serves the image under appeal for review tooling.
+// The default rendering is a grayscale, heavily blurred preview; the
+// reveal query serves the original and is counted against the
+// reviewer's exposure record. Availability matches POST /admin/replay:
+// the original must still be inline in the dataset bucket.
+func (s *Server) handleAppealImage(w http.ResponseWriter, r *http.Request) {
+	a, ok := s.loadAppeal(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	data, err := s.replayImage(r, a.SHA256)
+	if errors.Is(err, store.ErrNotFound) {
+		s.respondError(w, http.StatusNotFound, "no retained image for this appeal; retention requires NFWS_DATASET_INLINE_IMAGES")
+		return
+	}
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "loading image: "+err.Error())
+		return
+	}
+	if r.URL.Query().Get("reveal") == "true" {
+		reviewer := r.URL.Query().Get("reviewer")
+		if reviewer == "" {
+			s.respondError(w, http.StatusBadRequest, "reveal requires the reviewer query parameter")
+			return
+		}
+		s.recordExposure(r, reviewer, 1, 0)
+		s.audit(r, "appeal_reveal", map[string]any{
+			"appeal_id": a.ID,
+			"sha256":    a.SHA256,
+			"reviewer":  reviewer,
+		})
+		w.Header().Set("Content-Type", http.DetectContentType(data))
+		w.Write(data)
+		return
+	}
+	img, _, err := imageproc.Decode(data)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "decoding image: "+err.Error())
+		return
+	}
+	blur := s.cfg.ThumbnailBlur
+	if blur <= 0 {
+		blur = 12
+	}
+	thumb, err := imageproc.Thumbnail(imageproc.Grayscale(img), s.cfg.ThumbnailSize, blur)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Write(thumb)
+}
+
+// handleAppealExposure accepts the review tooling's report of how long
+// a reviewer had the revealed image on screen, accumulated into their
+// exposure record.
+func (s *Server) handleAppealExposure(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Reviewer string `json:"reviewer"`
+		ViewedMS int64  `json:"viewed_ms"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if req.Reviewer == "" {
+		s.respondError(w, http.StatusBadRequest, "reviewer is required")
+		return
+	}
+	if req.ViewedMS <= 0 {
+		s.respondError(w, http.StatusBadRequest, "viewed_ms must be positive")
+		return
+	}
+	s.recordExposure(r, req.Reviewer, 0, req.ViewedMS)
+	s.respond(w, http.StatusOK, map[string]any{"recorded": true})
+}
+
+// handleWellness lists per-reviewer exposure totals, for moderation
+// leads watching for reviewers who need rotation or a break.
+func (s *Server) handleWellness(w http.ResponseWriter, r *http.Request) {
+	records, err := s.store.List(r.Context(), exposureBucket, "", 0)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "listing exposure records: "+err.Error())
+		return
+	}
+	reviewers := make([]exposureRecord, 0, len(records))
+	for _, rec := range records {
+		var e exposureRecord
+		if err := json.Unmarshal(rec.Value, &e); err != nil {
+			continue
+		}
+		reviewers = append(reviewers, e)
+	}
+	s.respond(w, http.StatusOK, map[string]any{"reviewers": reviewers})
+}
+
+// recordExposure folds reveals and viewing time into one reviewer's
+// running totals.
+func (s *Server) recordExposure(r *http.Request, reviewer string, reveals int, viewedMS int64) {
+	var e exposureRecord
+	if data, err := s.store.Get(r.Context(), exposureBucket, reviewer); err == nil {
+		json.Unmarshal(data, &e)
+	}
+	e.Reviewer = reviewer
+	e.Reveals += reveals
+	e.ViewedMS += viewedMS
+	e.UpdatedAt = time.Now().UTC()
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if err := s.store.Put(r.Context(), exposureBucket, reviewer, data); err != nil {
+		s.log.Error("storing exposure record failed", "reviewer", reviewer, "err", err)
+	}
+}
@@ -0,0 +1,286 @@
+// Package stats keeps scan counters for the /stats endpoint. Counters
+// are in-process by default; in cluster mode they live in the shared
+// store so every replica reports the same numbers.
+package stats
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/illussioon/NFWS-Moderations-API/internal/store"
+)
+
+// bucket names in the shared store.
+const (
+	counterBucket = "stats"
+	modelsBucket  = "stats_models"
+	tenantsBucket = "stats_tenants"
+)
+
+// Collector accumulates scan counters. All methods are safe for
+// concurrent use.
+type Collector struct {
+	startedAt time.Time
+
+	mu         sync.Mutex
+	scans      int64
+	nsfw       int64
+	errors     int64
+	sampledOut int64
+	shed       int64
+	abandoned  int64
+	perModel   map[string]int64
+	perTenant  map[string]*TenantTraffic
+
+	// shared, when non-nil, replaces the local counters with Incr calls
+	// against the shared store. seenModels and seenTenants avoid
+	// re-registering names on every request.
+	shared      store.Store
+	seenModels  map[string]bool
+	seenTenants map[string]bool
+}
+
+// NewCollector returns a process-local collector.
+func NewCollector() *Collector {
+	return &Collector{
+		startedAt: time.Now(),
+		perModel:  make(map[string]int64),
+		perTenant: make(map[string]*TenantTraffic),
+	}
+}
+
+// NewShared returns a collector backed by the shared store, so counters
+// aggregate across replicas.
+func NewShared(st store.Store) *Collector {
+	return &Collector{
+		startedAt:   time.Now(),
+		shared:      st,
+		seenModels:  make(map[string]bool),
+		seenTenants: make(map[string]bool),
+	}
+}
+
+// RecordScan counts one completed scan and the models it ran.
+func (c *Collector) RecordScan(nsfw bool, models []string) {
+	if c.shared != nil {
+		c.recordShared(nsfw, models)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scans++
+	if nsfw {
+		c.nsfw++
+	}
+	for _, m := range models {
+		c.perModel[m]++
+	}
+}
+
+func (c *Collector) recordShared(nsfw bool, models []string) {
+	var newModels []string
+	c.mu.Lock()
+	for _, m := range models {
+		if !c.seenModels[m] {
+			c.seenModels[m] = true
+			newModels = append(newModels, m)
+		}
+	}
+	c.mu.Unlock()
+	// Stats are best-effort: never block or fail a scan on the shared
+	// backend, so updates run detached with a short timeout.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		c.shared.Incr(ctx, counterBucket, "scans", 1)
+		if nsfw {
+			c.shared.Incr(ctx, counterBucket, "nsfw", 1)
+		}
+		for _, m := range models {
+			c.shared.Incr(ctx, counterBucket, "model:"+m, 1)
+		}
+		for _, m := range newModels {
+			c.shared.Put(ctx, modelsBucket, m, []byte{})
+		}
+	}()
+}
+
+// TenantTraffic is the payload volume one tenant has moved.
+type TenantTraffic struct {
+	BytesIn  int64 `json:"bytes_in"`
+	BytesOut int64 `json:"bytes_out"`
+}
+
+// RecordBytes adds one request's payload sizes to a tenant's traffic
+// counters.
+func (c *Collector) RecordBytes(tenant string, in, out int64) {
+	if tenant == "" || (in <= 0 && out <= 0) {
+		return
+	}
+	if c.shared != nil {
+		c.mu.Lock()
+		first := !c.seenTenants[tenant]
+		c.seenTenants[tenant] = true
+		c.mu.Unlock()
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if in > 0 {
+				c.shared.Incr(ctx, counterBucket, "tenant_in:"+tenant, in)
+			}
+			if out > 0 {
+				c.shared.Incr(ctx, counterBucket, "tenant_out:"+tenant, out)
+			}
+			if first {
+				c.shared.Put(ctx, tenantsBucket, tenant, []byte{})
+			}
+		}()
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := c.perTenant[tenant]
+	if t == nil {
+		t = &TenantTraffic{}
+		c.perTenant[tenant] = t
+	}
+	if in > 0 {
+		t.BytesIn += in
+	}
+	if out > 0 {
+		t.BytesOut += out
+	}
+}
+
+// RecordSampledOut counts one submission auto-allowed by scan
+// sampling, so NSFW rates can still be projected onto full volume.
+func (c *Collector) RecordSampledOut() {
+	if c.shared != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			c.shared.Incr(ctx, counterBucket, "sampled_out", 1)
+		}()
+		return
+	}
+	c.mu.Lock()
+	c.sampledOut++
+	c.mu.Unlock()
+}
+
+// RecordShed counts one request rejected at the in-flight ceiling, so
+// operators can see shedding alongside the traffic that got through.
+func (c *Collector) RecordShed() {
+	if c.shared != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			c.shared.Incr(ctx, counterBucket, "shed", 1)
+		}()
+		return
+	}
+	c.mu.Lock()
+	c.shed++
+	c.mu.Unlock()
+}
+
+// RecordAbandoned counts one queued request dropped because its client
+// disconnected before a slot freed up.
+func (c *Collector) RecordAbandoned() {
+	if c.shared != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			c.shared.Incr(ctx, counterBucket, "abandoned", 1)
+		}()
+		return
+	}
+	c.mu.Lock()
+	c.abandoned++
+	c.mu.Unlock()
+}
+
+// RecordError counts one failed scan.
+func (c *Collector) RecordError() {
+	if c.shared != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			c.shared.Incr(ctx, counterBucket, "errors", 1)
+		}()
+		return
+	}
+	c.mu.Lock()
+	c.errors++
+	c.mu.Unlock()
+}
+
+// Snapshot is the JSON shape of /stats.
+type Snapshot struct {
+	UptimeSeconds int64 `json:"uptime_seconds"`
+	Scans         int64 `json:"scans"`
+	NSFW          int64 `json:"nsfw"`
+	Errors        int64 `json:"errors"`
+	// SampledOut counts submissions auto-allowed without scanning.
+	SampledOut int64 `json:"sampled_out"`
+	// Shed counts requests rejected at the in-flight ceiling.
+	Shed int64 `json:"shed"`
+	// Abandoned counts queued requests dropped on client disconnect.
+	Abandoned int64            `json:"abandoned"`
+	PerModel  map[string]int64 `json:"per_model"`
+	// PerTenant reports payload bytes moved per key name; empty without
+	// a keys file.
+	PerTenant map[string]TenantTraffic `json:"per_tenant,omitempty"`
+	// Cluster reports whether the counters aggregate across replicas.
+	Cluster bool `json:"cluster"`
+}
+
+// Snapshot returns the current counters. In cluster mode it reads
+// through the shared store; uptime is always this replica's own.
+func (c *Collector) Snapshot(ctx context.Context) Snapshot {
+	snap := Snapshot{
+		UptimeSeconds: int64(time.Since(c.startedAt).Seconds()),
+		PerModel:      make(map[string]int64),
+		Cluster:       c.shared != nil,
+	}
+	if c.shared == nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		snap.Scans, snap.NSFW, snap.Errors = c.scans, c.nsfw, c.errors
+		snap.SampledOut = c.sampledOut
+		snap.Shed = c.shed
+		snap.Abandoned = c.abandoned
+		for k, v := range c.perModel {
+			snap.PerModel[k] = v
+		}
+		if len(c.perTenant) > 0 {
+			snap.PerTenant = make(map[string]TenantTraffic, len(c.perTenant))
+			for k, v := range c.perTenant {
+				snap.PerTenant[k] = *v
+			}
+		}
+		return snap
+	}
+	snap.Scans, _ = c.shared.Incr(ctx, counterBucket, "scans", 0)
+	snap.NSFW, _ = c.shared.Incr(ctx, counterBucket, "nsfw", 0)
+	snap.Errors, _ = c.shared.Incr(ctx, counterBucket, "errors", 0)
+	snap.SampledOut, _ = c.shared.Incr(ctx, counterBucket, "sampled_out", 0)
+	snap.Shed, _ = c.shared.Incr(ctx, counterBucket, "shed", 0)
+	snap.Abandoned, _ = c.shared.Incr(ctx, counterBucket, "abandoned", 0)
+	if recs, err := c.shared.List(ctx, modelsBucket, "", 0); err == nil {
+		for _, rec := range recs {
+			snap.PerModel[rec.Key], _ = c.shared.Incr(ctx, counterBucket, "model:"+rec.Key, 0)
+		}
+	}
+	if recs, err := c.shared.List(ctx, tenantsBucket, "", 0); err == nil && len(recs) > 0 {
+		snap.PerTenant = make(map[string]TenantTraffic, len(recs))
+		for _, rec := range recs {
+			var t TenantTraffic
+			t.BytesIn, _ = c.shared.Incr(ctx, counterBucket, "tenant_in:"+rec.Key, 0)
+			t.BytesOut, _ = c.shared.Incr(ctx, counterBucket, "tenant_out:"+rec.Key, 0)
+			snap.PerTenant[rec.Key] = t
+		}
+	}
+	return snap
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryStore is the default backend: everything in process memory. It
+// exists for development and for installs that don't care about
+// persistence across restarts.
+type memoryStore struct {
+	mu       sync.RWMutex
+	buckets  map[string]map[string]Record
+	counters map[string]int64
+	leases   map[string]lease
+}
+
+type lease struct {
+	holder  string
+	expires time.Time
+}
+
+// NewMemory returns an empty in-memory store.
+func NewMemory() Store {
+	return &memoryStore{
+		buckets:  make(map[string]map[string]Record),
+		counters: make(map[string]int64),
+		leases:   make(map[string]lease),
+	}
+}
+
+func (m *memoryStore) Put(ctx context.Context, bucket, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.buckets[bucket]
+	if !ok {
+		b = make(map[string]Record)
+		m.buckets[bucket] = b
+	}
+	b[key] = Record{Key: key, Value: append([]byte(nil), value...), UpdatedAt: time.Now().UTC()}
+	return nil
+}
+
+func (m *memoryStore) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rec, ok := m.buckets[bucket][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), rec.Value...), nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, bucket, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.buckets[bucket], key)
+	return nil
+}
+
+func (m *memoryStore) List(ctx context.Context, bucket, prefix string, limit int) ([]Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []Record
+	for key, rec := range m.buckets[bucket] {
+		if strings.HasPrefix(key, prefix) {
+			rec.Value = append([]byte(nil), rec.Value...)
+			out = append(out, rec)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (m *memoryStore) Incr(ctx context.Context, bucket, key string, delta int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := bucket + ":" + key
+	m.counters[k] += delta
+	return m.counters[k], nil
+}
+
+func (m *memoryStore) Lease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if l, ok := m.leases[name]; ok && l.holder != holder && l.expires.After(now) {
+		return false, nil
+	}
+	m.leases[name] = lease{holder: holder, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (m *memoryStore) Ping(ctx context.Context) error { return nil }
+
+func (m *memoryStore) Close() error { return nil }
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Schema changes for the SQL backends ship as embedded, versioned
+// migrations instead of hand-applied SQL. The list is append-only: a
+// released step never changes, new steps go on the end with the next
+// version. Opening the store applies anything pending, and the
+// "migrate" subcommand does the same without starting the service.
+//
+// Steps use CREATE ... IF NOT EXISTS where possible so installs that
+// predate the version table adopt it cleanly: they read as version 0
+// and replay step 1 as a no-op.
+type migration struct {
+	version int
+	sql     string
+}
+
+var sqliteMigrations = []migration{
+	{1, sqliteSchema},
+}
+
+var postgresMigrations = []migration{
+	{1, postgresSchema},
+}
+
+const versionSchema = `
+CREATE TABLE IF NOT EXISTS nfws_schema_version (
+	version INTEGER PRIMARY KEY
+);`
+
+// Placeholder syntax differs per dialect, so each backend passes its
+// own version-recording statement.
+const (
+	sqliteRecordVersion   = `INSERT INTO nfws_schema_version (version) VALUES (?)`
+	postgresRecordVersion = `INSERT INTO nfws_schema_version (version) VALUES ($1)`
+)
+
+// migrate brings db up to the newest schema version, applying each
+// pending step in its own transaction, and returns the version reached.
+func migrate(db *sql.DB, steps []migration, recordVersion string) (int, error) {
+	if _, err := db.Exec(versionSchema); err != nil {
+		return 0, fmt.Errorf("store: creating schema version table: %w", err)
+	}
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM nfws_schema_version`).Scan(&current); err != nil {
+		return 0, fmt.Errorf("store: reading schema version: %w", err)
+	}
+	for _, m := range steps {
+		if m.version <= current {
+			continue
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return current, err
+		}
+		if _, err := tx.Exec(m.sql); err != nil {
+			tx.Rollback()
+			return current, fmt.Errorf("store: applying schema migration %d: %w", m.version, err)
+		}
+		if _, err := tx.Exec(recordVersion, m.version); err != nil {
+			tx.Rollback()
+			return current, fmt.Errorf("store: recording schema migration %d: %w", m.version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return current, err
+		}
+		current = m.version
+	}
+	return current, nil
+}
+
+// Migrate brings the schema for dsn up to date without opening the full
+// service, for init containers and release pipelines. It returns the
+// schema version reached; DSNs without a schema (memory, Redis) return
+// 0 with no error.
+func Migrate(dsn string) (int, error) {
+	switch {
+	case dsn == "" || dsn == "memory://" || strings.HasPrefix(dsn, "redis://"):
+		return 0, nil
+	case strings.HasPrefix(dsn, "sqlite://"), strings.HasPrefix(dsn, "postgres://"):
+		st, err := Open(dsn)
+		if err != nil {
+			return 0, err
+		}
+		defer st.Close()
+		switch s := st.(type) {
+		case *sqliteStore:
+			return migrate(s.db, sqliteMigrations, sqliteRecordVersion)
+		case *postgresStore:
+			return migrate(s.db, postgresMigrations, postgresRecordVersion)
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("store: unsupported DSN %q", dsn)
+	}
+}
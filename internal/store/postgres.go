@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// postgresStore is the shared SQL backend. Schema mirrors the SQLite
+// backend so the two stay behaviour-compatible.
+type postgresStore struct {
+	db *sql.DB
+}
+
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS nfws_kv (
+	bucket     TEXT NOT NULL,
+	key        TEXT NOT NULL,
+	value      BYTEA NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (bucket, key)
+);
+CREATE TABLE IF NOT EXISTS nfws_counters (
+	bucket TEXT NOT NULL,
+	key    TEXT NOT NULL,
+	value  BIGINT NOT NULL,
+	PRIMARY KEY (bucket, key)
+);
+CREATE TABLE IF NOT EXISTS nfws_leases (
+	name       TEXT PRIMARY KEY,
+	holder     TEXT NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL
+);`
+
+func openPostgres(dsn string) (Store, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("store: opening postgres: %w", err)
+	}
+	if _, err := migrate(db, postgresMigrations, postgresRecordVersion); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &postgresStore{db: db}, nil
+}
+
+func (s *postgresStore) Put(ctx context.Context, bucket, key string, value []byte) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO nfws_kv (bucket, key, value, updated_at) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (bucket, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		bucket, key, value, time.Now().UTC())
+	return err
+}
+
+func (s *postgresStore) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	var value []byte
+	err := s.db.QueryRowContext(ctx,
+		`SELECT value FROM nfws_kv WHERE bucket = $1 AND key = $2`, bucket, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (s *postgresStore) Delete(ctx context.Context, bucket, key string) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM nfws_kv WHERE bucket = $1 AND key = $2`, bucket, key)
+	return err
+}
+
+func (s *postgresStore) List(ctx context.Context, bucket, prefix string, limit int) ([]Record, error) {
+	query := `SELECT key, value, updated_at FROM nfws_kv
+		WHERE bucket = $1 AND substr(key, 1, $2) = $3 ORDER BY key`
+	args := []any{bucket, len(prefix), prefix}
+	if limit > 0 {
+		query += ` LIMIT $4`
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Record
+	for rows.Next() {
+		var rec Record
+		if err := rows.Scan(&rec.Key, &rec.Value, &rec.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func (s *postgresStore) Incr(ctx context.Context, bucket, key string, delta int64) (int64, error) {
+	var value int64
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO nfws_counters (bucket, key, value) VALUES ($1, $2, $3)
+		 ON CONFLICT (bucket, key) DO UPDATE SET value = nfws_counters.value + $3
+		 RETURNING value`,
+		bucket, key, delta).Scan(&value)
+	return value, err
+}
+
+func (s *postgresStore) Lease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO nfws_leases (name, holder, expires_at) VALUES ($1, $2, $3)
+		 ON CONFLICT (name) DO UPDATE SET holder = $2, expires_at = $3
+		 WHERE nfws_leases.holder = $2 OR nfws_leases.expires_at < $4`,
+		name, holder, now.Add(ttl), now)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *postgresStore) Ping(ctx context.Context) error { return s.db.PingContext(ctx) }
+
+func (s *postgresStore) Close() error { return s.db.Close() }
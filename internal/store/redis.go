@@ -0,0 +1,138 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisStore is the shared backend for horizontally-scaled deployments:
+// every replica pointing at the same Redis sees the same jobs, keys,
+// stats, and counters.
+type redisStore struct {
+	client *redis.Client
+}
+
+// keyPrefix namespaces everything this service writes so a shared Redis
+// can host other tenants.
+const keyPrefix = "nfws:"
+
+func openRedis(dsn string) (Store, error) {
+	opts, err := redis.ParseURL(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &redisStore{client: redis.NewClient(opts)}, nil
+}
+
+// envelope wraps stored values so UpdatedAt survives the round trip.
+type envelope struct {
+	Value     []byte    `json:"v"`
+	UpdatedAt time.Time `json:"t"`
+}
+
+func redisKey(bucket, key string) string { return keyPrefix + bucket + ":" + key }
+
+func (s *redisStore) Put(ctx context.Context, bucket, key string, value []byte) error {
+	data, err := json.Marshal(envelope{Value: value, UpdatedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, redisKey(bucket, key), data, 0).Err()
+}
+
+func (s *redisStore) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	data, err := s.client.Get(ctx, redisKey(bucket, key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	}
+	return env.Value, nil
+}
+
+func (s *redisStore) Delete(ctx context.Context, bucket, key string) error {
+	return s.client.Del(ctx, redisKey(bucket, key)).Err()
+}
+
+func (s *redisStore) List(ctx context.Context, bucket, prefix string, limit int) ([]Record, error) {
+	pattern := redisKey(bucket, prefix) + "*"
+	var out []Record
+	iter := s.client.Scan(ctx, 0, pattern, 0).Iterator()
+	for iter.Next(ctx) {
+		fullKey := iter.Val()
+		data, err := s.client.Get(ctx, fullKey).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue // expired or deleted mid-scan
+		}
+		if err != nil {
+			return nil, err
+		}
+		var env envelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			return nil, err
+		}
+		out = append(out, Record{
+			Key:       strings.TrimPrefix(fullKey, keyPrefix+bucket+":"),
+			Value:     env.Value,
+			UpdatedAt: env.UpdatedAt,
+		})
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *redisStore) Incr(ctx context.Context, bucket, key string, delta int64) (int64, error) {
+	k := keyPrefix + "ctr:" + bucket + ":" + key
+	if delta == 0 {
+		n, err := s.client.Get(ctx, k).Int64()
+		if errors.Is(err, redis.Nil) {
+			return 0, nil
+		}
+		return n, err
+	}
+	return s.client.IncrBy(ctx, k, delta).Result()
+}
+
+func (s *redisStore) Lease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	k := keyPrefix + "lease:" + name
+	ok, err := s.client.SetNX(ctx, k, holder, ttl).Result()
+	if err != nil || ok {
+		return ok, err
+	}
+	current, err := s.client.Get(ctx, k).Result()
+	if errors.Is(err, redis.Nil) {
+		// Expired between SETNX and GET; try once more.
+		return s.client.SetNX(ctx, k, holder, ttl).Result()
+	}
+	if err != nil {
+		return false, err
+	}
+	if current != holder {
+		return false, nil
+	}
+	// Renew our own lease.
+	return true, s.client.Set(ctx, k, holder, ttl).Err()
+}
+
+func (s *redisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+func (s *redisStore) Close() error { return s.client.Close() }
@@ -0,0 +1,211 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Resilient wraps a shared backend (Redis, Postgres) so an outage
+// degrades the node instead of failing it. While the backend is down,
+// operations run against a process-local memory store — rate counters,
+// leases, and stats keep working per node — and a prober redials with
+// jittered exponential backoff until the backend answers again. Writes
+// made locally during an outage are not replayed: for moderation
+// counters and quotas, availability wins over durability.
+//
+// Leases degrade to node-local too, so replicas may briefly elect one
+// leader each during an outage; background jobs prefer duplicate work
+// over none.
+type Resilient struct {
+	backend Store
+	local   Store
+	log     *slog.Logger
+	closed  chan struct{}
+
+	mu       sync.Mutex
+	degraded bool
+	since    time.Time
+	lastErr  error
+}
+
+// Probe pacing: first retry after ~1s, doubling to at most ~30s, each
+// delay jittered by ±50% so replicas don't redial in lockstep.
+const (
+	probeTimeout  = 2 * time.Second
+	probeMinDelay = time.Second
+	probeMaxDelay = 30 * time.Second
+)
+
+// Health is the connection state reported under "store" in /healthz.
+type Health struct {
+	Degraded bool       `json:"degraded"`
+	Since    *time.Time `json:"since,omitempty"`
+	LastErr  string     `json:"last_error,omitempty"`
+}
+
+// NewResilient wraps backend with outage fallback and reconnection.
+func NewResilient(backend Store, log *slog.Logger) *Resilient {
+	return &Resilient{
+		backend: backend,
+		local:   NewMemory(),
+		log:     log,
+		closed:  make(chan struct{}),
+	}
+}
+
+// do runs op against the backend when it is believed healthy, and
+// against the local fallback otherwise. A backend failure (other than
+// the logical ErrNotFound, or the caller's own context expiring) flips
+// to degraded and starts the prober.
+func (s *Resilient) do(ctx context.Context, op func(Store) error) error {
+	if !s.isDegraded() {
+		err := op(s.backend)
+		if err == nil || errors.Is(err, ErrNotFound) || ctx.Err() != nil {
+			return err
+		}
+		s.fail(err)
+	}
+	return op(s.local)
+}
+
+func (s *Resilient) isDegraded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.degraded
+}
+
+// fail records a backend error and, on the first one, starts probing.
+func (s *Resilient) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = err
+	if s.degraded {
+		return
+	}
+	s.degraded = true
+	s.since = time.Now()
+	s.log.Warn("store backend unavailable, degrading to in-memory", "err", err)
+	go s.probe()
+}
+
+// probe redials the backend until it answers or the store is closed.
+func (s *Resilient) probe() {
+	delay := probeMinDelay
+	for {
+		jittered := time.Duration(float64(delay) * (0.5 + rand.Float64()))
+		select {
+		case <-s.closed:
+			return
+		case <-time.After(jittered):
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		err := s.backend.Ping(ctx)
+		cancel()
+		if err == nil {
+			s.restore()
+			return
+		}
+		s.mu.Lock()
+		s.lastErr = err
+		s.mu.Unlock()
+		if delay < probeMaxDelay {
+			delay *= 2
+			if delay > probeMaxDelay {
+				delay = probeMaxDelay
+			}
+		}
+	}
+}
+
+// restore flips back to the backend after a successful probe.
+func (s *Resilient) restore() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.degraded = false
+	s.lastErr = nil
+	s.log.Info("store backend recovered", "outage", time.Since(s.since).Round(time.Second).String())
+}
+
+// Health returns the current connection state.
+func (s *Resilient) Health() Health {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := Health{Degraded: s.degraded}
+	if s.degraded {
+		since := s.since
+		h.Since = &since
+		if s.lastErr != nil {
+			h.LastErr = s.lastErr.Error()
+		}
+	}
+	return h
+}
+
+func (s *Resilient) Put(ctx context.Context, bucket, key string, value []byte) error {
+	return s.do(ctx, func(st Store) error { return st.Put(ctx, bucket, key, value) })
+}
+
+func (s *Resilient) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	var value []byte
+	err := s.do(ctx, func(st Store) error {
+		var err error
+		value, err = st.Get(ctx, bucket, key)
+		return err
+	})
+	return value, err
+}
+
+func (s *Resilient) Delete(ctx context.Context, bucket, key string) error {
+	return s.do(ctx, func(st Store) error { return st.Delete(ctx, bucket, key) })
+}
+
+func (s *Resilient) List(ctx context.Context, bucket, prefix string, limit int) ([]Record, error) {
+	var recs []Record
+	err := s.do(ctx, func(st Store) error {
+		var err error
+		recs, err = st.List(ctx, bucket, prefix, limit)
+		return err
+	})
+	return recs, err
+}
+
+func (s *Resilient) Incr(ctx context.Context, bucket, key string, delta int64) (int64, error) {
+	var n int64
+	err := s.do(ctx, func(st Store) error {
+		var err error
+		n, err = st.Incr(ctx, bucket, key, delta)
+		return err
+	})
+	return n, err
+}
+
+func (s *Resilient) Lease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	var ok bool
+	err := s.do(ctx, func(st Store) error {
+		var err error
+		ok, err = st.Lease(ctx, name, holder, ttl)
+		return err
+	})
+	return ok, err
+}
+
+// Ping reports the degraded state without redialing: the prober owns
+// reconnection, and /healthz should answer fast during an outage.
+func (s *Resilient) Ping(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.degraded {
+		return s.lastErr
+	}
+	return s.backend.Ping(ctx)
+}
+
+func (s *Resilient) Close() error {
+	close(s.closed)
+	s.local.Close()
+	return s.backend.Close()
+}
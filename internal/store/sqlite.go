@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteStore is the embedded backend for single-node installs without
+// Postgres/Redis. One table holds every bucket; the cgo-free driver
+// keeps the binary self-contained.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS nfws_kv (
+	bucket     TEXT NOT NULL,
+	key        TEXT NOT NULL,
+	value      BLOB NOT NULL,
+	updated_at TEXT NOT NULL,
+	PRIMARY KEY (bucket, key)
+);
+CREATE TABLE IF NOT EXISTS nfws_counters (
+	bucket TEXT NOT NULL,
+	key    TEXT NOT NULL,
+	value  INTEGER NOT NULL,
+	PRIMARY KEY (bucket, key)
+);
+CREATE TABLE IF NOT EXISTS nfws_leases (
+	name       TEXT PRIMARY KEY,
+	holder     TEXT NOT NULL,
+	expires_at TEXT NOT NULL
+);`
+
+func openSQLite(path string) (Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("store: sqlite DSN requires a file path")
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("store: opening sqlite %s: %w", path, err)
+	}
+	// SQLite handles one writer at a time; a single connection avoids
+	// SQLITE_BUSY churn under concurrent handlers.
+	db.SetMaxOpenConns(1)
+	if _, err := migrate(db, sqliteMigrations, sqliteRecordVersion); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteStore{db: db}, nil
+}
+
+func (s *sqliteStore) Put(ctx context.Context, bucket, key string, value []byte) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO nfws_kv (bucket, key, value, updated_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT (bucket, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		bucket, key, value, time.Now().UTC().Format(time.RFC3339Nano))
+	return err
+}
+
+func (s *sqliteStore) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	var value []byte
+	err := s.db.QueryRowContext(ctx,
+		`SELECT value FROM nfws_kv WHERE bucket = ? AND key = ?`, bucket, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (s *sqliteStore) Delete(ctx context.Context, bucket, key string) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM nfws_kv WHERE bucket = ? AND key = ?`, bucket, key)
+	return err
+}
+
+func (s *sqliteStore) List(ctx context.Context, bucket, prefix string, limit int) ([]Record, error) {
+	query := `SELECT key, value, updated_at FROM nfws_kv
+		WHERE bucket = ? AND substr(key, 1, ?) = ? ORDER BY key`
+	args := []any{bucket, len(prefix), prefix}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Record
+	for rows.Next() {
+		var rec Record
+		var ts string
+		if err := rows.Scan(&rec.Key, &rec.Value, &ts); err != nil {
+			return nil, err
+		}
+		rec.UpdatedAt, _ = time.Parse(time.RFC3339Nano, ts)
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func (s *sqliteStore) Incr(ctx context.Context, bucket, key string, delta int64) (int64, error) {
+	var value int64
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO nfws_counters (bucket, key, value) VALUES (?1, ?2, ?3)
+		 ON CONFLICT (bucket, key) DO UPDATE SET value = value + ?3
+		 RETURNING value`,
+		bucket, key, delta).Scan(&value)
+	return value, err
+}
+
+func (s *sqliteStore) Lease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO nfws_leases (name, holder, expires_at) VALUES (?1, ?2, ?3)
+		 ON CONFLICT (name) DO UPDATE SET holder = ?2, expires_at = ?3
+		 WHERE nfws_leases.holder = ?2 OR nfws_leases.expires_at < ?4`,
+		name, holder, now.Add(ttl).Format(time.RFC3339Nano), now.Format(time.RFC3339Nano))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *sqliteStore) Ping(ctx context.Context) error { return s.db.PingContext(ctx) }
+
+func (s *sqliteStore) Close() error { return s.db.Close() }
@@ -0,0 +1,78 @@
+// Package store is the persistence layer behind jobs, audit logs, API
+// keys, and stats. The backend is selected by a single DSN so small
+// installs can run on an embedded database while larger ones point the
+// same code at a shared server.
+//
+// The data model is deliberately narrow — namespaced key/value records
+// with JSON payloads — so every backend stays trivial to implement and
+// subsystems don't grow private schemas.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Get for missing keys.
+var ErrNotFound = errors.New("store: not found")
+
+// Record is one stored entry.
+type Record struct {
+	Key       string
+	Value     []byte
+	UpdatedAt time.Time
+}
+
+// Store is the backend interface. Buckets namespace keys per subsystem
+// ("jobs", "audit", "apikeys", "stats", ...).
+type Store interface {
+	Put(ctx context.Context, bucket, key string, value []byte) error
+	Get(ctx context.Context, bucket, key string) ([]byte, error)
+	Delete(ctx context.Context, bucket, key string) error
+	// List returns records in bucket whose key has the given prefix, in
+	// key order, at most limit entries (limit <= 0 means no cap).
+	List(ctx context.Context, bucket, prefix string, limit int) ([]Record, error)
+	// Incr atomically adds delta to an integer counter and returns the
+	// new value. A delta of 0 reads the counter. Counters share the
+	// bucket namespace with Put/Get but are not interchangeable with
+	// them.
+	Incr(ctx context.Context, bucket, key string, delta int64) (int64, error)
+	// Lease tries to acquire or renew the named lease for holder. It
+	// returns true when holder owns the lease for the next ttl; a lease
+	// held by someone else is only taken over after it expires. Leader
+	// election between replicas builds on this.
+	Lease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// Open selects a backend from the DSN:
+//
+//	""               in-process memory (default; lost on restart)
+//	memory://        same, explicit
+//	sqlite://PATH    embedded SQLite database file
+//	redis://...      shared Redis, for multi-replica deployments
+//	postgres://...   shared Postgres, for multi-replica deployments
+func Open(dsn string) (Store, error) {
+	switch {
+	case dsn == "" || dsn == "memory://":
+		return NewMemory(), nil
+	case strings.HasPrefix(dsn, "sqlite://"):
+		return openSQLite(strings.TrimPrefix(dsn, "sqlite://"))
+	case strings.HasPrefix(dsn, "redis://"):
+		return openRedis(dsn)
+	case strings.HasPrefix(dsn, "postgres://"):
+		return openPostgres(dsn)
+	default:
+		return nil, fmt.Errorf("store: unsupported DSN %q (expected memory://, sqlite://PATH, redis://..., or postgres://...)", dsn)
+	}
+}
+
+// Shared reports whether the DSN points at a backend shared between
+// replicas (as opposed to process- or node-local state).
+func Shared(dsn string) bool {
+	return strings.HasPrefix(dsn, "redis://") || strings.HasPrefix(dsn, "postgres://")
+}